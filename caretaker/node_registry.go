@@ -876,8 +876,8 @@ func (r *DataNodeRegistry) updateTabletList(parentCtx context.Context) {
 /*
 registryMaintenance runs regularly to perform various maintenance
 operations on the known tables and nodes, such as updating the list of
-known nodes and discovering and reassigning tablets which aren't covered
-by anybody.
+known nodes, discovering and reassigning tablets which aren't covered
+by anybody and merging tablets which have become too small.
 */
 func (r *DataNodeRegistry) registryMaintenance() {
 	for {
@@ -887,6 +887,7 @@ func (r *DataNodeRegistry) registryMaintenance() {
 
 		r.updateNodeList(ctx)
 		r.updateTabletList(ctx)
+		r.mergeSmallTablets(ctx)
 
 		runTime = time.Now().Sub(startTime)
 		numMaintenanceRuns.Inc()
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"context"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
+)
+
+var numTabletMerges = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "caretaker_node_registry",
+	Name:      "num_tablet_merges",
+	Help:      "Number of times two adjacent tablets were merged into one",
+})
+var numTabletMergeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "caretaker_node_registry",
+	Name:      "num_tablet_merge_errors",
+	Help:      "Number of times two adjacent tablets failed to be merged",
+}, []string{"error_class"})
+
+func init() {
+	prometheus.MustRegister(numTabletMerges)
+	prometheus.MustRegister(numTabletMergeErrors)
+}
+
+/*
+mergeSizeFraction determines how small two adjacent tablets have to be in
+order to be merged: their combined size must not exceed the split size of
+the table divided by this number. This leaves enough room for the merged
+tablet to grow before it would be split again.
+*/
+const mergeSizeFraction = 4
+
+/*
+TabletSlice represents a slice of ServerTabletMetadata objects which can be
+sorted by their start keys.
+*/
+type TabletSlice []*redcloud.ServerTabletMetadata
+
+/*
+Len returns the number of tablets in the slice.
+*/
+func (t TabletSlice) Len() int {
+	return len(t)
+}
+
+/*
+Less determines whether the i-th tablet in TabletSlice starts before the
+j-th.
+*/
+func (t TabletSlice) Less(i, j int) bool {
+	return bytes.Compare(t[i].StartKey, t[j].StartKey) < 0
+}
+
+/*
+Swap swaps the two slice entries with the numbers i and j.
+*/
+func (t TabletSlice) Swap(i, j int) {
+	var tablet = t[i]
+	t[i] = t[j]
+	t[j] = tablet
+}
+
+/*
+tabletSize determines the combined size of all major and minor sstables and
+journals of the specified tablet, as last recorded in etcd.
+*/
+func tabletSize(tablet *redcloud.ServerTabletMetadata) int64 {
+	var pathdesc *redcloud.SSTablePathDescription
+	var size int64
+
+	for _, pathdesc = range tablet.SstablePath {
+		size += pathdesc.MajorSstableSize + pathdesc.MinorSstableSize +
+			pathdesc.JournalSize
+	}
+
+	return size
+}
+
+/*
+mergeSmallTablets finds adjacent tablets of the same table whose combined
+size is well below the split size of the table, and merges them into a
+single tablet.
+*/
+func (r *DataNodeRegistry) mergeSmallTablets(parentCtx context.Context) {
+	var ctx context.Context
+	var span *trace.Span
+	var tables map[string]*redcloud.ServerTableMetadata
+	var table *redcloud.ServerTableMetadata
+	var numMerged int64
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataNodeRegistry/mergeSmallTablets")
+	defer span.End()
+
+	if tables, err = r.GetTableList(ctx); err != nil {
+		log.Print("Error fetching table list: ", err)
+		return
+	}
+
+	for _, table = range tables {
+		var tablets TabletSlice
+		var i int
+
+		// Tables without a split size never get split, so never merge them.
+		if table.TableMd == nil || table.TableMd.SplitSize <= 0 {
+			continue
+		}
+
+		tablets = append(tablets, table.Tablet...)
+		sort.Sort(tablets)
+
+		for i = 0; i+1 < len(tablets); i++ {
+			var a = tablets[i]
+			var b = tablets[i+1]
+
+			// Only tablets sharing a boundary can be merged.
+			if len(a.EndKey) == 0 || !bytes.Equal(a.EndKey, b.StartKey) {
+				continue
+			}
+
+			if (tabletSize(a)+tabletSize(b))*mergeSizeFraction >
+				table.TableMd.SplitSize {
+				continue
+			}
+
+			if err = r.mergeTablets(ctx, table.Name, a, b); err != nil {
+				span.Annotate(
+					[]trace.Attribute{
+						trace.StringAttribute("error", err.Error()),
+						trace.StringAttribute("table", table.Name),
+						trace.StringAttribute("end-key", string(b.EndKey)),
+					}, "Tablet merge failed")
+				log.Print("Could not merge tablets of ", table.Name, " ending at ",
+					a.EndKey, " and ", b.EndKey, ": ", err)
+				continue
+			}
+
+			numMerged++
+
+			// The merged tablet will be considered again in the next run.
+			i++
+		}
+	}
+
+	span.AddAttributes(
+		trace.Int64Attribute("tablets-merged", numMerged))
+}
+
+/*
+mergeTablets merges the two adjacent tablets a and b of the specified table
+into a single tablet. Both tablets are released from their data nodes and
+the merged tablet is then served by the data node which held a, which will
+combine the sstables of both tablets. If the merged tablet cannot be served,
+the original tablets are handed back to their data nodes.
+*/
+func (r *DataNodeRegistry) mergeTablets(
+	parentCtx context.Context, table string,
+	a, b *redcloud.ServerTabletMetadata) error {
+	var ctx context.Context
+	var span *trace.Span
+	var clientCtx context.Context
+	var cancel context.CancelFunc
+	var tablets = []*redcloud.ServerTabletMetadata{a, b}
+	var nodes []*DataNode
+	var releases []*redcloud.RangeReleaseResponse
+	var tablet *redcloud.ServerTabletMetadata
+	var node *DataNode
+	var rsr = &redcloud.RangeServingRequest{
+		Table:    table,
+		StartKey: a.StartKey,
+		EndKey:   b.EndKey,
+	}
+	var ts redcloud.DataNodeMetadataServiceClient
+	var i int
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataNodeRegistry/mergeTablets")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", table),
+		/* TODO: escape this somehow */
+		trace.StringAttribute("end-key", string(b.EndKey)))
+
+	// Leave tablets of unhealthy nodes to updateTabletList.
+	for _, tablet = range tablets {
+		node = r.GetNodeByAddress(ctx, net.JoinHostPort(
+			tablet.Host, strconv.Itoa(int(tablet.Port))))
+		if node == nil || !node.IsHealthy() {
+			numTabletMergeErrors.With(
+				prometheus.Labels{"error_class": "node_unhealthy"}).Inc()
+			return fmt.Errorf("Tablet ending at %v is not held by a healthy node",
+				tablet.EndKey)
+		}
+		nodes = append(nodes, node)
+	}
+
+	clientCtx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	for i, tablet = range tablets {
+		var resp *redcloud.RangeReleaseResponse
+
+		ts = redcloud.NewDataNodeMetadataServiceClient(nodes[i].Connection())
+		span.Annotate(nil, "Sending ReleaseRange RPC to "+nodes[i].Address())
+		if resp, err = ts.ReleaseRange(clientCtx, &redcloud.RangeReleaseRequest{
+			Table:    table,
+			StartKey: tablet.StartKey,
+			EndKey:   tablet.EndKey,
+		}, grpc.FailFast(true)); err != nil {
+			numTabletMergeErrors.With(
+				prometheus.Labels{"error_class": "release_failed"}).Inc()
+			r.restoreTablets(ctx, table, tablets[:i], nodes[:i], releases)
+			return err
+		}
+
+		releases = append(releases, resp)
+		rsr.Paths = append(rsr.Paths, resp.Paths...)
+	}
+
+	ts = redcloud.NewDataNodeMetadataServiceClient(nodes[0].Connection())
+	span.Annotate(nil, "Sending ServeRange RPC to "+nodes[0].Address())
+	if _, err = ts.ServeRange(clientCtx, rsr, grpc.FailFast(true)); err != nil {
+		numTabletMergeErrors.With(
+			prometheus.Labels{"error_class": "server_refused"}).Inc()
+		r.restoreTablets(ctx, table, tablets, nodes, releases)
+		return err
+	}
+
+	span.Annotate(nil, "Tablets merged")
+	numTabletMerges.Inc()
+	return nil
+}
+
+/*
+restoreTablets hands the specified tablets back to the data nodes they were
+released from after a failed merge, along with the sstables they reported.
+*/
+func (r *DataNodeRegistry) restoreTablets(
+	parentCtx context.Context, table string,
+	tablets []*redcloud.ServerTabletMetadata, nodes []*DataNode,
+	releases []*redcloud.RangeReleaseResponse) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var tablet *redcloud.ServerTabletMetadata
+	var i int
+	var err error
+
+	ctx, cancel = context.WithTimeout(parentCtx, time.Minute)
+	defer cancel()
+
+	for i, tablet = range tablets {
+		var ts = redcloud.NewDataNodeMetadataServiceClient(
+			nodes[i].Connection())
+
+		if _, err = ts.ServeRange(ctx, &redcloud.RangeServingRequest{
+			Table:    table,
+			StartKey: tablet.StartKey,
+			EndKey:   tablet.EndKey,
+			Paths:    releases[i].Paths,
+		}, grpc.FailFast(true)); err != nil {
+			log.Print("Could not restore tablet of ", table, " ending at ",
+				tablet.EndKey, " on ", nodes[i].Address(), ": ", err)
+			numTabletMergeErrors.With(
+				prometheus.Labels{"error_class": "restore_failed"}).Inc()
+		}
+	}
+}
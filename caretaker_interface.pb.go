@@ -15,9 +15,31 @@ It has these top-level messages:
 	TableName
 	GetRequest
 	ColumnRange
+	GetRowRequest
+	ColumnFamilySelection
 	GetRangeRequest
+	RowFilter
+	FamilyColumns
+	Row
+	GetRangeResumeToken
+	GetRangePageResponse
 	InsertRequest
+	InsertResponse
+	DeleteRequest
+	IncrementRequest
+	CheckAndInsertRequest
+	CheckAndInsertResponse
+	StreamInsertCheckpoint
+	Mutation
+	RowMutation
+	BatchMutateRequest
+	CellReference
+	MultiGetRequest
+	MultiGetResult
+	MultiGetResponse
 	SSTablePathDescription
+	BloomFilterData
+	KeyIndexData
 	ServerTabletMetadata
 	ColumnFamilyMetadata
 	TableMetadata
@@ -30,6 +52,8 @@ It has these top-level messages:
 	Column
 	ColumnSet
 	ColumnFamily
+	SSTableBlock
+	SSTableBlockData
 */
 package redcloud
 
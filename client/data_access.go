@@ -1,12 +1,15 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -16,6 +19,7 @@ import (
 	etcd "go.etcd.io/etcd/clientv3"
 	"go.opencensus.io/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 )
 
@@ -77,13 +81,38 @@ parts of the specified key range.
 func (d *DataAccessClient) getRangeClients(
 	ctx context.Context, table string, keyRange *common.KeyRange,
 	forceFetch bool) ([]*grpc.ClientConn, error) {
+	var covers []*krClientConn
+	var cover *krClientConn
+	var rv = make([]*grpc.ClientConn, 0)
+	var err error
+
+	if covers, err = d.getRangeCovers(
+		ctx, table, keyRange, forceFetch); err != nil {
+		return rv, err
+	}
+
+	for _, cover = range covers {
+		rv = append(rv, cover.ClientConn)
+	}
+
+	return rv, nil
+}
+
+/*
+getRangeCovers finds the key ranges of all tablets holding parts of the
+specified key range, along with the gRPC client connections for the data
+nodes serving them.
+*/
+func (d *DataAccessClient) getRangeCovers(
+	ctx context.Context, table string, keyRange *common.KeyRange,
+	forceFetch bool) ([]*krClientConn, error) {
 	var span = trace.FromContext(ctx)
 	var dialOpts []grpc.DialOption
 	var resp *etcd.GetResponse
 	var rangeClients []*krClientConn
 	var md *redcloud.ServerTableMetadata
 	var td *redcloud.ServerTabletMetadata
-	var rv = make([]*grpc.ClientConn, 0)
+	var rv = make([]*krClientConn, 0)
 	var ok bool
 	var err error
 
@@ -108,7 +137,7 @@ func (d *DataAccessClient) getRangeClients(
 			var krcli *krClientConn
 			for _, krcli = range rangeClients {
 				if keyRange.ContainsRange(krcli.KeyRange) {
-					rv = append(rv, krcli.ClientConn)
+					rv = append(rv, krcli)
 				}
 			}
 
@@ -132,18 +161,18 @@ func (d *DataAccessClient) getRangeClients(
 		ctx, common.EtcdTableConfigPath(d.instance, table)); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Error communicating with etcd")
-		return []*grpc.ClientConn{}, err
+		return []*krClientConn{}, err
 	}
 
 	if len(resp.Kvs) == 0 {
 		span.Annotate(nil, "No data nodes assigned to table")
-		return []*grpc.ClientConn{}, ErrNoDataNodes
+		return []*krClientConn{}, ErrNoDataNodes
 	}
 
 	if err = proto.Unmarshal(resp.Kvs[0].Value, md); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Table metadata corruption detected")
-		return []*grpc.ClientConn{}, err
+		return []*krClientConn{}, err
 	}
 
 	// Time to take the write lock and fill the cache.
@@ -158,6 +187,7 @@ func (d *DataAccessClient) getRangeClients(
 		var hostPort = net.JoinHostPort(
 			td.Host, strconv.FormatInt(int64(td.Port), 10))
 		var client *grpc.ClientConn
+		var cover *krClientConn
 
 		if client, ok = d.clientConnCache[hostPort]; !ok {
 			if client, err = grpc.Dial(hostPort, dialOpts...); err != nil {
@@ -165,24 +195,56 @@ func (d *DataAccessClient) getRangeClients(
 					trace.StringAttribute("failing-host", hostPort),
 					trace.StringAttribute("error", err.Error()))
 				span.Annotate(nil, "Data node connection failed")
-				return []*grpc.ClientConn{}, err
+				return []*krClientConn{}, err
 			}
 		}
 
+		cover = &krClientConn{
+			KeyRange:   kr,
+			ClientConn: client,
+		}
 		d.dataNodeRangeCache[table] = append(
-			d.dataNodeRangeCache[table], &krClientConn{
-				KeyRange:   kr,
-				ClientConn: client,
-			})
+			d.dataNodeRangeCache[table], cover)
 
 		if keyRange.ContainsRange(kr) {
-			rv = append(rv, client)
+			rv = append(rv, cover)
 		}
 	}
 
+	/*
+		Tablets are listed in the order they were loaded in, so split tablets
+		may appear anywhere in the table metadata. Keep the covers in key
+		order, so scans can visit them one after the other.
+	*/
+	sortCovers(d.dataNodeRangeCache[table])
+	sortCovers(rv)
+
 	return rv, nil
 }
 
+/*
+sortCovers sorts the range covers by the start keys of their key ranges.
+*/
+func sortCovers(covers []*krClientConn) {
+	sort.Slice(covers, func(i, j int) bool {
+		return bytes.Compare(covers[i].KeyRange.StartKey,
+			covers[j].KeyRange.StartKey) < 0
+	})
+}
+
+/*
+reverseClients reverses the order of the client connections in place, so
+the tablets of a key range are visited starting from the end of the range.
+This relies on getRangeCovers returning the covers in key order.
+*/
+func reverseClients(conns []*grpc.ClientConn) {
+	var i int
+
+	for i = 0; i < len(conns)/2; i++ {
+		conns[i], conns[len(conns)-1-i] = conns[len(conns)-1-i], conns[i]
+	}
+}
+
 /*
 Get requests the latest version of a single key of data from the specified
 column path (table, row, column family, column).
@@ -239,6 +301,204 @@ func (d *DataAccessClient) Get(
 	}
 }
 
+/*
+GetRow requests the latest versions of the selected columns from several
+column families of a single row.
+*/
+func (d *DataAccessClient) GetRow(
+	parentCtx context.Context, req *redcloud.GetRowRequest,
+	opts ...grpc.CallOption) (*redcloud.Row, error) {
+	// The key range is just 1 key wide.
+	var kr = common.NewKeyRange(req.Key, req.Key)
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataAccessClient/GetRow")
+	defer span.End()
+
+	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
+		return nil, err
+	} else if len(conns) > 1 {
+		log.Printf("Error: multiple data nodes registered for key? %v", req)
+	}
+
+	for {
+		for _, conn = range conns {
+			var dnsc = redcloud.NewDataNodeServiceClient(conn)
+			var row *redcloud.Row
+
+			if row, err = dnsc.GetRow(
+				ctx, req, opts...); err == common.ErrTabletNotLoaded {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the key and retry.
+				if conns, err = d.getRangeClients(
+					ctx, req.Table, kr, true); err != nil {
+					return nil, err
+				}
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Error communicating with data node")
+				return nil, err
+			} else {
+				return row, nil
+			}
+		}
+
+		if ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/*
+MultiGet requests the latest versions of a number of individual cells at
+once. The cells are grouped by the data nodes holding them, which are
+queried in parallel. The results are returned in the same order as the
+cells of the request; cells which hold no data have no column set.
+*/
+func (d *DataAccessClient) MultiGet(
+	parentCtx context.Context, req *redcloud.MultiGetRequest,
+	opts ...grpc.CallOption) (*redcloud.MultiGetResponse, error) {
+	var resp = &redcloud.MultiGetResponse{
+		Result: make([]*redcloud.MultiGetResult, len(req.Cell)),
+	}
+	var pending []int
+	var forceFetch bool
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+	var i int
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataAccessClient/MultiGet")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.Int64Attribute("num-cells", int64(len(req.Cell))))
+
+	for i = range req.Cell {
+		pending = append(pending, i)
+	}
+
+	for len(pending) > 0 {
+		var batches = make(map[*grpc.ClientConn]*redcloud.MultiGetRequest)
+		var positions = make(map[*grpc.ClientConn][]int)
+		var conns []*grpc.ClientConn
+		var responses []*redcloud.MultiGetResponse
+		var errs []error
+		var retry []int
+		var conn *grpc.ClientConn
+		var wg sync.WaitGroup
+		var j int
+
+		// Group the cells by the data nodes holding them.
+		for _, i = range pending {
+			var cell = req.Cell[i]
+			var kr = common.NewKeyRange(cell.Key, cell.Key)
+			var cellConns []*grpc.ClientConn
+			var batch *redcloud.MultiGetRequest
+			var ok bool
+
+			if cellConns, err = d.getRangeClients(
+				ctx, req.Table, kr, forceFetch); err != nil {
+				return nil, err
+			}
+
+			if len(cellConns) == 0 {
+				span.Annotate(nil, "No range cover registered for key")
+				return nil, ErrNoDataNodes
+			} else if len(cellConns) > 1 {
+				span.AddAttributes(trace.Int64Attribute(
+					"num-range-covers", int64(len(cellConns))))
+				span.Annotate(
+					nil, "More than one range cover registered for a single key")
+				return nil, fmt.Errorf(
+					"Error: multiple data nodes registered for key? %v", cell.Key)
+			}
+
+			if batch, ok = batches[cellConns[0]]; !ok {
+				batch = &redcloud.MultiGetRequest{Table: req.Table}
+				batches[cellConns[0]] = batch
+				conns = append(conns, cellConns[0])
+			}
+			batch.Cell = append(batch.Cell, cell)
+			positions[cellConns[0]] = append(positions[cellConns[0]], i)
+
+			// The cache only needs to be refreshed once per round.
+			forceFetch = false
+		}
+
+		span.AddAttributes(
+			trace.Int64Attribute("num-data-nodes", int64(len(conns))))
+
+		responses = make([]*redcloud.MultiGetResponse, len(conns))
+		errs = make([]error, len(conns))
+		for j, conn = range conns {
+			wg.Add(1)
+			go func(j int, conn *grpc.ClientConn) {
+				var dnsc = redcloud.NewDataNodeServiceClient(conn)
+
+				defer wg.Done()
+				responses[j], errs[j] = dnsc.MultiGet(
+					ctx, batches[conn], opts...)
+			}(j, conn)
+		}
+		wg.Wait()
+
+		for j, conn = range conns {
+			var result *redcloud.MultiGetResult
+			var k int
+
+			if grpc.Code(errs[j]) == codes.Unavailable {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the cells and retry.
+				retry = append(retry, positions[conn]...)
+				continue
+			} else if errs[j] != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", errs[j].Error()))
+				span.Annotate(nil, "Data node communication error")
+				return nil, errs[j]
+			}
+
+			if len(responses[j].Result) != len(positions[conn]) {
+				span.Annotate(nil, "Unexpected number of results")
+				return nil, fmt.Errorf(
+					"Data node returned %d results for %d cells",
+					len(responses[j].Result), len(positions[conn]))
+			}
+
+			for k, result = range responses[j].Result {
+				if result.TabletNotLoaded {
+					retry = append(retry, positions[conn][k])
+				} else {
+					resp.Result[positions[conn][k]] = result
+				}
+			}
+		}
+
+		pending = retry
+		forceFetch = true
+
+		// Check TTL / RPC cancelled.
+		if len(pending) > 0 && ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return nil, ctx.Err()
+		}
+	}
+
+	return resp, nil
+}
+
 /*
 GetRange requests all versions of a key range of data from the specified
 column paths (table, row, column family, columns).
@@ -260,6 +520,11 @@ func (d *DataAccessClient) GetRange(
 		return err
 	}
 
+	// Visit the tablets from the end of the range for reverse scans.
+	if req.Reverse {
+		reverseClients(conns)
+	}
+
 	for _, conn = range conns {
 		var dnsc = redcloud.NewDataNodeServiceClient(conn)
 		var rstream redcloud.DataNodeService_GetRangeClient
@@ -272,13 +537,13 @@ func (d *DataAccessClient) GetRange(
 
 		for {
 			var colset *redcloud.ColumnSet
-			if colset, err = rstream.Recv(); err != nil {
+			if colset, err = rstream.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
 				span.AddAttributes(
 					trace.StringAttribute("error", err.Error()))
 				span.Annotate(nil, "Data node result stream error")
 				return err
-			} else if colset == nil {
-				break
 			}
 			resp <- colset
 		}
@@ -288,18 +553,132 @@ func (d *DataAccessClient) GetRange(
 	return nil
 }
 
+/*
+GetRangeRows requests all versions of a key range of data from the specified
+column paths (table, row, column family, columns), like GetRange, but
+reports the data grouped by row along with the row key. The rows of each
+tablet are reported in key order.
+*/
+func (d *DataAccessClient) GetRangeRows(
+	parentCtx context.Context, req *redcloud.GetRangeRequest,
+	resp chan *redcloud.ColumnFamily, opts ...grpc.CallOption) error {
+	var kr = common.NewKeyRange(req.StartKey, req.EndKey)
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataAccessClient/GetRangeRows")
+	defer span.End()
+
+	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
+		return err
+	}
+
+	// Visit the tablets from the end of the range for reverse scans.
+	if req.Reverse {
+		reverseClients(conns)
+	}
+
+	for _, conn = range conns {
+		var dnsc = redcloud.NewDataNodeServiceClient(conn)
+		var rstream redcloud.DataNodeService_GetRangeRowsClient
+
+		if rstream, err = dnsc.GetRangeRows(ctx, req, opts...); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Data node communication error")
+			return err
+		}
+
+		for {
+			var row *redcloud.ColumnFamily
+			if row, err = rstream.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node result stream error")
+				return err
+			}
+			resp <- row
+		}
+	}
+
+	span.Annotate(nil, "Result stream complete")
+	return nil
+}
+
+/*
+GetRangeFamilies requests all versions of a key range of data from several
+column families at a time. The data of each row is reported merged across
+all requested column families. The rows of each tablet are reported in key
+order.
+*/
+func (d *DataAccessClient) GetRangeFamilies(
+	parentCtx context.Context, req *redcloud.GetRangeRequest,
+	resp chan *redcloud.Row, opts ...grpc.CallOption) error {
+	var kr = common.NewKeyRange(req.StartKey, req.EndKey)
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataAccessClient/GetRangeFamilies")
+	defer span.End()
+
+	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
+		return err
+	}
+
+	// Visit the tablets from the end of the range for reverse scans.
+	if req.Reverse {
+		reverseClients(conns)
+	}
+
+	for _, conn = range conns {
+		var dnsc = redcloud.NewDataNodeServiceClient(conn)
+		var rstream redcloud.DataNodeService_GetRangeFamiliesClient
+
+		if rstream, err = dnsc.GetRangeFamilies(ctx, req, opts...); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Data node communication error")
+			return err
+		}
+
+		for {
+			var row *redcloud.Row
+			if row, err = rstream.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node result stream error")
+				return err
+			}
+			resp <- row
+		}
+	}
+
+	span.Annotate(nil, "Result stream complete")
+	return nil
+}
+
 /*
 Insert requests to place a new version of a column into the database.
 The destination of the column must be specified as a
-(table, row, column family, column) tuple.
+(table, row, column family, column) tuple. The response holds the timestamp
+the column was written with, which may have been assigned by the data node.
 */
 func (d *DataAccessClient) Insert(
 	parentCtx context.Context, req *redcloud.InsertRequest,
-	opts ...grpc.CallOption) error {
+	opts ...grpc.CallOption) (*redcloud.InsertResponse, error) {
 	// The key range is just 1 key wide.
 	var kr = common.NewKeyRange(req.Key, req.Key)
 	var conns []*grpc.ClientConn
 	var conn *grpc.ClientConn
+	var resp *redcloud.InsertResponse
 	var ctx context.Context
 	var span *trace.Span
 	var err error
@@ -308,7 +687,7 @@ func (d *DataAccessClient) Insert(
 	defer span.End()
 
 	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(conns) > 1 {
@@ -316,29 +695,29 @@ func (d *DataAccessClient) Insert(
 			trace.Int64Attribute("num-range-covers", int64(len(conns))))
 		span.Annotate(
 			nil, "More than one range cover registered for a single key")
-		return fmt.Errorf("Error: multiple data nodes registered for key? %v",
-			req)
+		return nil, fmt.Errorf(
+			"Error: multiple data nodes registered for key? %v", req)
 	}
 
 	for {
 		for _, conn = range conns {
 			var dnsc = redcloud.NewDataNodeServiceClient(conn)
 
-			if _, err = dnsc.Insert(
+			if resp, err = dnsc.Insert(
 				ctx, req, opts...); err == common.ErrTabletNotLoaded {
 				span.Annotate(nil, "Tablet not loaded")
 				// Refresh data nodes covering the key and retry.
 				if conns, err = d.getRangeClients(
 					ctx, req.Table, kr, true); err != nil {
-					return err
+					return nil, err
 				}
 			} else if err != nil {
 				span.AddAttributes(
 					trace.StringAttribute("error", err.Error()))
 				span.Annotate(nil, "Data node communication error")
-				return err
+				return nil, err
 			} else {
-				return nil
+				return resp, nil
 			}
 		}
 
@@ -347,7 +726,557 @@ func (d *DataAccessClient) Insert(
 			span.AddAttributes(
 				trace.StringAttribute("error", ctx.Err().Error()))
 			span.Annotate(nil, "Context expired")
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 	}
 }
+
+/*
+Increment requests to add a delta to a counter column in the database. The
+destination of the counter must be specified as a
+(table, row, column family, column) tuple.
+*/
+func (d *DataAccessClient) Increment(
+	parentCtx context.Context, req *redcloud.IncrementRequest,
+	opts ...grpc.CallOption) error {
+	// The key range is just 1 key wide.
+	var kr = common.NewKeyRange(req.Key, req.Key)
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataAccessClient/Increment")
+	defer span.End()
+
+	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
+		return err
+	}
+
+	if len(conns) > 1 {
+		span.AddAttributes(
+			trace.Int64Attribute("num-range-covers", int64(len(conns))))
+		span.Annotate(
+			nil, "More than one range cover registered for a single key")
+		return fmt.Errorf("Error: multiple data nodes registered for key? %v",
+			req)
+	}
+
+	for {
+		for _, conn = range conns {
+			var dnsc = redcloud.NewDataNodeServiceClient(conn)
+
+			if _, err = dnsc.Increment(
+				ctx, req, opts...); err == common.ErrTabletNotLoaded {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the key and retry.
+				if conns, err = d.getRangeClients(
+					ctx, req.Table, kr, true); err != nil {
+					return err
+				}
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node communication error")
+				return err
+			} else {
+				return nil
+			}
+		}
+
+		// Check TTL / RPC cancelled.
+		if ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return ctx.Err()
+		}
+	}
+}
+
+/*
+CheckAndInsert requests to place a new version of a column into the
+database, but only if the latest version of the column matches the
+expectations from the request. The response reports whether the insert was
+applied, along with the latest version of the column before the insert.
+*/
+func (d *DataAccessClient) CheckAndInsert(
+	parentCtx context.Context, req *redcloud.CheckAndInsertRequest,
+	opts ...grpc.CallOption) (*redcloud.CheckAndInsertResponse, error) {
+	var kr *common.KeyRange
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataAccessClient/CheckAndInsert")
+	defer span.End()
+
+	if req.Insert == nil {
+		span.Annotate(nil, "No insert specified")
+		return nil, errors.New("No insert specified in conditional insert")
+	}
+
+	// The key range is just 1 key wide.
+	kr = common.NewKeyRange(req.Insert.Key, req.Insert.Key)
+
+	if conns, err = d.getRangeClients(
+		ctx, req.Insert.Table, kr, false); err != nil {
+		return nil, err
+	}
+
+	if len(conns) > 1 {
+		span.AddAttributes(
+			trace.Int64Attribute("num-range-covers", int64(len(conns))))
+		span.Annotate(
+			nil, "More than one range cover registered for a single key")
+		return nil, fmt.Errorf(
+			"Error: multiple data nodes registered for key? %v", req)
+	}
+
+	for {
+		for _, conn = range conns {
+			var dnsc = redcloud.NewDataNodeServiceClient(conn)
+			var resp *redcloud.CheckAndInsertResponse
+
+			if resp, err = dnsc.CheckAndInsert(
+				ctx, req, opts...); err == common.ErrTabletNotLoaded {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the key and retry.
+				if conns, err = d.getRangeClients(
+					ctx, req.Insert.Table, kr, true); err != nil {
+					return nil, err
+				}
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node communication error")
+				return nil, err
+			} else {
+				return resp, nil
+			}
+		}
+
+		// Check TTL / RPC cancelled.
+		if ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/*
+Delete requests to delete data from the database. Depending on the request,
+a single column, a range of columns or an entire row of the specified
+column family will be deleted.
+*/
+func (d *DataAccessClient) Delete(
+	parentCtx context.Context, req *redcloud.DeleteRequest,
+	opts ...grpc.CallOption) error {
+	// The key range is just 1 key wide.
+	var kr = common.NewKeyRange(req.Key, req.Key)
+	var conns []*grpc.ClientConn
+	var conn *grpc.ClientConn
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataAccessClient/Delete")
+	defer span.End()
+
+	if conns, err = d.getRangeClients(ctx, req.Table, kr, false); err != nil {
+		return err
+	}
+
+	if len(conns) > 1 {
+		span.AddAttributes(
+			trace.Int64Attribute("num-range-covers", int64(len(conns))))
+		span.Annotate(
+			nil, "More than one range cover registered for a single key")
+		return fmt.Errorf("Error: multiple data nodes registered for key? %v",
+			req)
+	}
+
+	for {
+		for _, conn = range conns {
+			var dnsc = redcloud.NewDataNodeServiceClient(conn)
+
+			if _, err = dnsc.Delete(
+				ctx, req, opts...); err == common.ErrTabletNotLoaded {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the key and retry.
+				if conns, err = d.getRangeClients(
+					ctx, req.Table, kr, true); err != nil {
+					return err
+				}
+			} else if err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node communication error")
+				return err
+			} else {
+				return nil
+			}
+		}
+
+		// Check TTL / RPC cancelled.
+		if ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return ctx.Err()
+		}
+	}
+}
+
+/*
+BatchMutate applies the inserts and deletions in the request to the rows of
+the table. The rows are grouped by the data nodes holding them, and a single
+BatchMutate RPC is sent to each of these data nodes in parallel. All changes
+to a column family of a row are applied atomically, but there is no
+atomicity across rows.
+*/
+func (d *DataAccessClient) BatchMutate(
+	parentCtx context.Context, req *redcloud.BatchMutateRequest,
+	opts ...grpc.CallOption) error {
+	var pending = req.Row
+	var forceFetch bool
+	var ctx context.Context
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataAccessClient/BatchMutate")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.Int64Attribute("num-rows", int64(len(req.Row))))
+
+	for len(pending) > 0 {
+		var batches = make(map[*grpc.ClientConn]*redcloud.BatchMutateRequest)
+		var conns []*grpc.ClientConn
+		var errs []error
+		var retry []*redcloud.RowMutation
+		var row *redcloud.RowMutation
+		var conn *grpc.ClientConn
+		var wg sync.WaitGroup
+		var i int
+
+		// Group the rows by the data nodes holding them.
+		for _, row = range pending {
+			var kr = common.NewKeyRange(row.Key, row.Key)
+			var rowConns []*grpc.ClientConn
+			var batch *redcloud.BatchMutateRequest
+			var ok bool
+
+			if rowConns, err = d.getRangeClients(
+				ctx, req.Table, kr, forceFetch); err != nil {
+				return err
+			}
+
+			if len(rowConns) == 0 {
+				span.Annotate(nil, "No range cover registered for key")
+				return ErrNoDataNodes
+			} else if len(rowConns) > 1 {
+				span.AddAttributes(
+					trace.Int64Attribute("num-range-covers", int64(len(rowConns))))
+				span.Annotate(
+					nil, "More than one range cover registered for a single key")
+				return fmt.Errorf(
+					"Error: multiple data nodes registered for key? %v", row.Key)
+			}
+
+			if batch, ok = batches[rowConns[0]]; !ok {
+				batch = &redcloud.BatchMutateRequest{Table: req.Table}
+				batches[rowConns[0]] = batch
+				conns = append(conns, rowConns[0])
+			}
+			batch.Row = append(batch.Row, row)
+
+			// The cache only needs to be refreshed once per round.
+			forceFetch = false
+		}
+
+		span.AddAttributes(
+			trace.Int64Attribute("num-data-nodes", int64(len(conns))))
+
+		errs = make([]error, len(conns))
+		for i, conn = range conns {
+			wg.Add(1)
+			go func(i int, conn *grpc.ClientConn) {
+				var dnsc = redcloud.NewDataNodeServiceClient(conn)
+
+				defer wg.Done()
+				_, errs[i] = dnsc.BatchMutate(ctx, batches[conn], opts...)
+			}(i, conn)
+		}
+		wg.Wait()
+
+		for i, conn = range conns {
+			if grpc.Code(errs[i]) == codes.Unavailable {
+				span.Annotate(nil, "Tablet not loaded")
+				// Refresh data nodes covering the rows and retry.
+				retry = append(retry, batches[conn].Row...)
+			} else if errs[i] != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", errs[i].Error()))
+				span.Annotate(nil, "Data node communication error")
+				return errs[i]
+			}
+		}
+
+		pending = retry
+		forceFetch = true
+
+		// Check TTL / RPC cancelled.
+		if len(pending) > 0 && ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+/*
+bulkInsertStream keeps track of a StreamInsert RPC to a single data node,
+along with all inserts sent through it which have not been acknowledged by
+a checkpoint yet.
+*/
+type bulkInsertStream struct {
+	stream       redcloud.DataNodeService_StreamInsertClient
+	lock         sync.Mutex
+	pending      []*redcloud.InsertRequest
+	acknowledged int64
+	done         chan error
+}
+
+/*
+receive processes checkpoints from the data node until the stream ends, and
+reports the final status of the stream through done.
+*/
+func (s *bulkInsertStream) receive() {
+	var checkpoint *redcloud.StreamInsertCheckpoint
+	var err error
+
+	for {
+		if checkpoint, err = s.stream.Recv(); err == io.EOF {
+			s.done <- nil
+			return
+		} else if err != nil {
+			s.done <- err
+			return
+		}
+
+		s.lock.Lock()
+		s.pending = s.pending[checkpoint.NumInserted-s.acknowledged:]
+		s.acknowledged = checkpoint.NumInserted
+		s.lock.Unlock()
+	}
+}
+
+/*
+send transmits the insert to the data node and remembers it until it is
+acknowledged.
+*/
+func (s *bulkInsertStream) send(req *redcloud.InsertRequest) error {
+	s.lock.Lock()
+	s.pending = append(s.pending, req)
+	s.lock.Unlock()
+
+	return s.stream.Send(req)
+}
+
+/*
+finish ends the stream and waits for the final checkpoint. Returns the
+status of the stream along with all inserts which were not acknowledged.
+*/
+func (s *bulkInsertStream) finish() ([]*redcloud.InsertRequest, error) {
+	var err error
+
+	s.stream.CloseSend()
+	err = <-s.done
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.pending, err
+}
+
+/*
+waitForBulkInsertRetry waits before the inserts which the failed stream s
+did not acknowledge are routed again. The delay grows with every
+consecutive failure; failures only count as consecutive if s didn't
+acknowledge any inserts. Returns the new number of consecutive failures, or
+streamErr once the inserts have been retried too often.
+*/
+func waitForBulkInsertRetry(ctx context.Context, s *bulkInsertStream,
+	failures int, streamErr error) (int, error) {
+	var err error
+
+	if s.acknowledged > 0 {
+		failures = 0
+	}
+
+	if failures >= common.MaxRetries {
+		return failures, streamErr
+	}
+
+	if err = common.WaitForRetry(ctx, failures); err != nil {
+		return failures, err
+	}
+
+	return failures + 1, nil
+}
+
+/*
+BulkInsert places all inserts read from reqs into the database, until reqs
+is closed. The inserts are routed to the data nodes holding the
+corresponding rows, using one StreamInsert RPC per data node. If a data
+node is unavailable, e.g. because it no longer holds a row, all inserts
+which it did not acknowledge are routed again after a delay, up to
+common.MaxRetries times in a row.
+*/
+func (d *DataAccessClient) BulkInsert(
+	parentCtx context.Context, reqs chan *redcloud.InsertRequest,
+	opts ...grpc.CallOption) error {
+	var streams = make(map[*grpc.ClientConn]*bulkInsertStream)
+	var retry []*redcloud.InsertRequest
+	var forceFetch bool
+	var failures int
+	var numInserted int64
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var span *trace.Span
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataAccessClient/BulkInsert")
+	defer span.End()
+
+	// Abort all streams which are still open when returning early.
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	for reqs != nil || len(retry) > 0 || len(streams) > 0 {
+		var req *redcloud.InsertRequest
+		var kr *common.KeyRange
+		var conns []*grpc.ClientConn
+		var s *bulkInsertStream
+		var ok bool
+
+		if len(retry) > 0 {
+			req = retry[0]
+			retry = retry[1:]
+		} else if reqs != nil {
+			if req, ok = <-reqs; !ok {
+				reqs = nil
+				continue
+			}
+		} else {
+			var conn *grpc.ClientConn
+
+			// All inserts have been sent; wait for the data nodes.
+			for conn, s = range streams {
+				var unacknowledged []*redcloud.InsertRequest
+
+				delete(streams, conn)
+				unacknowledged, err = s.finish()
+				if grpc.Code(err) == codes.Unavailable {
+					span.Annotate(nil, "Data node unavailable")
+					if failures, err = waitForBulkInsertRetry(
+						ctx, s, failures, err); err != nil {
+						span.AddAttributes(
+							trace.StringAttribute("error", err.Error()))
+						span.Annotate(nil, "Giving up on retries")
+						return err
+					}
+					retry = append(retry, unacknowledged...)
+					forceFetch = true
+				} else if err != nil {
+					span.AddAttributes(
+						trace.StringAttribute("error", err.Error()))
+					span.Annotate(nil, "Data node communication error")
+					return err
+				}
+			}
+			continue
+		}
+
+		kr = common.NewKeyRange(req.Key, req.Key)
+		if conns, err = d.getRangeClients(
+			ctx, req.Table, kr, forceFetch); err != nil {
+			return err
+		}
+		forceFetch = false
+
+		if len(conns) != 1 {
+			span.AddAttributes(
+				trace.Int64Attribute("num-range-covers", int64(len(conns))))
+			span.Annotate(nil, "Not exactly one range cover registered for key")
+			return fmt.Errorf(
+				"Error: %d data nodes registered for key %v", len(conns), req.Key)
+		}
+
+		if s, ok = streams[conns[0]]; !ok {
+			var dnsc = redcloud.NewDataNodeServiceClient(conns[0])
+
+			s = &bulkInsertStream{done: make(chan error, 1)}
+			if s.stream, err = dnsc.StreamInsert(ctx, opts...); err != nil {
+				span.AddAttributes(trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node communication error")
+				return err
+			}
+			streams[conns[0]] = s
+			go s.receive()
+		}
+
+		if err = s.send(req); err != nil {
+			var unacknowledged []*redcloud.InsertRequest
+
+			/*
+				The stream has been aborted by the data node; the actual
+				error is reported when the stream is finished.
+			*/
+			delete(streams, conns[0])
+			unacknowledged, err = s.finish()
+			if grpc.Code(err) == codes.Unavailable {
+				span.Annotate(nil, "Data node unavailable")
+				if failures, err = waitForBulkInsertRetry(
+					ctx, s, failures, err); err != nil {
+					span.AddAttributes(trace.StringAttribute("error", err.Error()))
+					span.Annotate(nil, "Giving up on retries")
+					return err
+				}
+				retry = append(retry, unacknowledged...)
+				forceFetch = true
+			} else if err != nil {
+				span.AddAttributes(trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Data node communication error")
+				return err
+			} else {
+				retry = append(retry, unacknowledged...)
+			}
+			continue
+		}
+
+		numInserted++
+
+		// Check TTL / RPC cancelled.
+		if ctx.Err() != nil {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			return ctx.Err()
+		}
+	}
+
+	span.AddAttributes(trace.Int64Attribute("num-sent", numInserted))
+	return nil
+}
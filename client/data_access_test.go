@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestSortCovers(t *testing.T) {
+	var covers = []*krClientConn{
+		{KeyRange: common.NewKeyRange([]byte("m"), []byte("t"))},
+		{KeyRange: common.NewKeyRange([]byte("t"), []byte{})},
+		{KeyRange: common.NewKeyRange([]byte{}, []byte("f"))},
+		{KeyRange: common.NewKeyRange([]byte("f"), []byte("m"))},
+	}
+	var expected = []string{"", "f", "m", "t"}
+	var i int
+
+	sortCovers(covers)
+
+	for i = range expected {
+		if string(covers[i].KeyRange.StartKey) != expected[i] {
+			t.Errorf("Unexpected start key at %d: \"%s\" (expected %s)", i,
+				covers[i].KeyRange.StartKey, expected[i])
+		}
+	}
+}
+
+/*
+fakeInsertStream acknowledges inserts with the checkpoints passed in, then
+ends with the configured error.
+*/
+type fakeInsertStream struct {
+	grpc.ClientStream
+	checkpoints chan *redcloud.StreamInsertCheckpoint
+	err         error
+}
+
+func (f *fakeInsertStream) Send(req *redcloud.InsertRequest) error {
+	return nil
+}
+
+func (f *fakeInsertStream) Recv() (*redcloud.StreamInsertCheckpoint, error) {
+	var checkpoint *redcloud.StreamInsertCheckpoint
+	var ok bool
+
+	if checkpoint, ok = <-f.checkpoints; !ok {
+		return nil, f.err
+	}
+
+	return checkpoint, nil
+}
+
+func (f *fakeInsertStream) CloseSend() error {
+	return nil
+}
+
+func TestBulkInsertStreamPending(t *testing.T) {
+	var cases = []struct {
+		name        string
+		checkpoints []int64
+		err         error
+		numPending  int
+	}{
+		{"all acknowledged", []int64{2, 5}, io.EOF, 0},
+		{"aborted", []int64{2, 4}, common.ErrTabletNotLoaded, 1},
+		{"never acknowledged", nil, common.ErrTabletNotLoaded, 5},
+	}
+	var i int
+
+	for i = range cases {
+		var fake = &fakeInsertStream{
+			checkpoints: make(chan *redcloud.StreamInsertCheckpoint,
+				len(cases[i].checkpoints)),
+			err: cases[i].err,
+		}
+		var s = &bulkInsertStream{stream: fake, done: make(chan error, 1)}
+		var pending []*redcloud.InsertRequest
+		var numInserted int64
+		var j int
+		var err error
+
+		for j = 0; j < 5; j++ {
+			if err = s.send(&redcloud.InsertRequest{
+				Key: []byte{byte('a' + j)}}); err != nil {
+				t.Errorf("%s: error sending insert: %s", cases[i].name, err)
+			}
+		}
+		for _, numInserted = range cases[i].checkpoints {
+			fake.checkpoints <- &redcloud.StreamInsertCheckpoint{
+				NumInserted: numInserted}
+		}
+		close(fake.checkpoints)
+
+		go s.receive()
+		pending, err = s.finish()
+
+		if cases[i].err == io.EOF && err != nil {
+			t.Errorf("%s: unexpected error: %s", cases[i].name, err)
+		} else if cases[i].err != io.EOF &&
+			grpc.Code(err) != codes.Unavailable {
+			t.Errorf("%s: unexpected error: got %v, want %v", cases[i].name,
+				err, cases[i].err)
+		}
+
+		if len(pending) != cases[i].numPending {
+			t.Errorf("%s: unexpected number of pending inserts: got %d, "+
+				"want %d", cases[i].name, len(pending), cases[i].numPending)
+		} else if len(pending) > 0 && pending[len(pending)-1].Key[0] != 'e' {
+			t.Errorf("%s: unexpected last pending insert: %v", cases[i].name,
+				pending[len(pending)-1])
+		}
+	}
+}
+
+func TestWaitForBulkInsertRetry(t *testing.T) {
+	var cases = []struct {
+		name         string
+		acknowledged int64
+		failures     int
+		expected     int
+		giveUp       bool
+	}{
+		{"first failure", 0, 0, 1, false},
+		{"consecutive failure", 0, 1, 2, false},
+		{"too many failures", 0, common.MaxRetries, common.MaxRetries, true},
+		{"progress made", 10, common.MaxRetries, 1, false},
+	}
+	var i int
+
+	for i = range cases {
+		var s = &bulkInsertStream{acknowledged: cases[i].acknowledged}
+		var failures int
+		var err error
+
+		failures, err = waitForBulkInsertRetry(context.Background(), s,
+			cases[i].failures, common.ErrTabletNotLoaded)
+		if failures != cases[i].expected {
+			t.Errorf("%s: unexpected number of failures: got %d, want %d",
+				cases[i].name, failures, cases[i].expected)
+		}
+		if cases[i].giveUp && err != common.ErrTabletNotLoaded {
+			t.Errorf("%s: unexpected error: %v", cases[i].name, err)
+		} else if !cases[i].giveUp && err != nil {
+			t.Errorf("%s: unexpected error: %s", cases[i].name, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+/*
+DefaultPageSize is the number of cells requested per page by a
+RangeIterator if the request doesn't specify a page size.
+*/
+const DefaultPageSize = 1000
+
+/*
+rangeIteratorRetryInterval is the time to wait before retrying a page after
+the data node serving it became unavailable.
+*/
+const rangeIteratorRetryInterval = 100 * time.Millisecond
+
+/*
+RangeIterator iterates over the results of a range scan page by page. Since
+the scan is resumed from the last position seen, it survives data node
+restarts and tablets moving to different data nodes.
+*/
+type RangeIterator struct {
+	client *DataAccessClient
+	req    *redcloud.GetRangeRequest
+	opts   []grpc.CallOption
+
+	/*
+		Start key of the part of the range which hasn't been scanned yet, or
+		its end key for reverse scans.
+	*/
+	position []byte
+
+	// Token for resuming the scan in the current tablet.
+	token []byte
+
+	rows []*redcloud.ColumnFamily
+	done bool
+}
+
+/*
+NewRangeIterator creates a new RangeIterator returning all data matching
+the GetRangeRequest. If no page size is set in the request, DefaultPageSize
+will be used.
+*/
+func (d *DataAccessClient) NewRangeIterator(
+	req *redcloud.GetRangeRequest, opts ...grpc.CallOption) *RangeIterator {
+	var it = &RangeIterator{
+		client: d,
+		req:    proto.Clone(req).(*redcloud.GetRangeRequest),
+		opts:   opts,
+	}
+
+	if it.req.PageSize <= 0 {
+		it.req.PageSize = DefaultPageSize
+	}
+	if it.req.Reverse {
+		it.position = it.req.EndKey
+	} else {
+		it.position = it.req.StartKey
+	}
+	it.token = it.req.ResumeToken
+
+	return it
+}
+
+/*
+Next returns the next row of the scan. Rows are returned in key order, or in
+reverse key order for reverse scans. Rows which don't fit into a single page
+are returned in several consecutive parts with the same key. Returns io.EOF
+once the scan is complete.
+*/
+func (it *RangeIterator) Next(ctx context.Context) (
+	*redcloud.ColumnFamily, error) {
+	var row *redcloud.ColumnFamily
+	var err error
+
+	for len(it.rows) == 0 {
+		if it.done {
+			return nil, io.EOF
+		}
+
+		if err = it.fetchPage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	row = it.rows[0]
+	it.rows = it.rows[1:]
+	return row, nil
+}
+
+/*
+fetchPage requests the next page of results from the tablet covering the
+current position of the scan, i.e. the first tablet of the remaining range
+or the last one for reverse scans. If the data node holding the tablet
+cannot be reached, the tablets of the table are looked up again and the page
+is requested from whichever data node is serving the tablet now.
+*/
+func (it *RangeIterator) fetchPage(parentCtx context.Context) error {
+	var ctx context.Context
+	var span *trace.Span
+	var forceFetch bool
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.RangeIterator/fetchPage")
+	defer span.End()
+
+	for {
+		var remaining *common.KeyRange
+		var covers []*krClientConn
+		var cover *krClientConn
+		var candidate *krClientConn
+		var pageReq *redcloud.GetRangeRequest
+		var resp *redcloud.GetRangePageResponse
+
+		if it.req.Reverse {
+			remaining = common.NewKeyRange(it.req.StartKey, it.position)
+		} else {
+			remaining = common.NewKeyRange(it.position, it.req.EndKey)
+		}
+
+		if covers, err = it.client.getRangeCovers(
+			ctx, it.req.Table, remaining, forceFetch); err != nil {
+			return err
+		}
+
+		for _, candidate = range covers {
+			if it.req.Reverse {
+				// All candidates overlap the range, pick the last one.
+				if cover == nil || bytes.Compare(candidate.KeyRange.StartKey,
+					cover.KeyRange.StartKey) > 0 {
+					cover = candidate
+				}
+			} else if candidate.KeyRange.Contains(it.position) {
+				cover = candidate
+				break
+			}
+		}
+
+		if cover == nil {
+			if forceFetch {
+				span.Annotate(nil, "No tablet covers the scan position")
+				return ErrNoDataNodes
+			}
+			// The cached tablet list may be outdated.
+			forceFetch = true
+			continue
+		}
+
+		// Restrict the request to the tablet so it's served in one piece.
+		pageReq = proto.Clone(it.req).(*redcloud.GetRangeRequest)
+		pageReq.ResumeToken = it.token
+		if it.req.Reverse {
+			pageReq.EndKey = it.position
+			if bytes.Compare(cover.KeyRange.StartKey, it.req.StartKey) > 0 {
+				pageReq.StartKey = cover.KeyRange.StartKey
+			}
+		} else {
+			pageReq.StartKey = it.position
+			if len(cover.KeyRange.EndKey) > 0 && (len(it.req.EndKey) == 0 ||
+				bytes.Compare(cover.KeyRange.EndKey, it.req.EndKey) < 0) {
+				pageReq.EndKey = cover.KeyRange.EndKey
+			}
+		}
+
+		if resp, err = redcloud.NewDataNodeServiceClient(
+			cover.ClientConn).GetRangePage(
+			ctx, pageReq, it.opts...); grpc.Code(err) == codes.Unavailable {
+			span.Annotate(nil, "Data node or tablet unavailable")
+
+			// Give the tablet some time to be loaded elsewhere, then retry.
+			select {
+			case <-ctx.Done():
+				span.AddAttributes(
+					trace.StringAttribute("error", ctx.Err().Error()))
+				span.Annotate(nil, "Context expired")
+				return ctx.Err()
+			case <-time.After(rangeIteratorRetryInterval):
+			}
+			forceFetch = true
+			continue
+		} else if err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Data node communication error")
+			return err
+		}
+
+		it.rows = resp.Row
+
+		if len(resp.NextResumeToken) > 0 {
+			it.token = resp.NextResumeToken
+			return nil
+		}
+
+		/*
+			The tablet has been scanned completely, so continue with the next
+			one. The old token is kept since it's harmless for rows past its
+			position, but still needed if the tablet has been split while
+			scanning it.
+		*/
+		if it.req.Reverse {
+			if bytes.Equal(pageReq.StartKey, it.req.StartKey) {
+				it.done = true
+			} else {
+				it.position = pageReq.StartKey
+			}
+		} else if len(pageReq.EndKey) == 0 ||
+			bytes.Equal(pageReq.EndKey, it.req.EndKey) {
+			it.done = true
+		} else {
+			it.position = pageReq.EndKey
+		}
+
+		return nil
+	}
+}
@@ -12,9 +12,33 @@ requested is not loaded on this server.
 var ErrTabletNotLoaded = grpc.Errorf(
 	codes.Unavailable, "Tablet not loaded")
 
+/*
+ErrTabletSplitting is an error indicating that the requested tablet is
+currently being split and cannot be released until the split is done.
+*/
+var ErrTabletSplitting = grpc.Errorf(
+	codes.Unavailable, "Tablet is being split")
+
 /*
 ErrColumnFamilyNotConfigured is an error indicating that while the tablet
 specified is known, the specific column family is not configured.
 */
 var ErrColumnFamilyNotConfigured = grpc.Errorf(
 	codes.NotFound, "Column family not configured")
+
+/*
+ErrMemtableFrozen is an error indicating that data was about to be written
+to a journal whose memtable is already being flushed. Journals are only
+flushed while holding the journal lock, which writers hold as well, so this
+indicates a bug. Nothing has been written to the journal.
+*/
+var ErrMemtableFrozen = grpc.Errorf(
+	codes.Unavailable, "Memtable is being flushed")
+
+/*
+ErrChecksumMismatch is an error indicating that a record read from a journal
+or an sstable doesn't match its checksum, i.e. the stored data has been
+corrupted.
+*/
+var ErrChecksumMismatch = grpc.Errorf(
+	codes.DataLoss, "Checksum mismatch, data corrupted")
@@ -56,7 +56,8 @@ in the other range.
 */
 func (kr *KeyRange) ContainsRangeFully(other *KeyRange) bool {
 	return (bytes.Compare(kr.StartKey, other.StartKey) <= 0 &&
-		bytes.Compare(other.StartKey, other.EndKey) <= 0 &&
+		(len(other.EndKey) == 0 ||
+			bytes.Compare(other.StartKey, other.EndKey) <= 0) &&
 		(len(kr.EndKey) == 0 ||
 			(len(other.EndKey) > 0 &&
 				bytes.Compare(other.EndKey, kr.EndKey) <= 0)))
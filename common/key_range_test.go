@@ -48,6 +48,8 @@ var nonOverlapingRanges = map[*KeyRange]*KeyRange{
 var fullyContainedRanges = map[*KeyRange]*KeyRange{
 	NewKeyRange([]byte{}, []byte{}):                     NewKeyRange([]byte{}, []byte{}),
 	NewKeyRange([]byte{}, []byte{}):                     NewKeyRange([]byte{0x20}, []byte{0x20, 0x22}),
+	NewKeyRange([]byte{}, []byte{}):                     NewKeyRange([]byte{0xA0}, []byte{}),
+	NewKeyRange([]byte{0x20}, []byte{}):                 NewKeyRange([]byte{0xA0}, []byte{}),
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}),
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xA0, 0x22}, []byte{0xA0, 0x3A}),
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xB2, 0x22}, []byte{0xB2, 0x3A}),
@@ -63,6 +65,7 @@ var notFullyContainedRanges = map[*KeyRange]*KeyRange{
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xA0}, []byte{0xB2, 0x3A}),
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A, 0x01}),
 	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xA0}, []byte{0xB3}),
+	NewKeyRange([]byte{0xA0, 0x22}, []byte{0xB2, 0x3A}): NewKeyRange([]byte{0xB0}, []byte{}),
 }
 
 func TestInvalidKeyRange(t *testing.T) {
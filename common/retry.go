@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+/*
+MaxRetries is the number of times an operation which keeps failing is
+retried before giving up.
+*/
+const MaxRetries = 8
+
+/*
+Delays between two attempts at an operation: the first retry happens after
+minRetryDelay, and the delay doubles with every further retry, up to
+maxRetryDelay.
+*/
+const (
+	minRetryDelay = 50 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+/*
+RetryDelay determines how long to wait before retrying an operation which
+has already been retried "retries" times.
+*/
+func RetryDelay(retries int) time.Duration {
+	var delay = minRetryDelay
+
+	for ; retries > 0 && delay < maxRetryDelay; retries-- {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+/*
+WaitForRetry waits before retrying an operation which has already been
+retried "retries" times, see RetryDelay. Returns the error of the context if
+it ends before the delay is up.
+*/
+func WaitForRetry(ctx context.Context, retries int) error {
+	var timer = time.NewTimer(RetryDelay(retries))
+
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	var testdata = map[int]time.Duration{
+		0:    50 * time.Millisecond,
+		1:    100 * time.Millisecond,
+		3:    400 * time.Millisecond,
+		8:    10 * time.Second,
+		1000: 10 * time.Second,
+	}
+	var retries int
+	var delay time.Duration
+
+	for retries, delay = range testdata {
+		if RetryDelay(retries) != delay {
+			t.Errorf("Unexpected delay after %d retries: got %s, want %s",
+				retries, RetryDelay(retries), delay)
+		}
+	}
+}
+
+func TestWaitForRetryCancelled(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	var err error
+
+	cancel()
+	if err = WaitForRetry(ctx, 1000); err != context.Canceled {
+		t.Errorf("Unexpected error waiting with cancelled context: %v", err)
+	}
+}
@@ -17,6 +17,66 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+type RowFilter_FilterType int32
+
+const (
+	// The column value is exactly equal to value.
+	RowFilter_VALUE_EQUALS RowFilter_FilterType = 0
+	// The column value starts with value.
+	RowFilter_VALUE_PREFIX RowFilter_FilterType = 1
+	// The column value matches the regular expression regex.
+	RowFilter_VALUE_REGEX RowFilter_FilterType = 2
+	// The row key matches the regular expression regex.
+	RowFilter_KEY_REGEX RowFilter_FilterType = 3
+	// The length of the column value is between min_length and max_length.
+	RowFilter_VALUE_LENGTH RowFilter_FilterType = 4
+	// The column holds any data.
+	RowFilter_HAS_COLUMN RowFilter_FilterType = 5
+)
+
+var RowFilter_FilterType_name = map[int32]string{
+	0: "VALUE_EQUALS",
+	1: "VALUE_PREFIX",
+	2: "VALUE_REGEX",
+	3: "KEY_REGEX",
+	4: "VALUE_LENGTH",
+	5: "HAS_COLUMN",
+}
+var RowFilter_FilterType_value = map[string]int32{
+	"VALUE_EQUALS": 0,
+	"VALUE_PREFIX": 1,
+	"VALUE_REGEX":  2,
+	"KEY_REGEX":    3,
+	"VALUE_LENGTH": 4,
+	"HAS_COLUMN":   5,
+}
+
+func (x RowFilter_FilterType) String() string {
+	return proto.EnumName(RowFilter_FilterType_name, int32(x))
+}
+func (RowFilter_FilterType) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{5, 0} }
+
+type Mutation_MutationType int32
+
+const (
+	Mutation_INSERT Mutation_MutationType = 0
+	Mutation_DELETE Mutation_MutationType = 1
+)
+
+var Mutation_MutationType_name = map[int32]string{
+	0: "INSERT",
+	1: "DELETE",
+}
+var Mutation_MutationType_value = map[string]int32{
+	"INSERT": 0,
+	"DELETE": 1,
+}
+
+func (x Mutation_MutationType) String() string {
+	return proto.EnumName(Mutation_MutationType_name, int32(x))
+}
+func (Mutation_MutationType) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{17, 0} }
+
 //
 // GetRequest formulates a request for fetching a single individual key.
 type GetRequest struct {
@@ -28,6 +88,12 @@ type GetRequest struct {
 	ColumnFamily string `protobuf:"bytes,3,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
 	// Name of the specific column being referred to.
 	Column string `protobuf:"bytes,4,opt,name=column" json:"column,omitempty"`
+	//
+	// If set, return the state of the cell as of this time, in milliseconds
+	// since the epoch: the latest version written at or before it, unless it
+	// had been deleted by then. Versions already removed through the version
+	// limits of the table cannot be returned.
+	ReadTimestamp int64 `protobuf:"varint,5,opt,name=read_timestamp,json=readTimestamp" json:"read_timestamp,omitempty"`
 }
 
 func (m *GetRequest) Reset()                    { *m = GetRequest{} }
@@ -63,6 +129,13 @@ func (m *GetRequest) GetColumn() string {
 	return ""
 }
 
+func (m *GetRequest) GetReadTimestamp() int64 {
+	if m != nil {
+		return m.ReadTimestamp
+	}
+	return 0
+}
+
 //
 // ColumnRange describes a range of column names to be operated on.
 type ColumnRange struct {
@@ -93,6 +166,99 @@ func (m *ColumnRange) GetEndColumn() string {
 	return ""
 }
 
+//
+// GetRowRequest formulates a request for the latest versions of columns from
+// several column families of a single row.
+type GetRowRequest struct {
+	// Key of the row to be fetched from the database.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the table which data is being extracted from.
+	Table string `protobuf:"bytes,2,opt,name=table" json:"table,omitempty"`
+	// Column families and columns to fetch.
+	Family []*ColumnFamilySelection `protobuf:"bytes,3,rep,name=family" json:"family,omitempty"`
+}
+
+func (m *GetRowRequest) Reset()                    { *m = GetRowRequest{} }
+func (m *GetRowRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetRowRequest) ProtoMessage()               {}
+func (*GetRowRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+
+func (m *GetRowRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *GetRowRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *GetRowRequest) GetFamily() []*ColumnFamilySelection {
+	if m != nil {
+		return m.Family
+	}
+	return nil
+}
+
+//
+// ColumnFamilySelection selects a number of columns from a column family.
+type ColumnFamilySelection struct {
+	// Name of the column family to read from.
+	ColumnFamily string `protobuf:"bytes,1,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// List of the names of all columns to read.
+	Column []string `protobuf:"bytes,2,rep,name=column" json:"column,omitempty"`
+	// Range of columns to read in addition to the listed ones.
+	ColumnRange *ColumnRange `protobuf:"bytes,3,opt,name=column_range,json=columnRange" json:"column_range,omitempty"`
+	// Prefix of the names of columns to read in addition to the above.
+	ColumnPrefix string `protobuf:"bytes,4,opt,name=column_prefix,json=columnPrefix" json:"column_prefix,omitempty"`
+	// Whether to read all columns of the column family.
+	AllColumns bool `protobuf:"varint,5,opt,name=all_columns,json=allColumns" json:"all_columns,omitempty"`
+}
+
+func (m *ColumnFamilySelection) Reset()                    { *m = ColumnFamilySelection{} }
+func (m *ColumnFamilySelection) String() string            { return proto.CompactTextString(m) }
+func (*ColumnFamilySelection) ProtoMessage()               {}
+func (*ColumnFamilySelection) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+
+func (m *ColumnFamilySelection) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *ColumnFamilySelection) GetColumn() []string {
+	if m != nil {
+		return m.Column
+	}
+	return nil
+}
+
+func (m *ColumnFamilySelection) GetColumnRange() *ColumnRange {
+	if m != nil {
+		return m.ColumnRange
+	}
+	return nil
+}
+
+func (m *ColumnFamilySelection) GetColumnPrefix() string {
+	if m != nil {
+		return m.ColumnPrefix
+	}
+	return ""
+}
+
+func (m *ColumnFamilySelection) GetAllColumns() bool {
+	if m != nil {
+		return m.AllColumns
+	}
+	return false
+}
+
 //
 // GetRangeRequest describes a request for data for a key range from a number
 // (or range) of columns. All data matching the criteria will be streamed back
@@ -107,10 +273,12 @@ type GetRangeRequest struct {
 	// Name of the table which the data is being extracted from.
 	Table string `protobuf:"bytes,3,opt,name=table" json:"table,omitempty"`
 	//
-	// Name of the column family the data is stored at. Data can only be
-	// requested from a single column family at a time.
+	// Name of the column family the data is stored at. Use family to request
+	// data from several column families at a time.
 	ColumnFamily string `protobuf:"bytes,4,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
-	// List of the names of all columns affected.
+	//
+	// List of the names of all columns affected. Further columns can be
+	// selected through column_range, column_prefix and all_columns.
 	Column []string `protobuf:"bytes,5,rep,name=column" json:"column,omitempty"`
 	//
 	// Minimum timestamp to be taken into account for returning, or 0.
@@ -121,16 +289,51 @@ type GetRangeRequest struct {
 	MaxTimestamp int64 `protobuf:"varint,7,opt,name=max_timestamp,json=maxTimestamp" json:"max_timestamp,omitempty"`
 	//
 	// Maximum number of results which will be returned from each data node.
-	// There will be no ordering of results, probably, so this is mostly a
-	// sanity check mechanism to prevent excessive load. Setting this to 0
-	// will disable any limits.
+	// Results are returned in key order, so this limits the results to the
+	// first cells of the range on each data node. Setting this to 0 will
+	// disable any limits.
 	MaxResults int64 `protobuf:"varint,8,opt,name=max_results,json=maxResults" json:"max_results,omitempty"`
+	//
+	// Number of cells after which the scan will stop, or 0 for no limit.
+	// Column versions are never split across pages, so pages may contain
+	// slightly more cells. Use GetRangePage to obtain a token for resuming
+	// the scan afterwards.
+	PageSize int64 `protobuf:"varint,9,opt,name=page_size,json=pageSize" json:"page_size,omitempty"`
+	//
+	// Token returned by a previous GetRangePage call. If set, the scan will
+	// continue right after the last column returned in that call. The token
+	// has to be used with the same direction of scanning (see reverse).
+	ResumeToken []byte `protobuf:"bytes,10,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	//
+	// Column families and columns to read. If set, column_family and column
+	// are ignored. Only GetRangeFamilies supports more than one column family.
+	Family []*ColumnFamilySelection `protobuf:"bytes,11,rep,name=family" json:"family,omitempty"`
+	// Range of columns to read in addition to the listed ones.
+	ColumnRange *ColumnRange `protobuf:"bytes,12,opt,name=column_range,json=columnRange" json:"column_range,omitempty"`
+	// Prefix of the names of columns to read in addition to the above.
+	ColumnPrefix string `protobuf:"bytes,13,opt,name=column_prefix,json=columnPrefix" json:"column_prefix,omitempty"`
+	// Whether to read all columns of the column family.
+	AllColumns bool `protobuf:"varint,14,opt,name=all_columns,json=allColumns" json:"all_columns,omitempty"`
+	//
+	// Filters to apply to the rows in the range. Only rows matching all
+	// filters will be returned.
+	Filter []*RowFilter `protobuf:"bytes,15,rep,name=filter" json:"filter,omitempty"`
+	//
+	// Whether to return the rows in reverse key order, starting at the end of
+	// the range. Combined with max_results, this returns the last rows of the
+	// range.
+	Reverse bool `protobuf:"varint,16,opt,name=reverse" json:"reverse,omitempty"`
+	//
+	// If set, return the state of the range as of this time, in milliseconds
+	// since the epoch, like GetRequest.read_timestamp. Reading several ranges
+	// at the same read timestamp yields a consistent view of all of them.
+	ReadTimestamp int64 `protobuf:"varint,17,opt,name=read_timestamp,json=readTimestamp" json:"read_timestamp,omitempty"`
 }
 
 func (m *GetRangeRequest) Reset()                    { *m = GetRangeRequest{} }
 func (m *GetRangeRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetRangeRequest) ProtoMessage()               {}
-func (*GetRangeRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+func (*GetRangeRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
 
 func (m *GetRangeRequest) GetStartKey() []byte {
 	if m != nil {
@@ -188,206 +391,1391 @@ func (m *GetRangeRequest) GetMaxResults() int64 {
 	return 0
 }
 
-//
-// InsertRequest describes a request to insert a specific value for the specified
-// key; the timestamp and TTL will be preserved.
-type InsertRequest struct {
-	// Key of the row which shall be updated.
-	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	// Name of the table to mutate.
-	Table string `protobuf:"bytes,2,opt,name=table" json:"table,omitempty"`
-	// Name of the column family to mutate.
-	ColumnFamily string `protobuf:"bytes,3,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
-	// Name of the column to insert.
-	ColumnName string `protobuf:"bytes,4,opt,name=column_name,json=columnName" json:"column_name,omitempty"`
-	// Column value to insert into the data stream.
-	Column *Column `protobuf:"bytes,5,opt,name=column" json:"column,omitempty"`
+func (m *GetRangeRequest) GetPageSize() int64 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
 }
 
-func (m *InsertRequest) Reset()                    { *m = InsertRequest{} }
-func (m *InsertRequest) String() string            { return proto.CompactTextString(m) }
-func (*InsertRequest) ProtoMessage()               {}
-func (*InsertRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+func (m *GetRangeRequest) GetResumeToken() []byte {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return nil
+}
 
-func (m *InsertRequest) GetKey() []byte {
+func (m *GetRangeRequest) GetFamily() []*ColumnFamilySelection {
 	if m != nil {
-		return m.Key
+		return m.Family
 	}
 	return nil
 }
 
-func (m *InsertRequest) GetTable() string {
+func (m *GetRangeRequest) GetColumnRange() *ColumnRange {
 	if m != nil {
-		return m.Table
+		return m.ColumnRange
 	}
-	return ""
+	return nil
 }
 
-func (m *InsertRequest) GetColumnFamily() string {
+func (m *GetRangeRequest) GetColumnPrefix() string {
 	if m != nil {
-		return m.ColumnFamily
+		return m.ColumnPrefix
 	}
 	return ""
 }
 
-func (m *InsertRequest) GetColumnName() string {
+func (m *GetRangeRequest) GetAllColumns() bool {
 	if m != nil {
-		return m.ColumnName
+		return m.AllColumns
 	}
-	return ""
+	return false
 }
 
-func (m *InsertRequest) GetColumn() *Column {
+func (m *GetRangeRequest) GetFilter() []*RowFilter {
 	if m != nil {
-		return m.Column
+		return m.Filter
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*GetRequest)(nil), "redcloud.GetRequest")
-	proto.RegisterType((*ColumnRange)(nil), "redcloud.ColumnRange")
-	proto.RegisterType((*GetRangeRequest)(nil), "redcloud.GetRangeRequest")
-	proto.RegisterType((*InsertRequest)(nil), "redcloud.InsertRequest")
+func (m *GetRangeRequest) GetReverse() bool {
+	if m != nil {
+		return m.Reverse
+	}
+	return false
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// Client API for DataNodeService service
+func (m *GetRangeRequest) GetReadTimestamp() int64 {
+	if m != nil {
+		return m.ReadTimestamp
+	}
+	return 0
+}
 
-type DataNodeServiceClient interface {
-	//
-	// Request an individual data cell from the database. If multiple versions of
-	// the cell exist, only the latest version will be returned.
-	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Column, error)
+//
+// RowFilter describes a condition on a row which has to be met for the row to
+// be returned by a range scan. Conditions on column values are evaluated
+// against the latest version of the column.
+type RowFilter struct {
+	// Type of the condition to test.
+	Type RowFilter_FilterType `protobuf:"varint,1,opt,name=type,enum=redcloud.RowFilter_FilterType" json:"type,omitempty"`
 	//
-	// Request a range of data from the database. This will return all matching
-	// versions of all matching data on the database.
-	GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeClient, error)
-	// Set a very specific data cell to the specified value.
-	Insert(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Column family of the column to test. May be left empty unless data is
+	// read from several column families, in which case the first one requested
+	// is used.
+	ColumnFamily string `protobuf:"bytes,2,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// Name of the column to test, if any.
+	Column string `protobuf:"bytes,3,opt,name=column" json:"column,omitempty"`
+	// Value to compare the column value to.
+	Value []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	// Regular expression to match, in the syntax of the Go regexp package.
+	Regex string `protobuf:"bytes,5,opt,name=regex" json:"regex,omitempty"`
+	// Minimum length of the column value.
+	MinLength int64 `protobuf:"varint,6,opt,name=min_length,json=minLength" json:"min_length,omitempty"`
+	// Maximum length of the column value, or 0 for no maximum.
+	MaxLength int64 `protobuf:"varint,7,opt,name=max_length,json=maxLength" json:"max_length,omitempty"`
 }
 
-type dataNodeServiceClient struct {
-	cc *grpc.ClientConn
-}
+func (m *RowFilter) Reset()                    { *m = RowFilter{} }
+func (m *RowFilter) String() string            { return proto.CompactTextString(m) }
+func (*RowFilter) ProtoMessage()               {}
+func (*RowFilter) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
 
-func NewDataNodeServiceClient(cc *grpc.ClientConn) DataNodeServiceClient {
-	return &dataNodeServiceClient{cc}
+func (m *RowFilter) GetType() RowFilter_FilterType {
+	if m != nil {
+		return m.Type
+	}
+	return RowFilter_VALUE_EQUALS
 }
 
-func (c *dataNodeServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Column, error) {
-	out := new(Column)
-	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Get", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *RowFilter) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
 	}
-	return out, nil
+	return ""
 }
 
-func (c *dataNodeServiceClient) GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_DataNodeService_serviceDesc.Streams[0], c.cc, "/redcloud.DataNodeService/GetRange", opts...)
-	if err != nil {
-		return nil, err
-	}
-	x := &dataNodeServiceGetRangeClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
-	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+func (m *RowFilter) GetColumn() string {
+	if m != nil {
+		return m.Column
 	}
-	return x, nil
+	return ""
 }
 
-type DataNodeService_GetRangeClient interface {
-	Recv() (*ColumnSet, error)
-	grpc.ClientStream
+func (m *RowFilter) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
 }
 
-type dataNodeServiceGetRangeClient struct {
-	grpc.ClientStream
+func (m *RowFilter) GetRegex() string {
+	if m != nil {
+		return m.Regex
+	}
+	return ""
 }
 
-func (x *dataNodeServiceGetRangeClient) Recv() (*ColumnSet, error) {
-	m := new(ColumnSet)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *RowFilter) GetMinLength() int64 {
+	if m != nil {
+		return m.MinLength
 	}
-	return m, nil
+	return 0
 }
 
-func (c *dataNodeServiceClient) Insert(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Insert", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *RowFilter) GetMaxLength() int64 {
+	if m != nil {
+		return m.MaxLength
 	}
-	return out, nil
+	return 0
 }
 
-// Server API for DataNodeService service
-
-type DataNodeServiceServer interface {
-	//
-	// Request an individual data cell from the database. If multiple versions of
-	// the cell exist, only the latest version will be returned.
-	Get(context.Context, *GetRequest) (*Column, error)
-	//
-	// Request a range of data from the database. This will return all matching
-	// versions of all matching data on the database.
-	GetRange(*GetRangeRequest, DataNodeService_GetRangeServer) error
-	// Set a very specific data cell to the specified value.
-	Insert(context.Context, *InsertRequest) (*Empty, error)
+//
+// FamilyColumns contains the data of a row in a single column family.
+type FamilyColumns struct {
+	// Name of the column family the data was read from.
+	ColumnFamily string `protobuf:"bytes,1,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// All columns of the row read from the column family.
+	ColumnSet []*ColumnSet `protobuf:"bytes,2,rep,name=column_set,json=columnSet" json:"column_set,omitempty"`
 }
 
-func RegisterDataNodeServiceServer(s *grpc.Server, srv DataNodeServiceServer) {
-	s.RegisterService(&_DataNodeService_serviceDesc, srv)
-}
+func (m *FamilyColumns) Reset()                    { *m = FamilyColumns{} }
+func (m *FamilyColumns) String() string            { return proto.CompactTextString(m) }
+func (*FamilyColumns) ProtoMessage()               {}
+func (*FamilyColumns) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
 
-func _DataNodeService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(DataNodeServiceServer).Get(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/redcloud.DataNodeService/Get",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(DataNodeServiceServer).Get(ctx, req.(*GetRequest))
+func (m *FamilyColumns) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
 	}
-	return interceptor(ctx, in, info, handler)
+	return ""
 }
 
-func _DataNodeService_GetRange_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(GetRangeRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (m *FamilyColumns) GetColumnSet() []*ColumnSet {
+	if m != nil {
+		return m.ColumnSet
 	}
-	return srv.(DataNodeServiceServer).GetRange(m, &dataNodeServiceGetRangeServer{stream})
+	return nil
 }
 
-type DataNodeService_GetRangeServer interface {
-	Send(*ColumnSet) error
-	grpc.ServerStream
+//
+// Row contains the data of a row from several column families.
+type Row struct {
+	// Key of the row.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Data of the row per column family, in the order they were requested.
+	Family []*FamilyColumns `protobuf:"bytes,2,rep,name=family" json:"family,omitempty"`
 }
 
-type dataNodeServiceGetRangeServer struct {
-	grpc.ServerStream
-}
+func (m *Row) Reset()                    { *m = Row{} }
+func (m *Row) String() string            { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()               {}
+func (*Row) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{7} }
 
-func (x *dataNodeServiceGetRangeServer) Send(m *ColumnSet) error {
-	return x.ServerStream.SendMsg(m)
+func (m *Row) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *Row) GetFamily() []*FamilyColumns {
+	if m != nil {
+		return m.Family
+	}
+	return nil
+}
+
+//
+// GetRangeResumeToken describes the position at which a paginated range scan
+// stopped. It is passed to clients as an opaque token.
+type GetRangeResumeToken struct {
+	// Key of the last row returned.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the last column returned from that row.
+	Column string `protobuf:"bytes,2,opt,name=column" json:"column,omitempty"`
+}
+
+func (m *GetRangeResumeToken) Reset()                    { *m = GetRangeResumeToken{} }
+func (m *GetRangeResumeToken) String() string            { return proto.CompactTextString(m) }
+func (*GetRangeResumeToken) ProtoMessage()               {}
+func (*GetRangeResumeToken) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{8} }
+
+func (m *GetRangeResumeToken) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *GetRangeResumeToken) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+//
+// GetRangePageResponse contains a page of results of a range scan, grouped by
+// row in key order.
+type GetRangePageResponse struct {
+	// All rows returned in this page.
+	Row []*ColumnFamily `protobuf:"bytes,1,rep,name=row" json:"row,omitempty"`
+	//
+	// Token for resuming the scan after this page in the next GetRangePage
+	// call. Empty if the scan has been completed.
+	NextResumeToken []byte `protobuf:"bytes,2,opt,name=next_resume_token,json=nextResumeToken,proto3" json:"next_resume_token,omitempty"`
+}
+
+func (m *GetRangePageResponse) Reset()                    { *m = GetRangePageResponse{} }
+func (m *GetRangePageResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetRangePageResponse) ProtoMessage()               {}
+func (*GetRangePageResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{9} }
+
+func (m *GetRangePageResponse) GetRow() []*ColumnFamily {
+	if m != nil {
+		return m.Row
+	}
+	return nil
+}
+
+func (m *GetRangePageResponse) GetNextResumeToken() []byte {
+	if m != nil {
+		return m.NextResumeToken
+	}
+	return nil
+}
+
+//
+// InsertRequest describes a request to insert a specific value for the specified
+// key; the timestamp and TTL will be preserved.
+type InsertRequest struct {
+	// Key of the row which shall be updated.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the table to mutate.
+	Table string `protobuf:"bytes,2,opt,name=table" json:"table,omitempty"`
+	// Name of the column family to mutate.
+	ColumnFamily string `protobuf:"bytes,3,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// Name of the column to insert.
+	ColumnName string `protobuf:"bytes,4,opt,name=column_name,json=columnName" json:"column_name,omitempty"`
+	// Column value to insert into the data stream.
+	Column *Column `protobuf:"bytes,5,opt,name=column" json:"column,omitempty"`
+	//
+	// If set, the timestamp of the column is ignored and assigned by the data
+	// node instead, from a clock which is strictly increasing for each tablet.
+	// This is implied for tables with server_timestamps set. Supported by
+	// Insert, StreamInsert and CheckAndInsert.
+	ServerTimestamp bool `protobuf:"varint,6,opt,name=server_timestamp,json=serverTimestamp" json:"server_timestamp,omitempty"`
+}
+
+func (m *InsertRequest) Reset()                    { *m = InsertRequest{} }
+func (m *InsertRequest) String() string            { return proto.CompactTextString(m) }
+func (*InsertRequest) ProtoMessage()               {}
+func (*InsertRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{10} }
+
+func (m *InsertRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *InsertRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *InsertRequest) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *InsertRequest) GetColumnName() string {
+	if m != nil {
+		return m.ColumnName
+	}
+	return ""
+}
+
+func (m *InsertRequest) GetColumn() *Column {
+	if m != nil {
+		return m.Column
+	}
+	return nil
+}
+
+func (m *InsertRequest) GetServerTimestamp() bool {
+	if m != nil {
+		return m.ServerTimestamp
+	}
+	return false
+}
+
+//
+// InsertResponse reports the outcome of an Insert RPC.
+type InsertResponse struct {
+	//
+	// Timestamp the column was written with, in milliseconds since the epoch.
+	// This is the timestamp assigned by the data node if server assigned
+	// timestamps are used.
+	Timestamp int64 `protobuf:"varint,1,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *InsertResponse) Reset()                    { *m = InsertResponse{} }
+func (m *InsertResponse) String() string            { return proto.CompactTextString(m) }
+func (*InsertResponse) ProtoMessage()               {}
+func (*InsertResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{11} }
+
+func (m *InsertResponse) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+//
+// DeleteRequest describes a request to delete data from a row. Depending on the
+// fields set, either a single column, a range of columns or all columns of the
+// row in the column family are deleted.
+type DeleteRequest struct {
+	// Key of the row which shall be updated.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the table to mutate.
+	Table string `protobuf:"bytes,2,opt,name=table" json:"table,omitempty"`
+	// Name of the column family to mutate.
+	ColumnFamily string `protobuf:"bytes,3,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	//
+	// Name of the column to delete. If empty, the columns described by
+	// column_range are deleted instead.
+	Column string `protobuf:"bytes,4,opt,name=column" json:"column,omitempty"`
+	//
+	// Range of columns to delete if no column is given. If neither column nor
+	// column_range are set, all columns of the row are deleted.
+	ColumnRange *ColumnRange `protobuf:"bytes,5,opt,name=column_range,json=columnRange" json:"column_range,omitempty"`
+	//
+	// Timestamp of the deletion in milliseconds. All data not newer than this
+	// timestamp will be deleted. If 0, the current time of the data node will
+	// be used. Ignored for tables with server_timestamps set.
+	Timestamp int64 `protobuf:"varint,6,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *DeleteRequest) Reset()                    { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()               {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{12} }
+
+func (m *DeleteRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *DeleteRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetColumnRange() *ColumnRange {
+	if m != nil {
+		return m.ColumnRange
+	}
+	return nil
+}
+
+func (m *DeleteRequest) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+//
+// IncrementRequest describes a request to add a delta to a counter cell.
+type IncrementRequest struct {
+	// Key of the row which shall be updated.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the table to mutate.
+	Table string `protobuf:"bytes,2,opt,name=table" json:"table,omitempty"`
+	// Name of the column family to mutate.
+	ColumnFamily string `protobuf:"bytes,3,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// Name of the counter column to increment.
+	Column string `protobuf:"bytes,4,opt,name=column" json:"column,omitempty"`
+	// Value to add to the counter. May be negative.
+	Delta int64 `protobuf:"varint,5,opt,name=delta" json:"delta,omitempty"`
+	//
+	// Timestamp of the increment in milliseconds. If 0, the current time of the
+	// data node will be used. Ignored for tables with server_timestamps set.
+	Timestamp int64 `protobuf:"varint,6,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *IncrementRequest) Reset()                    { *m = IncrementRequest{} }
+func (m *IncrementRequest) String() string            { return proto.CompactTextString(m) }
+func (*IncrementRequest) ProtoMessage()               {}
+func (*IncrementRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{13} }
+
+func (m *IncrementRequest) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *IncrementRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *IncrementRequest) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *IncrementRequest) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+func (m *IncrementRequest) GetDelta() int64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *IncrementRequest) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+//
+// CheckAndInsertRequest describes a request to insert a value into a cell only
+// if the latest version of the cell matches the expectations.
+type CheckAndInsertRequest struct {
+	// Insert to apply if the expectations are met.
+	Insert *InsertRequest `protobuf:"bytes,1,opt,name=insert" json:"insert,omitempty"`
+	//
+	// If set, the insert is only applied if the cell currently holds no data.
+	// The expected timestamp and content are ignored in this case.
+	ExpectAbsent bool `protobuf:"varint,2,opt,name=expect_absent,json=expectAbsent" json:"expect_absent,omitempty"`
+	// Timestamp the latest version of the cell is expected to have.
+	ExpectedTimestamp int64 `protobuf:"varint,3,opt,name=expected_timestamp,json=expectedTimestamp" json:"expected_timestamp,omitempty"`
+	// Content the latest version of the cell is expected to have.
+	ExpectedContent []byte `protobuf:"bytes,4,opt,name=expected_content,json=expectedContent,proto3" json:"expected_content,omitempty"`
+}
+
+func (m *CheckAndInsertRequest) Reset()                    { *m = CheckAndInsertRequest{} }
+func (m *CheckAndInsertRequest) String() string            { return proto.CompactTextString(m) }
+func (*CheckAndInsertRequest) ProtoMessage()               {}
+func (*CheckAndInsertRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{14} }
+
+func (m *CheckAndInsertRequest) GetInsert() *InsertRequest {
+	if m != nil {
+		return m.Insert
+	}
+	return nil
+}
+
+func (m *CheckAndInsertRequest) GetExpectAbsent() bool {
+	if m != nil {
+		return m.ExpectAbsent
+	}
+	return false
+}
+
+func (m *CheckAndInsertRequest) GetExpectedTimestamp() int64 {
+	if m != nil {
+		return m.ExpectedTimestamp
+	}
+	return 0
+}
+
+func (m *CheckAndInsertRequest) GetExpectedContent() []byte {
+	if m != nil {
+		return m.ExpectedContent
+	}
+	return nil
+}
+
+//
+// CheckAndInsertResponse reports the outcome of a CheckAndInsert RPC.
+type CheckAndInsertResponse struct {
+	// Whether the expectations were met and the insert has been applied.
+	Applied bool `protobuf:"varint,1,opt,name=applied" json:"applied,omitempty"`
+	//
+	// Latest version of the cell before the insert, or unset if the cell held
+	// no data.
+	Current *Column `protobuf:"bytes,2,opt,name=current" json:"current,omitempty"`
+	//
+	// Timestamp the column was written with if the insert was applied, in
+	// milliseconds since the epoch. This is the timestamp assigned by the data
+	// node if server assigned timestamps are used.
+	Timestamp int64 `protobuf:"varint,3,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *CheckAndInsertResponse) Reset()                    { *m = CheckAndInsertResponse{} }
+func (m *CheckAndInsertResponse) String() string            { return proto.CompactTextString(m) }
+func (*CheckAndInsertResponse) ProtoMessage()               {}
+func (*CheckAndInsertResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{15} }
+
+func (m *CheckAndInsertResponse) GetApplied() bool {
+	if m != nil {
+		return m.Applied
+	}
+	return false
+}
+
+func (m *CheckAndInsertResponse) GetCurrent() *Column {
+	if m != nil {
+		return m.Current
+	}
+	return nil
+}
+
+func (m *CheckAndInsertResponse) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+//
+// StreamInsertCheckpoint is sent periodically by the data node during a
+// StreamInsert RPC to acknowledge the inserts written so far.
+type StreamInsertCheckpoint struct {
+	//
+	// Number of inserts received through the stream which have been written to
+	// the journal, counted from the start of the stream.
+	NumInserted int64 `protobuf:"varint,1,opt,name=num_inserted,json=numInserted" json:"num_inserted,omitempty"`
+}
+
+func (m *StreamInsertCheckpoint) Reset()                    { *m = StreamInsertCheckpoint{} }
+func (m *StreamInsertCheckpoint) String() string            { return proto.CompactTextString(m) }
+func (*StreamInsertCheckpoint) ProtoMessage()               {}
+func (*StreamInsertCheckpoint) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{16} }
+
+func (m *StreamInsertCheckpoint) GetNumInserted() int64 {
+	if m != nil {
+		return m.NumInserted
+	}
+	return 0
+}
+
+//
+// Mutation describes a single change to a row which is part of a batch.
+type Mutation struct {
+	// Type of the change to apply to the row.
+	Type Mutation_MutationType `protobuf:"varint,1,opt,name=type,enum=redcloud.Mutation_MutationType" json:"type,omitempty"`
+	// Name of the column family to mutate.
+	ColumnFamily string `protobuf:"bytes,2,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	//
+	// Name of the column to insert or delete. For deletions, an empty column
+	// means that the columns described by column_range are deleted instead.
+	Column string `protobuf:"bytes,3,opt,name=column" json:"column,omitempty"`
+	// Column value to insert into the data stream. Only used for inserts.
+	Value *Column `protobuf:"bytes,4,opt,name=value" json:"value,omitempty"`
+	//
+	// Range of columns to delete if no column is given. If neither column nor
+	// column_range are set, all columns of the row in the column family are
+	// deleted. Only used for deletions.
+	ColumnRange *ColumnRange `protobuf:"bytes,5,opt,name=column_range,json=columnRange" json:"column_range,omitempty"`
+	//
+	// Timestamp of the deletion in milliseconds, or 0 to use the current time
+	// of the data node. Only used for deletions. Ignored for tables with
+	// server_timestamps set, where the mutations of a row are assigned
+	// consecutive timestamps in the order they are listed in.
+	Timestamp int64 `protobuf:"varint,6,opt,name=timestamp" json:"timestamp,omitempty"`
+}
+
+func (m *Mutation) Reset()                    { *m = Mutation{} }
+func (m *Mutation) String() string            { return proto.CompactTextString(m) }
+func (*Mutation) ProtoMessage()               {}
+func (*Mutation) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{17} }
+
+func (m *Mutation) GetType() Mutation_MutationType {
+	if m != nil {
+		return m.Type
+	}
+	return Mutation_INSERT
+}
+
+func (m *Mutation) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *Mutation) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+func (m *Mutation) GetValue() *Column {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Mutation) GetColumnRange() *ColumnRange {
+	if m != nil {
+		return m.ColumnRange
+	}
+	return nil
+}
+
+func (m *Mutation) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+//
+// RowMutation describes a number of changes to a single row. They will be
+// applied together, i.e. a reader will see either all or none of the changes
+// made to a column family of the row.
+type RowMutation struct {
+	// Key of the row which shall be updated.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// List of all changes to make to the row.
+	Mutation []*Mutation `protobuf:"bytes,2,rep,name=mutation" json:"mutation,omitempty"`
+}
+
+func (m *RowMutation) Reset()                    { *m = RowMutation{} }
+func (m *RowMutation) String() string            { return proto.CompactTextString(m) }
+func (*RowMutation) ProtoMessage()               {}
+func (*RowMutation) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{18} }
+
+func (m *RowMutation) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *RowMutation) GetMutation() []*Mutation {
+	if m != nil {
+		return m.Mutation
+	}
+	return nil
+}
+
+//
+// BatchMutateRequest describes a request to apply changes to a number of rows
+// of a table at once.
+type BatchMutateRequest struct {
+	// Name of the table to mutate.
+	Table string `protobuf:"bytes,1,opt,name=table" json:"table,omitempty"`
+	// List of the rows to change, along with the changes.
+	Row []*RowMutation `protobuf:"bytes,2,rep,name=row" json:"row,omitempty"`
+}
+
+func (m *BatchMutateRequest) Reset()                    { *m = BatchMutateRequest{} }
+func (m *BatchMutateRequest) String() string            { return proto.CompactTextString(m) }
+func (*BatchMutateRequest) ProtoMessage()               {}
+func (*BatchMutateRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{19} }
+
+func (m *BatchMutateRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *BatchMutateRequest) GetRow() []*RowMutation {
+	if m != nil {
+		return m.Row
+	}
+	return nil
+}
+
+//
+// CellReference identifies a single data cell of a table.
+type CellReference struct {
+	// Key of the row holding the cell.
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Name of the column family the cell is stored at.
+	ColumnFamily string `protobuf:"bytes,2,opt,name=column_family,json=columnFamily" json:"column_family,omitempty"`
+	// Name of the column of the cell.
+	Column string `protobuf:"bytes,3,opt,name=column" json:"column,omitempty"`
+}
+
+func (m *CellReference) Reset()                    { *m = CellReference{} }
+func (m *CellReference) String() string            { return proto.CompactTextString(m) }
+func (*CellReference) ProtoMessage()               {}
+func (*CellReference) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{20} }
+
+func (m *CellReference) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *CellReference) GetColumnFamily() string {
+	if m != nil {
+		return m.ColumnFamily
+	}
+	return ""
+}
+
+func (m *CellReference) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
+//
+// MultiGetRequest describes a request for fetching the latest versions of a
+// number of data cells of a table at once.
+type MultiGetRequest struct {
+	// Name of the table which data is being extracted from.
+	Table string `protobuf:"bytes,1,opt,name=table" json:"table,omitempty"`
+	// List of the cells to fetch.
+	Cell []*CellReference `protobuf:"bytes,2,rep,name=cell" json:"cell,omitempty"`
+}
+
+func (m *MultiGetRequest) Reset()                    { *m = MultiGetRequest{} }
+func (m *MultiGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*MultiGetRequest) ProtoMessage()               {}
+func (*MultiGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{21} }
+
+func (m *MultiGetRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *MultiGetRequest) GetCell() []*CellReference {
+	if m != nil {
+		return m.Cell
+	}
+	return nil
+}
+
+//
+// MultiGetResult holds the result of looking up a single cell of a
+// MultiGetRequest.
+type MultiGetResult struct {
+	// Latest version of the cell, or unset if the cell holds no data.
+	Column *Column `protobuf:"bytes,1,opt,name=column" json:"column,omitempty"`
+	//
+	// Set if the tablet holding the cell is not loaded on the data node. The
+	// cell has to be requested from the data node serving the tablet instead.
+	TabletNotLoaded bool `protobuf:"varint,2,opt,name=tablet_not_loaded,json=tabletNotLoaded" json:"tablet_not_loaded,omitempty"`
+	// Description of any errors encountered looking up the cell.
+	Error string `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+	//
+	// gRPC status code of the errors encountered looking up the cell, as a
+	// plain RPC would have returned it. DATA_LOSS indicates that the data of
+	// the cell has been found to be corrupted.
+	ErrorCode uint32 `protobuf:"varint,4,opt,name=error_code,json=errorCode" json:"error_code,omitempty"`
+}
+
+func (m *MultiGetResult) Reset()                    { *m = MultiGetResult{} }
+func (m *MultiGetResult) String() string            { return proto.CompactTextString(m) }
+func (*MultiGetResult) ProtoMessage()               {}
+func (*MultiGetResult) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{22} }
+
+func (m *MultiGetResult) GetColumn() *Column {
+	if m != nil {
+		return m.Column
+	}
+	return nil
+}
+
+func (m *MultiGetResult) GetTabletNotLoaded() bool {
+	if m != nil {
+		return m.TabletNotLoaded
+	}
+	return false
+}
+
+func (m *MultiGetResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *MultiGetResult) GetErrorCode() uint32 {
+	if m != nil {
+		return m.ErrorCode
+	}
+	return 0
+}
+
+//
+// MultiGetResponse holds the results of a MultiGetRequest, in the same order
+// as the cells of the request.
+type MultiGetResponse struct {
+	Result []*MultiGetResult `protobuf:"bytes,1,rep,name=result" json:"result,omitempty"`
+}
+
+func (m *MultiGetResponse) Reset()                    { *m = MultiGetResponse{} }
+func (m *MultiGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*MultiGetResponse) ProtoMessage()               {}
+func (*MultiGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{23} }
+
+func (m *MultiGetResponse) GetResult() []*MultiGetResult {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetRequest)(nil), "redcloud.GetRequest")
+	proto.RegisterType((*ColumnRange)(nil), "redcloud.ColumnRange")
+	proto.RegisterType((*GetRowRequest)(nil), "redcloud.GetRowRequest")
+	proto.RegisterType((*ColumnFamilySelection)(nil), "redcloud.ColumnFamilySelection")
+	proto.RegisterType((*GetRangeRequest)(nil), "redcloud.GetRangeRequest")
+	proto.RegisterType((*RowFilter)(nil), "redcloud.RowFilter")
+	proto.RegisterType((*FamilyColumns)(nil), "redcloud.FamilyColumns")
+	proto.RegisterType((*Row)(nil), "redcloud.Row")
+	proto.RegisterType((*GetRangeResumeToken)(nil), "redcloud.GetRangeResumeToken")
+	proto.RegisterType((*GetRangePageResponse)(nil), "redcloud.GetRangePageResponse")
+	proto.RegisterType((*InsertRequest)(nil), "redcloud.InsertRequest")
+	proto.RegisterType((*InsertResponse)(nil), "redcloud.InsertResponse")
+	proto.RegisterType((*DeleteRequest)(nil), "redcloud.DeleteRequest")
+	proto.RegisterType((*IncrementRequest)(nil), "redcloud.IncrementRequest")
+	proto.RegisterType((*CheckAndInsertRequest)(nil), "redcloud.CheckAndInsertRequest")
+	proto.RegisterType((*CheckAndInsertResponse)(nil), "redcloud.CheckAndInsertResponse")
+	proto.RegisterType((*StreamInsertCheckpoint)(nil), "redcloud.StreamInsertCheckpoint")
+	proto.RegisterType((*Mutation)(nil), "redcloud.Mutation")
+	proto.RegisterType((*RowMutation)(nil), "redcloud.RowMutation")
+	proto.RegisterType((*BatchMutateRequest)(nil), "redcloud.BatchMutateRequest")
+	proto.RegisterType((*CellReference)(nil), "redcloud.CellReference")
+	proto.RegisterType((*MultiGetRequest)(nil), "redcloud.MultiGetRequest")
+	proto.RegisterType((*MultiGetResult)(nil), "redcloud.MultiGetResult")
+	proto.RegisterType((*MultiGetResponse)(nil), "redcloud.MultiGetResponse")
+	proto.RegisterEnum("redcloud.RowFilter_FilterType", RowFilter_FilterType_name, RowFilter_FilterType_value)
+	proto.RegisterEnum("redcloud.Mutation_MutationType", Mutation_MutationType_name, Mutation_MutationType_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for DataNodeService service
+
+type DataNodeServiceClient interface {
+	//
+	// Request an individual data cell from the database. If multiple versions of
+	// the cell exist, only the latest version will be returned.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Column, error)
+	//
+	// Request the latest versions of a number of columns from several column
+	// families of a single row.
+	GetRow(ctx context.Context, in *GetRowRequest, opts ...grpc.CallOption) (*Row, error)
+	//
+	// Request the latest versions of a number of individual data cells at once.
+	// Cells of tablets not loaded on the data node are marked as such rather
+	// than failing the entire request.
+	MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error)
+	//
+	// Request a range of data from the database. This will return all matching
+	// versions of all matching data on the database.
+	GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeClient, error)
+	//
+	// Request a range of data from the database like GetRange, but return the
+	// data grouped by row, in key order, along with the row key.
+	GetRangeRows(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeRowsClient, error)
+	//
+	// Request a single page of a range scan, of at most page_size cells. The
+	// response contains a token to request the next page with.
+	GetRangePage(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetRangePageResponse, error)
+	//
+	// Request a range of data from several column families at a time. The data
+	// of each row is merged across the column families and returned in key
+	// order.
+	GetRangeFamilies(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeFamiliesClient, error)
+	//
+	// Set a very specific data cell to the specified value. Returns the
+	// timestamp the cell was written with.
+	Insert(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*InsertResponse, error)
+	//
+	// Set a data cell to the specified value only if its latest version matches
+	// the expected timestamp and content, or if it holds no data if requested.
+	// Conditional inserts are serialized with all other writes to the same
+	// column family of the tablet, so no write can slip in between the check
+	// and the insert.
+	CheckAndInsert(ctx context.Context, in *CheckAndInsertRequest, opts ...grpc.CallOption) (*CheckAndInsertResponse, error)
+	//
+	// Add a delta to a counter cell. Get and GetRange will return the sum of all
+	// deltas as a single COUNTER column.
+	Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*Empty, error)
+	//
+	// Insert a stream of data cells into the database. A checkpoint will be sent
+	// back periodically to acknowledge the inserts written so far, as well as
+	// when the stream ends or fails.
+	StreamInsert(ctx context.Context, opts ...grpc.CallOption) (DataNodeService_StreamInsertClient, error)
+	//
+	// Delete a single data cell, a range of columns or an entire row from the
+	// database. Deleted data will no longer be returned by Get or GetRange.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Empty, error)
+	//
+	// Apply a number of inserts and deletions to rows in a table. All changes to
+	// a column family of a row are applied atomically.
+	BatchMutate(ctx context.Context, in *BatchMutateRequest, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type dataNodeServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDataNodeServiceClient(cc *grpc.ClientConn) DataNodeServiceClient {
+	return &dataNodeServiceClient{cc}
+}
+
+func (c *dataNodeServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Column, error) {
+	out := new(Column)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Get", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) GetRow(ctx context.Context, in *GetRowRequest, opts ...grpc.CallOption) (*Row, error) {
+	out := new(Row)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/GetRow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error) {
+	out := new(MultiGetResponse)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/MultiGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DataNodeService_serviceDesc.Streams[0], c.cc, "/redcloud.DataNodeService/GetRange", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodeServiceGetRangeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DataNodeService_GetRangeClient interface {
+	Recv() (*ColumnSet, error)
+	grpc.ClientStream
+}
+
+type dataNodeServiceGetRangeClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodeServiceGetRangeClient) Recv() (*ColumnSet, error) {
+	m := new(ColumnSet)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeServiceClient) GetRangeRows(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeRowsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DataNodeService_serviceDesc.Streams[1], c.cc, "/redcloud.DataNodeService/GetRangeRows", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodeServiceGetRangeRowsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DataNodeService_GetRangeRowsClient interface {
+	Recv() (*ColumnFamily, error)
+	grpc.ClientStream
+}
+
+type dataNodeServiceGetRangeRowsClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodeServiceGetRangeRowsClient) Recv() (*ColumnFamily, error) {
+	m := new(ColumnFamily)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeServiceClient) GetRangePage(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetRangePageResponse, error) {
+	out := new(GetRangePageResponse)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/GetRangePage", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) GetRangeFamilies(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (DataNodeService_GetRangeFamiliesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DataNodeService_serviceDesc.Streams[2], c.cc, "/redcloud.DataNodeService/GetRangeFamilies", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodeServiceGetRangeFamiliesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DataNodeService_GetRangeFamiliesClient interface {
+	Recv() (*Row, error)
+	grpc.ClientStream
+}
+
+type dataNodeServiceGetRangeFamiliesClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodeServiceGetRangeFamiliesClient) Recv() (*Row, error) {
+	m := new(Row)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeServiceClient) Insert(ctx context.Context, in *InsertRequest, opts ...grpc.CallOption) (*InsertResponse, error) {
+	out := new(InsertResponse)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Insert", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) CheckAndInsert(ctx context.Context, in *CheckAndInsertRequest, opts ...grpc.CallOption) (*CheckAndInsertResponse, error) {
+	out := new(CheckAndInsertResponse)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/CheckAndInsert", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) Increment(ctx context.Context, in *IncrementRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Increment", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) StreamInsert(ctx context.Context, opts ...grpc.CallOption) (DataNodeService_StreamInsertClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DataNodeService_serviceDesc.Streams[3], c.cc, "/redcloud.DataNodeService/StreamInsert", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dataNodeServiceStreamInsertClient{stream}
+	return x, nil
+}
+
+type DataNodeService_StreamInsertClient interface {
+	Send(*InsertRequest) error
+	Recv() (*StreamInsertCheckpoint, error)
+	grpc.ClientStream
+}
+
+type dataNodeServiceStreamInsertClient struct {
+	grpc.ClientStream
+}
+
+func (x *dataNodeServiceStreamInsertClient) Send(m *InsertRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *dataNodeServiceStreamInsertClient) Recv() (*StreamInsertCheckpoint, error) {
+	m := new(StreamInsertCheckpoint)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dataNodeServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/Delete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dataNodeServiceClient) BatchMutate(ctx context.Context, in *BatchMutateRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := grpc.Invoke(ctx, "/redcloud.DataNodeService/BatchMutate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for DataNodeService service
+
+type DataNodeServiceServer interface {
+	//
+	// Request an individual data cell from the database. If multiple versions of
+	// the cell exist, only the latest version will be returned.
+	Get(context.Context, *GetRequest) (*Column, error)
+	//
+	// Request the latest versions of a number of columns from several column
+	// families of a single row.
+	GetRow(context.Context, *GetRowRequest) (*Row, error)
+	//
+	// Request the latest versions of a number of individual data cells at once.
+	// Cells of tablets not loaded on the data node are marked as such rather
+	// than failing the entire request.
+	MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error)
+	//
+	// Request a range of data from the database. This will return all matching
+	// versions of all matching data on the database.
+	GetRange(*GetRangeRequest, DataNodeService_GetRangeServer) error
+	//
+	// Request a range of data from the database like GetRange, but return the
+	// data grouped by row, in key order, along with the row key.
+	GetRangeRows(*GetRangeRequest, DataNodeService_GetRangeRowsServer) error
+	//
+	// Request a single page of a range scan, of at most page_size cells. The
+	// response contains a token to request the next page with.
+	GetRangePage(context.Context, *GetRangeRequest) (*GetRangePageResponse, error)
+	//
+	// Request a range of data from several column families at a time. The data
+	// of each row is merged across the column families and returned in key
+	// order.
+	GetRangeFamilies(*GetRangeRequest, DataNodeService_GetRangeFamiliesServer) error
+	//
+	// Set a very specific data cell to the specified value. Returns the
+	// timestamp the cell was written with.
+	Insert(context.Context, *InsertRequest) (*InsertResponse, error)
+	//
+	// Set a data cell to the specified value only if its latest version matches
+	// the expected timestamp and content, or if it holds no data if requested.
+	// Conditional inserts are serialized with all other writes to the same
+	// column family of the tablet, so no write can slip in between the check
+	// and the insert.
+	CheckAndInsert(context.Context, *CheckAndInsertRequest) (*CheckAndInsertResponse, error)
+	//
+	// Add a delta to a counter cell. Get and GetRange will return the sum of all
+	// deltas as a single COUNTER column.
+	Increment(context.Context, *IncrementRequest) (*Empty, error)
+	//
+	// Insert a stream of data cells into the database. A checkpoint will be sent
+	// back periodically to acknowledge the inserts written so far, as well as
+	// when the stream ends or fails.
+	StreamInsert(DataNodeService_StreamInsertServer) error
+	//
+	// Delete a single data cell, a range of columns or an entire row from the
+	// database. Deleted data will no longer be returned by Get or GetRange.
+	Delete(context.Context, *DeleteRequest) (*Empty, error)
+	//
+	// Apply a number of inserts and deletions to rows in a table. All changes to
+	// a column family of a row are applied atomically.
+	BatchMutate(context.Context, *BatchMutateRequest) (*Empty, error)
+}
+
+func RegisterDataNodeServiceServer(s *grpc.Server, srv DataNodeServiceServer) {
+	s.RegisterService(&_DataNodeService_serviceDesc, srv)
+}
+
+func _DataNodeService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_GetRow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).GetRow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/GetRow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).GetRow(ctx, req.(*GetRowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_MultiGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).MultiGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/MultiGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).MultiGet(ctx, req.(*MultiGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_GetRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DataNodeServiceServer).GetRange(m, &dataNodeServiceGetRangeServer{stream})
+}
+
+type DataNodeService_GetRangeServer interface {
+	Send(*ColumnSet) error
+	grpc.ServerStream
+}
+
+type dataNodeServiceGetRangeServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodeServiceGetRangeServer) Send(m *ColumnSet) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DataNodeService_GetRangeRows_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DataNodeServiceServer).GetRangeRows(m, &dataNodeServiceGetRangeRowsServer{stream})
+}
+
+type DataNodeService_GetRangeRowsServer interface {
+	Send(*ColumnFamily) error
+	grpc.ServerStream
+}
+
+type dataNodeServiceGetRangeRowsServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodeServiceGetRangeRowsServer) Send(m *ColumnFamily) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _DataNodeService_GetRangePage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).GetRangePage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/GetRangePage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).GetRangePage(ctx, req.(*GetRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_GetRangeFamilies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DataNodeServiceServer).GetRangeFamilies(m, &dataNodeServiceGetRangeFamiliesServer{stream})
+}
+
+type DataNodeService_GetRangeFamiliesServer interface {
+	Send(*Row) error
+	grpc.ServerStream
+}
+
+type dataNodeServiceGetRangeFamiliesServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodeServiceGetRangeFamiliesServer) Send(m *Row) error {
+	return x.ServerStream.SendMsg(m)
 }
 
 func _DataNodeService_Insert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
@@ -408,6 +1796,104 @@ func _DataNodeService_Insert_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DataNodeService_CheckAndInsert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckAndInsertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).CheckAndInsert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/CheckAndInsert",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).CheckAndInsert(ctx, req.(*CheckAndInsertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/Increment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).Increment(ctx, req.(*IncrementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_StreamInsert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DataNodeServiceServer).StreamInsert(&dataNodeServiceStreamInsertServer{stream})
+}
+
+type DataNodeService_StreamInsertServer interface {
+	Send(*StreamInsertCheckpoint) error
+	Recv() (*InsertRequest, error)
+	grpc.ServerStream
+}
+
+type dataNodeServiceStreamInsertServer struct {
+	grpc.ServerStream
+}
+
+func (x *dataNodeServiceStreamInsertServer) Send(m *StreamInsertCheckpoint) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *dataNodeServiceStreamInsertServer) Recv() (*InsertRequest, error) {
+	m := new(InsertRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DataNodeService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DataNodeService_BatchMutate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchMutateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServiceServer).BatchMutate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/redcloud.DataNodeService/BatchMutate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServiceServer).BatchMutate(ctx, req.(*BatchMutateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _DataNodeService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "redcloud.DataNodeService",
 	HandlerType: (*DataNodeServiceServer)(nil),
@@ -416,10 +1902,38 @@ var _DataNodeService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _DataNodeService_Get_Handler,
 		},
+		{
+			MethodName: "GetRow",
+			Handler:    _DataNodeService_GetRow_Handler,
+		},
+		{
+			MethodName: "MultiGet",
+			Handler:    _DataNodeService_MultiGet_Handler,
+		},
+		{
+			MethodName: "GetRangePage",
+			Handler:    _DataNodeService_GetRangePage_Handler,
+		},
 		{
 			MethodName: "Insert",
 			Handler:    _DataNodeService_Insert_Handler,
 		},
+		{
+			MethodName: "CheckAndInsert",
+			Handler:    _DataNodeService_CheckAndInsert_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _DataNodeService_Increment_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _DataNodeService_Delete_Handler,
+		},
+		{
+			MethodName: "BatchMutate",
+			Handler:    _DataNodeService_BatchMutate_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -427,6 +1941,22 @@ var _DataNodeService_serviceDesc = grpc.ServiceDesc{
 			Handler:       _DataNodeService_GetRange_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetRangeRows",
+			Handler:       _DataNodeService_GetRangeRows_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetRangeFamilies",
+			Handler:       _DataNodeService_GetRangeFamilies_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamInsert",
+			Handler:       _DataNodeService_StreamInsert_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "data_interface.proto",
 }
@@ -434,32 +1964,103 @@ var _DataNodeService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("data_interface.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 426 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x93, 0xdf, 0x6e, 0x94, 0x40,
-	0x14, 0xc6, 0x77, 0x96, 0x96, 0xee, 0x1e, 0x68, 0xb6, 0x19, 0x37, 0x16, 0x31, 0xa6, 0x88, 0x37,
-	0x5c, 0xad, 0xa6, 0x7a, 0xed, 0x8d, 0x7f, 0x1a, 0xd3, 0xa4, 0x26, 0xd4, 0x7b, 0x32, 0x85, 0x53,
-	0x43, 0x64, 0x06, 0x0a, 0x07, 0xb3, 0x3c, 0x80, 0xcf, 0xe2, 0x2b, 0xf8, 0x78, 0x86, 0x19, 0x08,
-	0x54, 0x93, 0xde, 0xf5, 0x6e, 0xe6, 0xfb, 0x7e, 0xe7, 0x84, 0xef, 0xcc, 0x01, 0xb6, 0x99, 0x20,
-	0x91, 0xe4, 0x8a, 0xb0, 0xbe, 0x15, 0x29, 0xee, 0xaa, 0xba, 0xa4, 0x92, 0xaf, 0x6a, 0xcc, 0xd2,
-	0xa2, 0x6c, 0x33, 0xdf, 0xa1, 0xae, 0xc2, 0xc6, 0xc8, 0xe1, 0x1d, 0xc0, 0x05, 0x52, 0x8c, 0x77,
-	0x2d, 0x36, 0xc4, 0x4f, 0xc0, 0xfa, 0x81, 0x9d, 0xc7, 0x02, 0x16, 0xb9, 0x71, 0x7f, 0xe4, 0x5b,
-	0x38, 0x24, 0x71, 0x53, 0xa0, 0xb7, 0x0c, 0x58, 0xb4, 0x8e, 0xcd, 0x85, 0xbf, 0x82, 0xe3, 0xb4,
-	0x2c, 0x5a, 0xa9, 0x92, 0x5b, 0x21, 0xf3, 0xa2, 0xf3, 0x2c, 0xed, 0xba, 0x46, 0xfc, 0xac, 0x35,
-	0xfe, 0x14, 0x6c, 0x73, 0xf7, 0x0e, 0xb4, 0x3b, 0xdc, 0xc2, 0xaf, 0xe0, 0x7c, 0xd0, 0xa7, 0x58,
-	0xa8, 0xef, 0xc8, 0x5f, 0x82, 0xdb, 0x90, 0xa8, 0x29, 0x19, 0x60, 0xa6, 0x61, 0x47, 0x6b, 0x86,
-	0xe3, 0x2f, 0x00, 0x50, 0x65, 0x23, 0x60, 0xbe, 0x64, 0x8d, 0x2a, 0x33, 0x76, 0xf8, 0x6b, 0x09,
-	0x9b, 0x3e, 0x44, 0xdf, 0x6e, 0x4c, 0xf2, 0x1c, 0xd6, 0xa6, 0xeb, 0x94, 0x67, 0xa5, 0x85, 0x4b,
-	0xec, 0xf8, 0x29, 0x1c, 0xf5, 0xfd, 0x7a, 0x6b, 0xa9, 0x2d, 0x1b, 0x55, 0x76, 0x39, 0x4f, 0x6b,
-	0x3d, 0x98, 0xf6, 0xe0, 0xc1, 0xb4, 0x87, 0x81, 0x35, 0xa5, 0xed, 0x8b, 0x65, 0xae, 0x12, 0xca,
-	0x25, 0x36, 0x24, 0x64, 0xe5, 0xd9, 0x01, 0x8b, 0xac, 0xd8, 0x95, 0xb9, 0xfa, 0x36, 0x6a, 0x1a,
-	0x12, 0xfb, 0x19, 0x74, 0x34, 0x40, 0x62, 0x3f, 0x41, 0x67, 0xe0, 0xf4, 0x50, 0x8d, 0x4d, 0x5b,
-	0x50, 0xe3, 0xad, 0x34, 0x02, 0x52, 0xec, 0x63, 0xa3, 0x84, 0xbf, 0x19, 0x1c, 0x7f, 0x51, 0x0d,
-	0xd6, 0x8f, 0xf3, 0x9e, 0x67, 0xe0, 0x0c, 0x90, 0x12, 0x12, 0x87, 0x21, 0x80, 0x91, 0xae, 0x84,
-	0x44, 0x1e, 0xcd, 0x46, 0xc0, 0x22, 0xe7, 0xfc, 0x64, 0x37, 0xee, 0xdc, 0x6e, 0x78, 0xf0, 0xc1,
-	0x3f, 0xff, 0xc3, 0x60, 0xf3, 0x51, 0x90, 0xb8, 0x2a, 0x33, 0xbc, 0xc6, 0xfa, 0x67, 0x9e, 0x22,
-	0x7f, 0x0d, 0xd6, 0x05, 0x12, 0xdf, 0x4e, 0x45, 0xd3, 0x62, 0xfa, 0xff, 0xb5, 0x0a, 0x17, 0xfc,
-	0x3d, 0xac, 0xc6, 0x57, 0xe7, 0xcf, 0xee, 0x57, 0xcd, 0x36, 0xc1, 0x7f, 0xf2, 0x6f, 0xe9, 0x35,
-	0x52, 0xb8, 0x78, 0xc3, 0xf8, 0x3b, 0xb0, 0xcd, 0xb4, 0xf8, 0xe9, 0x84, 0xdc, 0x9b, 0x9f, 0xbf,
-	0x99, 0x8c, 0x4f, 0xb2, 0xa2, 0x2e, 0x5c, 0xdc, 0xd8, 0xfa, 0xbf, 0x79, 0xfb, 0x37, 0x00, 0x00,
-	0xff, 0xff, 0xb2, 0xc4, 0xee, 0xc3, 0x66, 0x03, 0x00, 0x00,
+	// 1555 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x5f, 0x6f, 0xdb, 0x46,
+	0x12, 0x17, 0x25, 0x5b, 0x96, 0x46, 0x92, 0x45, 0x6f, 0x1c, 0x87, 0xa7, 0xcb, 0xc5, 0x3e, 0x06,
+	0x97, 0x73, 0x12, 0x9c, 0x63, 0x38, 0x07, 0xdc, 0xe1, 0x0e, 0x97, 0xab, 0x6b, 0x2b, 0x8e, 0x61,
+	0xc7, 0x49, 0x57, 0x76, 0x91, 0xbe, 0x94, 0x60, 0xc8, 0xb1, 0x4d, 0x84, 0x7f, 0x54, 0x72, 0x65,
+	0xcb, 0x41, 0x3f, 0x48, 0x81, 0xbe, 0xf6, 0xad, 0x1f, 0xa4, 0x2f, 0x7d, 0x2a, 0xfa, 0xd4, 0x8f,
+	0x50, 0xf4, 0x43, 0x14, 0xbb, 0xcb, 0xbf, 0x12, 0xed, 0x24, 0x6d, 0x93, 0x27, 0x71, 0x67, 0x7e,
+	0x3b, 0x3b, 0x33, 0x3b, 0xff, 0x56, 0xb0, 0x68, 0x9b, 0xcc, 0x34, 0x1c, 0x9f, 0x61, 0x78, 0x6c,
+	0x5a, 0xb8, 0x36, 0x0c, 0x03, 0x16, 0x90, 0x46, 0x88, 0xb6, 0xe5, 0x06, 0x23, 0xbb, 0xd7, 0x62,
+	0x17, 0x43, 0x8c, 0x24, 0x59, 0xff, 0x4a, 0x01, 0xd8, 0x41, 0x46, 0xf1, 0x8b, 0x11, 0x46, 0x8c,
+	0xa8, 0x50, 0x7b, 0x85, 0x17, 0x9a, 0xb2, 0xa2, 0xac, 0xb6, 0x29, 0xff, 0x24, 0x8b, 0x30, 0xcb,
+	0xcc, 0x97, 0x2e, 0x6a, 0xd5, 0x15, 0x65, 0xb5, 0x49, 0xe5, 0x82, 0xdc, 0x86, 0x8e, 0x15, 0xb8,
+	0x23, 0xcf, 0x37, 0x8e, 0x4d, 0xcf, 0x71, 0x2f, 0xb4, 0x9a, 0xe0, 0xb6, 0x25, 0xf1, 0xb1, 0xa0,
+	0x91, 0x25, 0xa8, 0xcb, 0xb5, 0x36, 0x23, 0xb8, 0xf1, 0x8a, 0xfc, 0x0d, 0xe6, 0x43, 0x34, 0x6d,
+	0x83, 0x39, 0x1e, 0x46, 0xcc, 0xf4, 0x86, 0xda, 0xec, 0x8a, 0xb2, 0x5a, 0xa3, 0x1d, 0x4e, 0x3d,
+	0x4c, 0x88, 0xfa, 0x33, 0x68, 0x6d, 0x89, 0x0d, 0xd4, 0xf4, 0x4f, 0x90, 0xfc, 0x15, 0xda, 0x11,
+	0x33, 0x43, 0x66, 0xc4, 0x32, 0x15, 0x21, 0xb3, 0x25, 0x68, 0x12, 0x47, 0xfe, 0x02, 0x80, 0xbe,
+	0x9d, 0x00, 0xa4, 0xc2, 0x4d, 0xf4, 0x6d, 0xc9, 0xd6, 0x87, 0xd0, 0xe1, 0xa6, 0x06, 0xe7, 0xef,
+	0x6a, 0xed, 0xbf, 0xa0, 0x9e, 0x9a, 0x59, 0x5b, 0x6d, 0x6d, 0x2c, 0xaf, 0x25, 0xce, 0x5c, 0xdb,
+	0xca, 0x19, 0x3c, 0x40, 0x17, 0x2d, 0xe6, 0x04, 0x3e, 0x8d, 0xe1, 0xfa, 0x8f, 0x0a, 0x5c, 0x2f,
+	0x45, 0x4c, 0x3b, 0x50, 0xb9, 0xd2, 0x81, 0xd5, 0x95, 0x5a, 0xce, 0x81, 0xff, 0x86, 0x18, 0x67,
+	0x84, 0xdc, 0x35, 0xc2, 0xf9, 0xad, 0x8d, 0xeb, 0x93, 0x5a, 0x09, 0xbf, 0xd1, 0x96, 0x95, 0x2d,
+	0x72, 0xc7, 0x0e, 0x43, 0x3c, 0x76, 0xc6, 0xf1, 0xcd, 0xc4, 0xe2, 0x9e, 0x0b, 0x1a, 0x59, 0x86,
+	0x96, 0xe9, 0xba, 0xb1, 0x1b, 0x23, 0x71, 0x39, 0x0d, 0x0a, 0xa6, 0xeb, 0x4a, 0xb1, 0x91, 0xfe,
+	0xcb, 0x0c, 0x74, 0xb9, 0x27, 0x85, 0xfc, 0xd8, 0x97, 0x7f, 0x86, 0xa6, 0xbc, 0x9e, 0xcc, 0xa3,
+	0x0d, 0x41, 0xd8, 0xc3, 0x0b, 0x72, 0x03, 0xe6, 0xf8, 0xc5, 0x70, 0x56, 0x55, 0xb0, 0xea, 0xe8,
+	0xdb, 0x7b, 0x79, 0x7f, 0xd7, 0xae, 0x8c, 0xae, 0x99, 0x2b, 0x9d, 0x33, 0x5b, 0x70, 0xce, 0x6d,
+	0xe8, 0x78, 0x8e, 0x9f, 0x0b, 0xae, 0xba, 0x08, 0xae, 0xb6, 0xe7, 0xf8, 0x69, 0x6c, 0x09, 0x90,
+	0x39, 0xce, 0x81, 0xe6, 0x62, 0x90, 0x39, 0xce, 0x40, 0xcb, 0xd0, 0xe2, 0xa0, 0x10, 0xa3, 0x91,
+	0xcb, 0x22, 0xad, 0x21, 0x20, 0xe0, 0x99, 0x63, 0x2a, 0x29, 0xdc, 0xe6, 0xa1, 0x79, 0x82, 0x46,
+	0xe4, 0xbc, 0x46, 0xad, 0x29, 0xd8, 0x0d, 0x4e, 0x18, 0x38, 0xaf, 0x45, 0xbc, 0xf2, 0x9d, 0x1e,
+	0x1a, 0x2c, 0x78, 0x85, 0xbe, 0x06, 0xc2, 0xf0, 0x96, 0xa4, 0x1d, 0x72, 0x52, 0x2e, 0xae, 0x5a,
+	0xef, 0x14, 0x57, 0x53, 0x01, 0xd0, 0xfe, 0xed, 0x01, 0xd0, 0x79, 0x73, 0x00, 0xcc, 0x4f, 0x06,
+	0x00, 0xb9, 0x0f, 0xf5, 0x63, 0xc7, 0x65, 0x18, 0x6a, 0x5d, 0xa1, 0xf8, 0xb5, 0xec, 0x64, 0x1a,
+	0x9c, 0x3f, 0x16, 0x2c, 0x1a, 0x43, 0x88, 0x06, 0x73, 0x21, 0x9e, 0x61, 0x18, 0xa1, 0xa6, 0x0a,
+	0x49, 0xc9, 0xb2, 0xa4, 0x10, 0x2c, 0x94, 0x15, 0x82, 0x9f, 0xaa, 0xd0, 0x4c, 0xc5, 0x92, 0x0d,
+	0x98, 0xe1, 0x05, 0x4c, 0xc4, 0xd8, 0xfc, 0xc6, 0xad, 0x92, 0x93, 0xd7, 0xe4, 0xcf, 0xe1, 0xc5,
+	0x10, 0xa9, 0xc0, 0x4e, 0x07, 0x54, 0xf5, 0xca, 0x80, 0xaa, 0x15, 0xca, 0xd5, 0x22, 0xcc, 0x9e,
+	0x99, 0xee, 0x08, 0x45, 0x14, 0xb6, 0xa9, 0x5c, 0x70, 0x6a, 0x88, 0x27, 0x38, 0x16, 0xe9, 0xd1,
+	0xa4, 0x72, 0xc1, 0x2b, 0x10, 0x0f, 0x3e, 0x17, 0xfd, 0x13, 0x76, 0x1a, 0x47, 0x5e, 0xd3, 0x73,
+	0xfc, 0x7d, 0x41, 0x10, 0x6c, 0x73, 0x9c, 0xb0, 0xe7, 0x62, 0xb6, 0x39, 0x96, 0x6c, 0x3d, 0x04,
+	0xc8, 0x54, 0x27, 0x2a, 0xb4, 0x3f, 0xdd, 0xdc, 0x3f, 0xea, 0x1b, 0xfd, 0x4f, 0x8e, 0x36, 0xf7,
+	0x07, 0x6a, 0x25, 0xa3, 0x3c, 0xa7, 0xfd, 0xc7, 0xbb, 0x2f, 0x54, 0x85, 0x74, 0xa1, 0x25, 0x29,
+	0xb4, 0xbf, 0xd3, 0x7f, 0xa1, 0x56, 0x49, 0x07, 0x9a, 0x7b, 0xfd, 0xcf, 0xe2, 0x65, 0x2d, 0xdb,
+	0xb1, 0xdf, 0x3f, 0xd8, 0x39, 0x7c, 0xa2, 0xce, 0x90, 0x79, 0x80, 0x27, 0x9b, 0x03, 0x63, 0xeb,
+	0xd9, 0xfe, 0xd1, 0xd3, 0x03, 0x75, 0x56, 0x3f, 0x85, 0x8e, 0xb4, 0x3f, 0xb9, 0xdb, 0xb7, 0xaa,
+	0x4c, 0x1b, 0x00, 0x31, 0x28, 0x42, 0xa6, 0x55, 0x27, 0x83, 0x40, 0xca, 0x1a, 0x20, 0xa3, 0x4d,
+	0x2b, 0xf9, 0xd4, 0x9f, 0x40, 0x8d, 0x06, 0xe7, 0x25, 0x45, 0xf7, 0x41, 0x9a, 0x06, 0x52, 0xd0,
+	0x8d, 0x4c, 0x50, 0x41, 0xb5, 0xb4, 0xac, 0xfe, 0x1f, 0xae, 0x65, 0xe5, 0x27, 0x4b, 0xa7, 0x69,
+	0xc9, 0xf9, 0x02, 0x9a, 0xbb, 0x52, 0xdd, 0x85, 0xc5, 0x44, 0xc0, 0x73, 0x53, 0x08, 0x19, 0x06,
+	0x7e, 0x84, 0x64, 0x15, 0x6a, 0x61, 0x70, 0xae, 0x29, 0x42, 0x8d, 0xa5, 0xf2, 0x6c, 0xa4, 0x1c,
+	0x42, 0xee, 0xc1, 0x82, 0x8f, 0x63, 0x66, 0x14, 0x52, 0x5c, 0xd6, 0xb6, 0x2e, 0x67, 0xe4, 0xf4,
+	0xd2, 0x7f, 0x50, 0xa0, 0xb3, 0xeb, 0x47, 0x18, 0xbe, 0x9f, 0x36, 0xbb, 0x0c, 0x71, 0x86, 0x1b,
+	0xbe, 0xe9, 0x61, 0x5c, 0x2b, 0xe3, 0xeb, 0x39, 0x30, 0x3d, 0x6e, 0x55, 0x56, 0x29, 0x79, 0x9d,
+	0x50, 0xa7, 0xea, 0x44, 0x12, 0xea, 0x77, 0x41, 0x8d, 0x30, 0x3c, 0xc3, 0x70, 0xa2, 0x7c, 0x36,
+	0x68, 0x57, 0xd2, 0xb3, 0xa4, 0x5c, 0x83, 0xf9, 0xc4, 0xa6, 0xd8, 0x79, 0x37, 0xa1, 0x99, 0xed,
+	0x52, 0x64, 0x6c, 0xa7, 0x04, 0xfd, 0x7b, 0x05, 0x3a, 0xdb, 0xe8, 0x22, 0xc3, 0x0f, 0x3a, 0x6b,
+	0x4c, 0x56, 0xca, 0xd9, 0xb7, 0xae, 0x94, 0x05, 0x73, 0xea, 0x93, 0xe6, 0x7c, 0xab, 0x80, 0xba,
+	0xeb, 0x5b, 0x21, 0x7a, 0xe8, 0x7f, 0xd8, 0xe9, 0x69, 0x11, 0x66, 0x6d, 0x74, 0x99, 0x19, 0x0f,
+	0x4d, 0x72, 0xf1, 0x06, 0x6d, 0xbf, 0xe3, 0x73, 0xc8, 0x29, 0x5a, 0xaf, 0x36, 0x7d, 0xbb, 0x18,
+	0x89, 0x0f, 0xa0, 0xee, 0x08, 0x82, 0xd0, 0xba, 0x90, 0x7b, 0x05, 0x20, 0x8d, 0x61, 0x5c, 0x77,
+	0x1c, 0x0f, 0xd1, 0x62, 0x86, 0xf9, 0x32, 0x42, 0x9f, 0x09, 0xcb, 0x1a, 0xb4, 0x2d, 0x89, 0x9b,
+	0x82, 0x46, 0xfe, 0x01, 0x44, 0xae, 0x31, 0x5f, 0xdc, 0x6b, 0x42, 0xad, 0x85, 0x84, 0x93, 0x35,
+	0xda, 0xbb, 0xa0, 0xa6, 0x70, 0x2b, 0xf0, 0x19, 0x17, 0x2b, 0x8b, 0x6d, 0x37, 0xa1, 0x6f, 0x49,
+	0xb2, 0xfe, 0x25, 0x2c, 0x4d, 0x1a, 0x12, 0x87, 0x9f, 0x06, 0x73, 0xe6, 0x70, 0xe8, 0x3a, 0x68,
+	0x0b, 0x53, 0x1a, 0x34, 0x59, 0x92, 0x7b, 0x30, 0x67, 0x8d, 0xc2, 0x30, 0x51, 0xb6, 0x2c, 0x01,
+	0x12, 0x40, 0xd1, 0x8f, 0xb5, 0x49, 0x3f, 0xfe, 0x17, 0x96, 0x06, 0x2c, 0x44, 0xd3, 0x93, 0x67,
+	0x0b, 0x4d, 0x86, 0x81, 0xe3, 0x33, 0xde, 0xed, 0xfd, 0x91, 0x67, 0x48, 0x27, 0xc5, 0x2a, 0xd4,
+	0x68, 0xcb, 0x1f, 0xc5, 0x50, 0xb4, 0xf5, 0x6f, 0xaa, 0xd0, 0x78, 0x3a, 0x62, 0xa6, 0x98, 0xff,
+	0x1e, 0x16, 0xba, 0x58, 0xae, 0xf1, 0x27, 0x88, 0xf4, 0xe3, 0x8f, 0x6a, 0x63, 0x77, 0xf2, 0x6d,
+	0xac, 0xcc, 0x07, 0x92, 0xfd, 0xde, 0x32, 0xe6, 0x0e, 0xb4, 0xf3, 0x26, 0x11, 0x80, 0xfa, 0xee,
+	0xc1, 0xa0, 0x4f, 0x0f, 0xd5, 0x0a, 0xff, 0xde, 0xee, 0xef, 0xf7, 0x0f, 0xfb, 0xaa, 0xc2, 0xc7,
+	0x7e, 0x1a, 0x9c, 0xa7, 0x8e, 0x9a, 0xce, 0xa9, 0x35, 0x68, 0x78, 0x31, 0x37, 0x6e, 0x18, 0x64,
+	0xda, 0x7d, 0x34, 0xc5, 0xe8, 0x03, 0x20, 0x1f, 0x9b, 0xcc, 0x3a, 0x15, 0xac, 0xb4, 0xfa, 0xa4,
+	0x99, 0xa9, 0xe4, 0x33, 0xf3, 0xef, 0xb2, 0x01, 0x48, 0xb1, 0xd7, 0x0b, 0xb3, 0x45, 0x2a, 0x99,
+	0x23, 0xf4, 0xcf, 0xa1, 0xb3, 0x85, 0xae, 0x4b, 0xf1, 0x18, 0x43, 0xf4, 0x2d, 0x2c, 0xd1, 0xf3,
+	0xf7, 0xdc, 0x96, 0x7e, 0x08, 0xdd, 0xa7, 0x23, 0x97, 0x39, 0xb9, 0xb7, 0x59, 0xb9, 0xc6, 0xf7,
+	0x61, 0xc6, 0x42, 0xd7, 0x9d, 0x6e, 0x9d, 0x05, 0xf5, 0xa8, 0x00, 0xe9, 0x5f, 0x2b, 0x30, 0x9f,
+	0x89, 0xe5, 0x43, 0x6c, 0xae, 0x39, 0x28, 0x6f, 0x68, 0x0e, 0xf7, 0x60, 0x41, 0x1c, 0xc9, 0x0c,
+	0x3f, 0x60, 0x86, 0x1b, 0x98, 0x36, 0xda, 0x71, 0xf6, 0x77, 0x25, 0xe3, 0x20, 0x60, 0xfb, 0x82,
+	0xcc, 0x75, 0xc5, 0x30, 0x0c, 0xc2, 0x64, 0xae, 0x17, 0x0b, 0xf1, 0x3e, 0xe3, 0x1f, 0x86, 0x15,
+	0xd8, 0x32, 0x0e, 0x3b, 0xb4, 0x29, 0x28, 0x5b, 0x81, 0x8d, 0xfa, 0x36, 0xa8, 0x39, 0xe5, 0x64,
+	0x56, 0xaf, 0x43, 0x5d, 0xce, 0xdf, 0x71, 0x53, 0xd6, 0xf2, 0x57, 0x9d, 0x37, 0x84, 0xc6, 0xb8,
+	0x8d, 0x9f, 0xeb, 0xd0, 0xdd, 0x36, 0x99, 0x79, 0x10, 0xd8, 0x38, 0xc0, 0xf0, 0xcc, 0xb1, 0x90,
+	0x3c, 0x80, 0xda, 0x0e, 0x32, 0xb2, 0x98, 0x6d, 0xce, 0xfc, 0xda, 0x9b, 0xb2, 0x58, 0xaf, 0x90,
+	0x0d, 0xa8, 0xcb, 0xa7, 0x22, 0xb9, 0x51, 0xdc, 0x93, 0x3e, 0x1e, 0x7b, 0x9d, 0x42, 0x74, 0xe8,
+	0x15, 0xb2, 0x05, 0x8d, 0x44, 0x25, 0xf2, 0xa7, 0x32, 0x35, 0xe5, 0xbe, 0x5e, 0xa9, 0x05, 0xc2,
+	0x5a, 0xbd, 0x42, 0x1e, 0x41, 0x23, 0x99, 0x4c, 0xf2, 0x42, 0x26, 0x5e, 0x5b, 0xbd, 0xb2, 0x59,
+	0x4b, 0xaf, 0xac, 0x2b, 0xa4, 0x0f, 0xed, 0x14, 0x1b, 0x9c, 0x47, 0x57, 0xc9, 0xb8, 0x64, 0xbe,
+	0x11, 0x62, 0xf6, 0xa0, 0x9d, 0x1f, 0x90, 0xae, 0x12, 0x73, 0x6b, 0x9a, 0x95, 0x9f, 0xa9, 0xf4,
+	0x0a, 0xf9, 0x08, 0xd4, 0x84, 0x23, 0x8e, 0x70, 0xf0, 0x4a, 0xbd, 0x26, 0x1d, 0xbb, 0xae, 0x90,
+	0xff, 0x41, 0x5d, 0x96, 0x51, 0x72, 0x59, 0x7f, 0xea, 0x69, 0xd3, 0x8c, 0x54, 0x81, 0x23, 0x98,
+	0x2f, 0x36, 0x0d, 0x92, 0x7f, 0x69, 0x95, 0xf5, 0xc5, 0xde, 0xca, 0xe5, 0x80, 0x54, 0xec, 0x7f,
+	0xa0, 0x99, 0x8e, 0x00, 0xa4, 0x97, 0x3f, 0xbf, 0x38, 0x17, 0xf4, 0xba, 0x19, 0xaf, 0xef, 0x0d,
+	0xd9, 0x85, 0x5e, 0x21, 0xcf, 0xa0, 0x9d, 0xef, 0x24, 0x97, 0xdb, 0x95, 0x53, 0xa4, 0xbc, 0xf5,
+	0xe8, 0x95, 0x55, 0x65, 0x5d, 0x21, 0xff, 0x84, 0xba, 0x1c, 0xaf, 0xf2, 0xa2, 0x0a, 0x03, 0x57,
+	0x99, 0x1a, 0x8f, 0xa0, 0x95, 0xab, 0x8d, 0xe4, 0x66, 0x86, 0x98, 0x2e, 0x99, 0x25, 0xfb, 0x5f,
+	0xd6, 0xc5, 0xbf, 0x48, 0x0f, 0x7f, 0x0d, 0x00, 0x00, 0xff, 0xff, 0x39, 0xf2, 0xe9, 0xea, 0x74,
+	0x12, 0x00, 0x00,
 }
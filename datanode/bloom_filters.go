@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var bloomFilterChecks = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_bloom_filter_checks",
+	Help:      "Number of lookups checked against an sstable bloom filter.",
+})
+var bloomFilterSkips = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_bloom_filter_skips",
+	Help:      "Number of sstable reads avoided thanks to bloom filters.",
+})
+
+func init() {
+	prometheus.MustRegister(bloomFilterChecks)
+	prometheus.MustRegister(bloomFilterSkips)
+}
+
+/*
+loadBloomFilter reads the bloom filter of the sstable at the specified path
+and registers it for lookups. Sstables without a bloom filter, e.g. because
+they were written before bloom filters were introduced, will simply always
+be read.
+*/
+func (reg *ServingRangeRegistry) loadBloomFilter(
+	ctx context.Context, path string) {
+	var filter *storage.BloomFilter
+	var err error
+
+	if len(path) == 0 {
+		return
+	}
+
+	if filter, err = storage.ReadBloomFilter(ctx, path); err != nil {
+		log.Printf("Not using a bloom filter for %s: %s", path, err)
+		return
+	}
+
+	reg.setBloomFilter(path, filter)
+}
+
+/*
+setBloomFilter registers the bloom filter of the sstable at the specified
+path.
+*/
+func (reg *ServingRangeRegistry) setBloomFilter(
+	path string, filter *storage.BloomFilter) {
+	reg.bloomFilterLock.Lock()
+	defer reg.bloomFilterLock.Unlock()
+
+	reg.bloomFilters[path] = filter
+}
+
+/*
+dropBloomFilter forgets about the bloom filter of the sstable at the
+specified path, e.g. because the sstable is no longer served.
+*/
+func (reg *ServingRangeRegistry) dropBloomFilter(path string) {
+	reg.bloomFilterLock.Lock()
+	defer reg.bloomFilterLock.Unlock()
+
+	delete(reg.bloomFilters, path)
+}
+
+/*
+removeBloomFilter forgets about the bloom filter of the sstable at the
+specified path and removes its file, once the sstable has been replaced.
+*/
+func (reg *ServingRangeRegistry) removeBloomFilter(
+	ctx context.Context, path string) {
+	var u *url.URL
+	var err error
+
+	if len(path) == 0 {
+		return
+	}
+
+	reg.dropBloomFilter(path)
+
+	if u, err = url.Parse(path + ".bloom"); err != nil {
+		log.Printf("Error parsing bloom filter path %s: %s", path, err)
+		return
+	}
+	if err = filesystem.Remove(ctx, u); err != nil {
+		log.Printf("Error removing %s: %s", u.String(), err)
+	}
+}
+
+/*
+MayContainKey determines whether the sstable at the specified path may hold
+data for the key. If no bloom filter is known for the sstable, it may hold
+data for any key.
+*/
+func (reg *ServingRangeRegistry) MayContainKey(path string, key []byte) bool {
+	var filter *storage.BloomFilter
+	var ok bool
+
+	reg.bloomFilterLock.RLock()
+	filter, ok = reg.bloomFilters[path]
+	reg.bloomFilterLock.RUnlock()
+
+	if !ok {
+		return true
+	}
+
+	bloomFilterChecks.Inc()
+	if !filter.MayContain(key) {
+		bloomFilterSkips.Inc()
+		return false
+	}
+
+	return true
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/childoftheuniverse/recordio"
 	"github.com/childoftheuniverse/red-cloud"
 	"github.com/childoftheuniverse/red-cloud/common"
 	"github.com/childoftheuniverse/red-cloud/storage"
@@ -52,17 +57,43 @@ func NewDataNodeService(rangeRegistry *ServingRangeRegistry) *DataNodeService {
 }
 
 /*
-Get fetches an individual data cell from the involved sstables.
+lookupError combines the errors encountered while reading data into the
+error reported to the client. If any of the data read was corrupted, this
+is reported as data loss.
 */
-func (dns *DataNodeService) Get(
-	parentCtx context.Context, req *redcloud.GetRequest) (
-	*redcloud.Column, error) {
-	var ctx context.Context
-	var span *trace.Span
+func lookupError(allErrors []string) error {
+	var code = codes.Internal
+	var e string
+
+	for _, e = range allErrors {
+		if e == common.ErrChecksumMismatch.Error() {
+			code = codes.DataLoss
+		}
+	}
+
+	return grpc.Errorf(code, "%s", strings.Join(allErrors, "; "))
+}
+
+/*
+lookupLatest finds the latest version of the specified column of a row in
+all sstables and journals holding the row. Deleted and expired data is not
+taken into account. If "readTimestamp" is set, the latest version as of that
+time is returned instead. Returns the latest version, or nil if there is
+none, along with the number of files consulted and the errors encountered
+while reading them. The caller is expected to hold a read lock on the range
+registry.
+*/
+func (dns *DataNodeService) lookupLatest(
+	ctx context.Context, table, columnFamily, column string, key []byte,
+	readTimestamp int64) (*redcloud.Column, int, []string, error) {
+	var now = time.Now().UnixNano() / 1000000
 	var results = make(chan *redcloud.ColumnFamily)
 	var errors = make(chan error)
 	var doners = make(chan struct{})
+	var kr = common.NewKeyRange(key, key)
+	var columns = storage.NewColumnSelector([]string{column})
 	var allErrors []string
+	var row redcloud.ColumnFamily
 	var result *redcloud.Column
 	var sstPaths []*redcloud.SSTablePathDescription
 	var sstPath *redcloud.SSTablePathDescription
@@ -71,84 +102,59 @@ func (dns *DataNodeService) Get(
 	var numDone int
 	var err error
 
-	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Get")
-	defer span.End()
-
-	span.AddAttributes(
-		trace.StringAttribute("table", req.Table),
-		trace.StringAttribute("column-family", req.ColumnFamily))
-
-	numRequests.With(prometheus.Labels{
-		"service": "DataNodeService",
-		"method":  "Get",
-	}).Inc()
-
-	dns.rangeRegistry.Lock()
-	defer dns.rangeRegistry.Unlock()
-
 	/*
 		Try to figure out where the sstable files for the given path are
 		stored.
 	*/
-	sstPaths, err = dns.rangeRegistry.GetSSTablePathDescription(
-		ctx, req.Table, req.ColumnFamily, common.NewKeyRange(req.Key, req.Key))
-	if err != nil {
-		numErrors.With(prometheus.Labels{
-			"service":     "DataNodeService",
-			"method":      "Get",
-			"error_class": "get_sstable_path_description",
-		}).Inc()
-		span.AddAttributes(trace.StringAttribute("error", err.Error()))
-		span.Annotate(nil, "Error fetching sstable path")
-		return nil, err
+	if sstPaths, err = dns.rangeRegistry.GetSSTablePathDescription(
+		ctx, table, columnFamily, kr); err != nil {
+		return nil, 0, nil, err
 	}
 
+	/*
+		Sstables whose bloom filter shows that they hold no data for the key
+		don't need to be opened at all.
+	*/
 	for _, sstPath = range sstPaths {
-		if len(sstPath.MajorSstablePath) > 0 {
+		if len(sstPath.MajorSstablePath) > 0 &&
+			dns.rangeRegistry.MayContainKey(sstPath.MajorSstablePath, key) {
 			numRequired++
 			go storage.LookupInSstable(ctx, sstPath.MajorSstablePath,
-				[]string{req.Column}, common.NewKeyRange(req.Key, req.Key),
-				results, errors, doners)
+				columns, nil, kr, results, errors, doners)
 		}
 
-		if len(sstPath.MinorSstablePath) > 0 {
+		if len(sstPath.MinorSstablePath) > 0 &&
+			dns.rangeRegistry.MayContainKey(sstPath.MinorSstablePath, key) {
 			numRequired++
 			go storage.LookupInSstable(ctx, sstPath.MinorSstablePath,
-				[]string{req.Column}, common.NewKeyRange(req.Key, req.Key),
-				results, errors, doners)
+				columns, nil, kr, results, errors, doners)
 		}
 
+		// Journals held in memtables don't need to be read.
 		numRequired += len(sstPath.RelevantJournalPaths)
 		for _, path = range sstPath.RelevantJournalPaths {
-			go storage.LookupInJournal(ctx, path, []string{req.Column},
-				common.NewKeyRange(req.Key, req.Key), results, errors, doners)
+			var memtable *storage.Memtable
+
+			if memtable = dns.rangeRegistry.GetMemtable(path); memtable != nil {
+				go memtable.Lookup(ctx, columns, nil, kr, results, errors,
+					doners)
+			} else {
+				go storage.LookupInJournal(ctx, path, columns, nil, kr,
+					results, errors, doners)
+			}
 		}
 	}
 
-	span.AddAttributes(
-		trace.Int64Attribute("files-touched", int64(numRequired)))
-
 	if numRequired == 0 {
-		numErrors.With(prometheus.Labels{
-			"service":     "DataNodeService",
-			"method":      "Get",
-			"error_class": "no_data_sources",
-		}).Inc()
-		span.Annotate(nil, "No data sources found")
-		// No sources means no data.
-		return nil, grpc.Errorf(codes.NotFound,
-			"No data sources in column family %s for key: empty",
-			req.ColumnFamily)
+		return nil, 0, nil, nil
 	}
 
 	/*
-		Collect matching data from all sources (as above) and determine the
-		latest result. The result will be sent back to the client.
+		Collect matching data from all sources (as above) and merge it, so
+		deleted data can be told apart from the latest result.
 	*/
 	for {
 		var cf *redcloud.ColumnFamily
-		var cs *redcloud.ColumnSet
-		var col *redcloud.Column
 		var found bool
 		var err error
 
@@ -162,15 +168,7 @@ func (dns *DataNodeService) Get(
 				break
 			}
 		case cf = <-results:
-			for _, cs = range cf.ColumnSet {
-				for _, col = range cs.Column {
-					if result == nil {
-						result = col
-					} else if result.Timestamp < col.Timestamp {
-						result = col
-					}
-				}
-			}
+			storage.MergeColumnFamilies(&row, cf)
 		}
 
 		if found {
@@ -178,52 +176,38 @@ func (dns *DataNodeService) Get(
 		}
 	}
 
-	if len(allErrors) > 0 {
-		numErrors.With(prometheus.Labels{
-			"service":     "DataNodeService",
-			"method":      "Get",
-			"error_class": "lookup_errors",
-		}).Inc()
-		span.AddAttributes(
-			trace.Int64Attribute("num-errors", int64(len(allErrors))))
-		span.Annotate(nil, "Read errors encountered")
-		return result, grpc.Errorf(codes.Internal,
-			strings.Join(allErrors, "; "))
+	// Snapshot reads see the row as it was at the read timestamp.
+	if readTimestamp > 0 {
+		now = readTimestamp
+		storage.DropVersionsAfter(&row, readTimestamp)
 	}
 
-	if result == nil {
-		span.Annotate(nil, "Result empty")
-		return nil, grpc.Errorf(codes.NotFound,
-			"No column matched requirements")
+	/*
+		Versions are ordered newest first, so the first one is the latest.
+		Counters are returned as the sum of all their deltas.
+	*/
+	if storage.ExpireColumns(&row, now) && storage.PurgeTombstones(&row) {
+		storage.CollapseCounters(&row, true)
+		result = row.ColumnSet[0].Column[0]
 	}
 
-	return result, nil
+	return result, numRequired, allErrors, nil
 }
 
 /*
-GetRange fetches all data cells from the involved sstables which are
-matching the specified criteria.
+Get fetches an individual data cell from the involved sstables.
 */
-func (dns *DataNodeService) GetRange(
-	req *redcloud.GetRangeRequest, resp redcloud.DataNodeService_GetRangeServer) error {
-	var parentCtx context.Context
+func (dns *DataNodeService) Get(
+	parentCtx context.Context, req *redcloud.GetRequest) (
+	*redcloud.Column, error) {
 	var ctx context.Context
 	var span *trace.Span
-	var cancel context.CancelFunc
-	var results = make(chan *redcloud.ColumnFamily)
-	var errors = make(chan error)
-	var doners = make(chan struct{})
 	var allErrors []string
-	var sstPaths []*redcloud.SSTablePathDescription
-	var sstPath *redcloud.SSTablePathDescription
-	var path string
+	var result *redcloud.Column
 	var numRequired int
-	var numDone int
-	var numFound int64
 	var err error
 
-	parentCtx, cancel = context.WithCancel(resp.Context())
-	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/GetRange")
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Get")
 	defer span.End()
 
 	span.AddAttributes(
@@ -232,50 +216,22 @@ func (dns *DataNodeService) GetRange(
 
 	numRequests.With(prometheus.Labels{
 		"service": "DataNodeService",
-		"method":  "GetRange",
+		"method":  "Get",
 	}).Inc()
 
 	dns.rangeRegistry.Lock()
 	defer dns.rangeRegistry.Unlock()
 
-	/*
-		Try to figure out where the sstable files for the given path are
-		stored.
-	*/
-	if sstPaths, err = dns.rangeRegistry.GetSSTablePathDescription(
-		ctx, req.Table, req.ColumnFamily,
-		common.NewKeyRange(req.StartKey, req.EndKey)); err != nil {
+	if result, numRequired, allErrors, err = dns.lookupLatest(ctx, req.Table,
+		req.ColumnFamily, req.Column, req.Key, req.ReadTimestamp); err != nil {
 		numErrors.With(prometheus.Labels{
 			"service":     "DataNodeService",
-			"method":      "GetRange",
+			"method":      "Get",
 			"error_class": "get_sstable_path_description",
 		}).Inc()
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Error fetching sstable path")
-		return err
-	}
-
-	for _, sstPath = range sstPaths {
-		if len(sstPath.MajorSstablePath) > 0 {
-			numRequired++
-			go storage.LookupInSstable(ctx, sstPath.MajorSstablePath,
-				req.Column, common.NewKeyRange(req.StartKey, req.EndKey),
-				results, errors, doners)
-		}
-
-		if len(sstPath.MinorSstablePath) > 0 {
-			numRequired++
-			go storage.LookupInSstable(ctx, sstPath.MinorSstablePath,
-				req.Column, common.NewKeyRange(req.StartKey, req.EndKey),
-				results, errors, doners)
-		}
-
-		numRequired += len(sstPath.RelevantJournalPaths)
-		for _, path = range sstPath.RelevantJournalPaths {
-			go storage.LookupInJournal(ctx, path,
-				req.Column, common.NewKeyRange(req.StartKey, req.EndKey),
-				results, errors, doners)
-		}
+		return nil, err
 	}
 
 	span.AddAttributes(
@@ -284,121 +240,1497 @@ func (dns *DataNodeService) GetRange(
 	if numRequired == 0 {
 		numErrors.With(prometheus.Labels{
 			"service":     "DataNodeService",
-			"method":      "GetRange",
+			"method":      "Get",
 			"error_class": "no_data_sources",
 		}).Inc()
 		span.Annotate(nil, "No data sources found")
 		// No sources means no data.
-		return grpc.Errorf(codes.NotFound,
+		return nil, grpc.Errorf(codes.NotFound,
 			"No data sources in column family %s for key: empty",
 			req.ColumnFamily)
 	}
 
-	/*
-		Determine all matching columns from all selected sources (see above)
-		and stream them back to the client.
-	*/
-	for {
+	if len(allErrors) > 0 {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Get",
+			"error_class": "lookup_errors",
+		}).Inc()
+		span.AddAttributes(
+			trace.Int64Attribute("num-errors", int64(len(allErrors))))
+		span.Annotate(nil, "Read errors encountered")
+		return result, lookupError(allErrors)
+	}
+
+	if result == nil {
+		span.Annotate(nil, "Result empty")
+		return nil, grpc.Errorf(codes.NotFound,
+			"No column matched requirements")
+	}
+
+	return result, nil
+}
+
+/*
+GetRow fetches the latest versions of the selected columns from several
+column families of a single row.
+*/
+func (dns *DataNodeService) GetRow(
+	ctx context.Context, req *redcloud.GetRowRequest) (*redcloud.Row, error) {
+	var rangeReq = &redcloud.GetRangeRequest{
+		StartKey: req.Key,
+		EndKey:   req.Key,
+		Table:    req.Table,
+		Family:   req.Family,
+	}
+	var result *redcloud.Row
+	var fc *redcloud.FamilyColumns
+	var cs *redcloud.ColumnSet
+	var err error
+
+	if _, err = dns.getRange(ctx, "GetRow", rangeReq, true, false,
+		func(row *redcloud.Row) error {
+			result = row
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, grpc.Errorf(codes.NotFound,
+			"No column matched requirements")
+	}
+
+	// Versions are ordered newest first, so the first one is the latest.
+	for _, fc = range result.Family {
+		for _, cs = range fc.ColumnSet {
+			cs.Column = cs.Column[:1]
+		}
+	}
+
+	return result, nil
+}
+
+/*
+multiGetGroup collects the cells of a MultiGet request which are stored in
+the same tablet of a column family, so the files of the tablet only need to
+be read once for all of them.
+*/
+type multiGetGroup struct {
+	desc *redcloud.SSTablePathDescription
+
+	// Keys and columns of all cells of the group.
+	keys    [][]byte
+	columns []string
+
+	// Positions of the cells of the group in the request.
+	cells []int
+}
+
+/*
+lookupCells finds the latest versions of all cells of the group and stores
+them in the corresponding results. Errors reading any of the files of the
+tablet are reported for all cells of the group. The caller is expected to
+hold a read lock on the range registry.
+*/
+func (dns *DataNodeService) lookupCells(ctx context.Context,
+	group *multiGetGroup, req *redcloud.MultiGetRequest,
+	results []*redcloud.MultiGetResult) {
+	var rowData = make(chan *redcloud.ColumnFamily)
+	var errors = make(chan error)
+	var doners = make(chan struct{})
+	var rows = make(map[string]*redcloud.ColumnFamily)
+	var columns = storage.NewColumnSelector(group.columns)
+	var now = time.Now().UnixNano() / 1000000
+	var allErrors []string
+	var row *redcloud.ColumnFamily
+	var path string
+	var numRequired int
+	var numDone int
+	var i int
+
+	for _, path = range []string{
+		group.desc.MajorSstablePath, group.desc.MinorSstablePath} {
+		var keys [][]byte
+		var key []byte
+
+		if len(path) == 0 {
+			continue
+		}
+
+		// Only look for keys the bloom filter of the sstable doesn't rule out.
+		for _, key = range group.keys {
+			if dns.rangeRegistry.MayContainKey(path, key) {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) > 0 {
+			numRequired++
+			go storage.LookupKeysInSstable(ctx, path, columns, keys,
+				rowData, errors, doners)
+		}
+	}
+
+	// Journals held in memtables don't need to be read.
+	numRequired += len(group.desc.RelevantJournalPaths)
+	for _, path = range group.desc.RelevantJournalPaths {
+		var memtable *storage.Memtable
+
+		if memtable = dns.rangeRegistry.GetMemtable(path); memtable != nil {
+			go memtable.LookupKeys(ctx, columns, group.keys, rowData, errors,
+				doners)
+		} else {
+			go storage.LookupKeysInJournal(ctx, path, columns, group.keys,
+				rowData, errors, doners)
+		}
+	}
+
+	for numDone < numRequired {
 		var cf *redcloud.ColumnFamily
-		var cs *redcloud.ColumnSet
-		var col *redcloud.Column
-		var complete bool
 		var err error
+		var ok bool
 
 		select {
 		case err = <-errors:
 			allErrors = append(allErrors, err.Error())
 		case <-doners:
 			numDone++
-			if numDone == numRequired {
-				complete = true
-				break
-			}
-		case cf = <-results:
-			for _, cs = range cf.ColumnSet {
-				var rcs = new(redcloud.ColumnSet)
-				rcs.Name = cs.Name
-				for _, col = range cs.Column {
-					if (req.MinTimestamp == 0 || col.Timestamp >= req.MinTimestamp) &&
-						(req.MaxTimestamp == 0 || col.Timestamp <= req.MaxTimestamp) {
-						rcs.Column = append(rcs.Column, col)
-						numFound++
-					}
-				}
-
-				if len(rcs.Column) > 0 {
-					resp.Send(rcs)
-				}
-			}
-
-			if req.MaxResults > 0 && numFound >= req.MaxResults {
-				complete = true
-				cancel()
+		case cf = <-rowData:
+			if row, ok = rows[string(cf.Key)]; !ok {
+				row = &redcloud.ColumnFamily{Key: cf.Key}
+				rows[string(cf.Key)] = row
 			}
+			storage.MergeColumnFamilies(row, cf)
 		}
+	}
 
-		if complete {
-			break
+	/*
+		Remove deleted and expired data once per row. Counters are returned as
+		the sum of all their deltas.
+	*/
+	for _, row = range rows {
+		if storage.ExpireColumns(row, now) && storage.PurgeTombstones(row) {
+			storage.CollapseCounters(row, true)
 		}
 	}
 
 	if len(allErrors) > 0 {
 		numErrors.With(prometheus.Labels{
 			"service":     "DataNodeService",
-			"method":      "GetRange",
+			"method":      "MultiGet",
 			"error_class": "lookup_errors",
 		}).Inc()
-		span.AddAttributes(
-			trace.Int64Attribute("num-errors", int64(len(allErrors))))
-		span.Annotate(nil, "Read errors encountered")
-		return grpc.Errorf(codes.Internal,
-			strings.Join(allErrors, "; "))
 	}
 
-	return nil
+	for _, i = range group.cells {
+		var cs *redcloud.ColumnSet
+
+		if len(allErrors) > 0 {
+			var err = lookupError(allErrors)
+
+			results[i].Error = grpc.ErrorDesc(err)
+			results[i].ErrorCode = uint32(grpc.Code(err))
+		}
+
+		if row = rows[string(req.Cell[i].Key)]; row == nil {
+			continue
+		}
+
+		// Versions are ordered newest first, so the first one is the latest.
+		for _, cs = range row.ColumnSet {
+			if cs.Name == req.Cell[i].Column && len(cs.Column) > 0 {
+				results[i].Column = cs.Column[0]
+				break
+			}
+		}
+	}
 }
 
 /*
-Insert is used to set a very specific data cell to the specified value.
+MultiGet fetches the latest versions of a number of individual data cells.
+Cells are grouped by the tablet holding them, so every file is read only
+once per request.
 */
-func (dns *DataNodeService) Insert(
-	parentCtx context.Context, req *redcloud.InsertRequest) (
-	*redcloud.Empty, error) {
+func (dns *DataNodeService) MultiGet(
+	parentCtx context.Context, req *redcloud.MultiGetRequest) (
+	*redcloud.MultiGetResponse, error) {
 	var ctx context.Context
 	var span *trace.Span
-	var cf redcloud.ColumnFamily
-	var cs *redcloud.ColumnSet
-	var writer *recordio.RecordWriter
-	var err error
+	var resp = &redcloud.MultiGetResponse{
+		Result: make([]*redcloud.MultiGetResult, len(req.Cell)),
+	}
+	var groups = make(
+		map[*redcloud.SSTablePathDescription]*multiGetGroup)
+	var group *multiGetGroup
+	var cell *redcloud.CellReference
+	var wg sync.WaitGroup
+	var i int
 
-	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Insert")
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/MultiGet")
 	defer span.End()
 
 	span.AddAttributes(
 		trace.StringAttribute("table", req.Table),
-		trace.StringAttribute("column-family", req.ColumnFamily))
+		trace.Int64Attribute("num-cells", int64(len(req.Cell))))
 
 	numRequests.With(prometheus.Labels{
 		"service": "DataNodeService",
-		"method":  "Insert",
+		"method":  "MultiGet",
 	}).Inc()
 
-	cs = new(redcloud.ColumnSet)
-	cs.Name = req.ColumnName
-	cs.Column = append(cs.Column, req.Column)
-
-	cf.Key = req.Key
-	cf.ColumnSet = append(cf.ColumnSet, cs)
-
-	// Get the journal writer for the corresponding data set.
 	dns.rangeRegistry.Lock()
 	defer dns.rangeRegistry.Unlock()
 
-	if writer, err = dns.rangeRegistry.GetJournalWriter(
-		ctx, req.Table, req.ColumnFamily, req.Key); err != nil {
-		numErrors.With(prometheus.Labels{
-			"service":     "DataNodeService",
-			"method":      "Insert",
+	for i, cell = range req.Cell {
+		var sstPaths []*redcloud.SSTablePathDescription
+		var kr *common.KeyRange
+		var ok bool
+		var err error
+
+		resp.Result[i] = new(redcloud.MultiGetResult)
+
+		kr = common.NewKeyRange(cell.Key, cell.Key)
+		if sstPaths, err = dns.rangeRegistry.GetSSTablePathDescription(
+			ctx, req.Table, cell.ColumnFamily, kr); err ==
+			common.ErrTabletNotLoaded {
+			// The client has to ask the data node serving the tablet.
+			resp.Result[i].TabletNotLoaded = true
+			continue
+		} else if err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "MultiGet",
+				"error_class": "get_sstable_path_description",
+			}).Inc()
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error fetching sstable path")
+			resp.Result[i].Error = grpc.ErrorDesc(err)
+			resp.Result[i].ErrorCode = uint32(grpc.Code(err))
+			continue
+		}
+
+		if len(sstPaths) == 0 {
+			continue
+		}
+
+		if group, ok = groups[sstPaths[0]]; !ok {
+			group = &multiGetGroup{desc: sstPaths[0]}
+			groups[sstPaths[0]] = group
+		}
+
+		group.keys = append(group.keys, cell.Key)
+		group.columns = append(group.columns, cell.Column)
+		group.cells = append(group.cells, i)
+	}
+
+	span.AddAttributes(trace.Int64Attribute("num-tablets", int64(len(groups))))
+
+	for _, group = range groups {
+		wg.Add(1)
+		go func(group *multiGetGroup) {
+			defer wg.Done()
+			dns.lookupCells(ctx, group, req, resp.Result)
+		}(group)
+	}
+
+	wg.Wait()
+
+	return resp, nil
+}
+
+/*
+GetRange fetches all data cells from the involved sstables which are
+matching the specified criteria.
+*/
+func (dns *DataNodeService) GetRange(
+	req *redcloud.GetRangeRequest, resp redcloud.DataNodeService_GetRangeServer) error {
+	var err error
+
+	_, err = dns.getRange(resp.Context(), "GetRange", req, false, false,
+		func(row *redcloud.Row) error {
+			var cs *redcloud.ColumnSet
+			var err error
+
+			for _, cs = range row.Family[0].ColumnSet {
+				if err = resp.Send(cs); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	return err
+}
+
+/*
+GetRangeRows fetches all data cells from the involved sstables which are
+matching the specified criteria, like GetRange, but returns them grouped
+by row along with the row key.
+*/
+func (dns *DataNodeService) GetRangeRows(
+	req *redcloud.GetRangeRequest,
+	resp redcloud.DataNodeService_GetRangeRowsServer) error {
+	var err error
+
+	_, err = dns.getRange(resp.Context(), "GetRangeRows", req, false, false,
+		func(row *redcloud.Row) error {
+			return resp.Send(&redcloud.ColumnFamily{
+				Key:       row.Key,
+				ColumnSet: row.Family[0].ColumnSet,
+			})
+		})
+	return err
+}
+
+/*
+GetRangePage fetches a single page of at most page_size cells of the data
+matching the specified criteria, along with a token for fetching the next
+page.
+*/
+func (dns *DataNodeService) GetRangePage(
+	ctx context.Context, req *redcloud.GetRangeRequest) (
+	*redcloud.GetRangePageResponse, error) {
+	var resp = new(redcloud.GetRangePageResponse)
+	var err error
+
+	if resp.NextResumeToken, err = dns.getRange(
+		ctx, "GetRangePage", req, false, true,
+		func(row *redcloud.Row) error {
+			resp.Row = append(resp.Row, &redcloud.ColumnFamily{
+				Key:       row.Key,
+				ColumnSet: row.Family[0].ColumnSet,
+			})
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+/*
+GetRangeFamilies fetches all data cells matching the specified criteria from
+several column families, and returns them merged per row.
+*/
+func (dns *DataNodeService) GetRangeFamilies(
+	req *redcloud.GetRangeRequest,
+	resp redcloud.DataNodeService_GetRangeFamiliesServer) error {
+	var err error
+
+	_, err = dns.getRange(resp.Context(), "GetRangeFamilies", req, true,
+		false, resp.Send)
+	return err
+}
+
+/*
+columnSelector creates a ColumnSelector for all columns selected from the
+column family, as well as all columns of the family needed to evaluate the
+filter.
+*/
+func columnSelector(family *redcloud.ColumnFamilySelection,
+	filter *storage.RowFilter) *storage.ColumnSelector {
+	var ranges = []*redcloud.ColumnRange{family.ColumnRange}
+	var columns = append(
+		filter.Columns(family.ColumnFamily), family.Column...)
+
+	if len(family.ColumnPrefix) > 0 {
+		ranges = append(ranges, storage.PrefixRange(family.ColumnPrefix))
+	}
+	if family.AllColumns {
+		ranges = append(ranges, storage.AllColumns())
+	}
+
+	return storage.NewColumnSelector(columns, ranges...)
+}
+
+/*
+lookupFamily starts lookups of the rows in the key range "kr" in all files of
+the column family selected by "family", for the tablet covering
+"tabletRange". Rows not matching the key conditions of the filter are
+skipped, and the columns needed to evaluate the filter are read in addition
+to the selected ones. The results are merged by key, and the remaining data
+of each row after applying deletions, expiry and the version limits is
+reported through the returned RowSource, in reverse key order if "reverse"
+is set. Reverse lookups only keep as many rows of each file as "window"
+allows. If "readTimestamp" is set, all versions written after it are
+ignored. Also returns the number of files touched.
+*/
+func (dns *DataNodeService) lookupFamily(
+	ctx context.Context, table string, tabletRange, kr *common.KeyRange,
+	family *redcloud.ColumnFamilySelection, filter *storage.RowFilter,
+	reverse bool, window *storage.ReverseWindow,
+	now, readTimestamp int64) (
+	*storage.RowSource, int, error) {
+	var sources []*storage.RowSource
+	var source *storage.RowSource
+	var merged *storage.RowSource
+	var reversed *storage.RowSource
+	var i int
+	var sstPaths []*redcloud.SSTablePathDescription
+	var sstPath *redcloud.SSTablePathDescription
+	var path string
+	var columns = columnSelector(family, filter)
+	var maxVersions, maxVersionAge int64
+	var err error
+
+	/*
+		Try to figure out where the sstable files for the given path are
+		stored.
+	*/
+	if sstPaths, err = dns.rangeRegistry.GetSSTablePathDescription(
+		ctx, table, family.ColumnFamily, tabletRange); err != nil {
+		return nil, 0, err
+	}
+
+	/*
+		Each source gets its own set of channels so its rows can be merged
+		with those of the other sources in key order. Journal files aren't
+		sorted, so they have to be read completely before they can be merged.
+	*/
+	for _, sstPath = range sstPaths {
+		if len(sstPath.MajorSstablePath) > 0 {
+			source = storage.NewRowSource()
+			sources = append(sources, source)
+			go storage.LookupInSstable(ctx, sstPath.MajorSstablePath,
+				columns, filter, kr, source.Results, source.Errors,
+				source.Done)
+		}
+
+		if len(sstPath.MinorSstablePath) > 0 {
+			source = storage.NewRowSource()
+			sources = append(sources, source)
+			go storage.LookupInSstable(ctx, sstPath.MinorSstablePath,
+				columns, filter, kr, source.Results, source.Errors,
+				source.Done)
+		}
+
+		for _, path = range sstPath.RelevantJournalPaths {
+			var memtable *storage.Memtable
+
+			source = storage.NewRowSource()
+			sources = append(sources, source)
+
+			// Memtables are already sorted, unlike journal files.
+			if memtable = dns.rangeRegistry.GetMemtable(path); memtable != nil {
+				go memtable.Lookup(ctx, columns, filter, kr, source.Results,
+					source.Errors, source.Done)
+			} else {
+				go storage.LookupInJournalSorted(ctx, path,
+					columns, filter, kr, source.Results, source.Errors,
+					source.Done)
+			}
+		}
+	}
+
+	/*
+		Files can only be read front to back, so for reverse scans all rows
+		have to be read before they can be reported in reverse order. Only
+		the rows within the window are kept while reading.
+	*/
+	if reverse {
+		for i = range sources {
+			reversed = storage.NewRowSource()
+			go storage.ReverseRows(ctx, sources[i], reversed, window)
+			sources[i] = reversed
+		}
+	}
+
+	maxVersions, maxVersionAge = dns.rangeRegistry.versionLimits(table)
+
+	merged = storage.NewRowSource()
+	go func() {
+		var allErrors []string
+		var e string
+
+		allErrors = storage.MergeRows(sources, reverse,
+			func(row *redcloud.ColumnFamily) bool {
+				if readTimestamp > 0 &&
+					!storage.DropVersionsAfter(row, readTimestamp) {
+					return true
+				}
+				if !storage.ExpireColumns(row, now) ||
+					!storage.PurgeTombstones(row) {
+					return true
+				}
+
+				// Counters are returned as the sum of all their deltas.
+				storage.CollapseCounters(row, true)
+				if storage.LimitVersions(
+					row, maxVersions, maxVersionAge, now) {
+					merged.Results <- row
+				}
+				return true
+			})
+
+		for _, e = range allErrors {
+			merged.Errors <- errors.New(e)
+		}
+		merged.Done <- struct{}{}
+	}()
+
+	return merged, len(sources), nil
+}
+
+/*
+minReverseScanWindow and maxReverseScanWindow bound the number of rows of
+each file a reverse scan keeps in memory per pass.
+*/
+const (
+	minReverseScanWindow = 256
+	maxReverseScanWindow = 65536
+)
+
+/*
+reverseScanWindow determines how many rows of each file a reverse scan
+keeps in memory per pass. Every row returned contains at least one cell, so
+there's no need to keep more rows than cells requested. Scans running into
+many deleted rows shouldn't need too many passes either, though.
+*/
+func reverseScanWindow(req *redcloud.GetRangeRequest) int {
+	var limit = req.MaxResults
+
+	if req.PageSize > 0 && (limit == 0 || req.PageSize < limit) {
+		limit = req.PageSize
+	}
+
+	if limit == 0 || limit > maxReverseScanWindow {
+		return maxReverseScanWindow
+	}
+	if limit < minReverseScanWindow {
+		return minReverseScanWindow
+	}
+	return int(limit)
+}
+
+/*
+getRange looks up all rows matching the GetRangeRequest and passes them to
+"send" one by one, in key order. Only the matching versions of each row are
+passed on. "method" is the name of the RPC the request was received
+through, for monitoring. Unless "multiFamily" is set, requests for more than
+one column family are rejected. "paged" is set for scans returning a single
+page at a time.
+
+If the scan stopped because the page size was reached, a token for resuming
+the scan is returned.
+*/
+func (dns *DataNodeService) getRange(
+	rpcCtx context.Context, method string, req *redcloud.GetRangeRequest,
+	multiFamily, paged bool, send func(*redcloud.Row) error) ([]byte, error) {
+	var parentCtx context.Context
+	var ctx context.Context
+	var span *trace.Span
+	var cancel context.CancelFunc
+	var families = req.Family
+	var family *redcloud.ColumnFamilySelection
+	var selectors []*storage.ColumnSelector
+	var filter *storage.RowFilter
+	var sources []*storage.RowSource
+	var window *storage.ReverseWindow
+	var emit func([]byte, []*redcloud.ColumnFamily) bool
+	var source *storage.RowSource
+	var allErrors []string
+	var kr = common.NewKeyRange(req.StartKey, req.EndKey)
+	var resumeFrom *redcloud.GetRangeResumeToken
+	var nextToken *redcloud.GetRangeResumeToken
+	var now = time.Now().UnixNano() / 1000000
+	var numFound int64
+	var numFiles int
+	var sendErr error
+	var err error
+
+	parentCtx, cancel = context.WithCancel(rpcCtx)
+	defer cancel()
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/"+method)
+	defer span.End()
+
+	// Snapshot reads see the data as it was at the read timestamp.
+	if req.ReadTimestamp > 0 {
+		now = req.ReadTimestamp
+	}
+
+	if len(families) == 0 {
+		families = []*redcloud.ColumnFamilySelection{{
+			ColumnFamily: req.ColumnFamily,
+			Column:       req.Column,
+			ColumnRange:  req.ColumnRange,
+			ColumnPrefix: req.ColumnPrefix,
+			AllColumns:   req.AllColumns,
+		}}
+	}
+
+	span.AddAttributes(
+		trace.StringAttribute("table", req.Table),
+		trace.StringAttribute("column-family", families[0].ColumnFamily),
+		trace.Int64Attribute("num-column-families", int64(len(families))))
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  method,
+	}).Inc()
+
+	if len(families) > 1 && !multiFamily {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      method,
+			"error_class": "multiple_column_families",
+		}).Inc()
+		span.Annotate(nil, "Multiple column families requested")
+		return nil, grpc.Errorf(codes.InvalidArgument,
+			"%s supports only a single column family, use GetRangeFamilies",
+			method)
+	}
+
+	if filter, err = storage.NewRowFilter(
+		req.Filter, families[0].ColumnFamily); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      method,
+			"error_class": "invalid_filter",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Invalid filter")
+		return nil, grpc.Errorf(codes.InvalidArgument,
+			"Invalid filter: %s", err)
+	}
+
+	if len(req.ResumeToken) > 0 {
+		resumeFrom = new(redcloud.GetRangeResumeToken)
+		if err = proto.Unmarshal(req.ResumeToken, resumeFrom); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      method,
+				"error_class": "invalid_resume_token",
+			}).Inc()
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Invalid resume token")
+			return nil, grpc.Errorf(codes.InvalidArgument,
+				"Invalid resume token: %s", err)
+		}
+
+		/*
+			There's no need to look at any rows before the one the scan
+			stopped at, or after it in reverse scans. If the scan had already
+			moved past the end of this range, there is nothing left to
+			return.
+		*/
+		if req.Reverse && (len(req.EndKey) == 0 ||
+			bytes.Compare(resumeFrom.Key, req.EndKey) < 0) {
+			if bytes.Compare(resumeFrom.Key, req.StartKey) < 0 {
+				span.Annotate(nil, "Resume token past the start of the range")
+				return nil, nil
+			}
+			// Make sure the row the scan stopped at is still included.
+			kr = common.NewKeyRange(req.StartKey,
+				append(append([]byte{}, resumeFrom.Key...), 0))
+		} else if !req.Reverse &&
+			bytes.Compare(resumeFrom.Key, req.StartKey) > 0 {
+			if len(req.EndKey) > 0 &&
+				bytes.Compare(resumeFrom.Key, req.EndKey) >= 0 {
+				span.Annotate(nil, "Resume token past the end of the range")
+				return nil, nil
+			}
+			kr = common.NewKeyRange(resumeFrom.Key, req.EndKey)
+		}
+	}
+
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	/*
+		Rows from all column families are merged by key and streamed back to
+		the client as soon as all their versions are known. Since the rows
+		arrive in key order, the lookups can be stopped as soon as enough
+		results have been sent. In reverse scans, rows below the floor of
+		the window may be missing data, so they are left to the next pass.
+	*/
+	emit = func(key []byte, rows []*redcloud.ColumnFamily) bool {
+		var rrow = &redcloud.Row{Key: key}
+		var row *redcloud.ColumnFamily
+		var lastColumn string
+		var floor []byte
+		var truncated bool
+		var i int
+
+		if window != nil {
+			if floor, truncated = window.Floor(); truncated &&
+				bytes.Compare(key, floor) < 0 {
+				return false
+			}
+		}
+
+		if filter != nil {
+			var familyRows = make(map[string]*redcloud.ColumnFamily)
+
+			for i, row = range rows {
+				if row != nil {
+					familyRows[families[i].ColumnFamily] = row
+				}
+			}
+
+			if !filter.Matches(key, familyRows) {
+				return true
+			}
+		}
+
+		for i, row = range rows {
+			var fc *redcloud.FamilyColumns
+			var cs *redcloud.ColumnSet
+
+			if row == nil {
+				continue
+			}
+
+			fc = &redcloud.FamilyColumns{
+				ColumnFamily: families[i].ColumnFamily,
+			}
+			for _, cs = range row.ColumnSet {
+				var rcs = new(redcloud.ColumnSet)
+				var col *redcloud.Column
+
+				// Skip columns which were only read for the filter.
+				if filter != nil && !selectors[i].Matches(cs.Name) {
+					continue
+				}
+
+				// Skip all columns already returned before resuming.
+				if resumeFrom != nil && bytes.Equal(key, resumeFrom.Key) &&
+					cs.Name <= resumeFrom.Column {
+					continue
+				}
+
+				/*
+					Stop the scan once the page is full, but only at the
+					end of a column, so it can be resumed after that
+					column.
+				*/
+				if req.PageSize > 0 && numFound >= req.PageSize {
+					nextToken = &redcloud.GetRangeResumeToken{
+						Key:    key,
+						Column: lastColumn,
+					}
+					break
+				}
+
+				rcs.Name = cs.Name
+				for _, col = range cs.Column {
+					if req.MaxResults > 0 && numFound >= req.MaxResults {
+						break
+					}
+					if (req.MinTimestamp == 0 || col.Timestamp >= req.MinTimestamp) &&
+						(req.MaxTimestamp == 0 || col.Timestamp <= req.MaxTimestamp) {
+						rcs.Column = append(rcs.Column, col)
+						numFound++
+					}
+				}
+
+				if len(rcs.Column) > 0 {
+					fc.ColumnSet = append(fc.ColumnSet, rcs)
+					lastColumn = cs.Name
+				}
+			}
+
+			if len(fc.ColumnSet) > 0 {
+				rrow.Family = append(rrow.Family, fc)
+			}
+			if nextToken != nil {
+				break
+			}
+		}
+
+		if len(rrow.Family) > 0 {
+			if sendErr = send(rrow); sendErr != nil {
+				return false
+			}
+		}
+
+		if nextToken == nil && req.PageSize > 0 &&
+			numFound >= req.PageSize && len(rrow.Family) > 0 {
+			nextToken = &redcloud.GetRangeResumeToken{
+				Key:    key,
+				Column: lastColumn,
+			}
+		}
+
+		return nextToken == nil &&
+			(req.MaxResults == 0 || numFound < req.MaxResults)
+	}
+
+	/*
+		Reverse scans only keep a window of the last rows of every file in
+		memory. If that wasn't enough, the rest of the range below the
+		window is scanned in another pass.
+	*/
+	for {
+		var floor []byte
+		var truncated bool
+
+		sources = nil
+		selectors = nil
+		numFiles = 0
+		if req.Reverse {
+			window = storage.NewReverseWindow(reverseScanWindow(req))
+		}
+
+		for _, family = range families {
+			var numFamilyFiles int
+
+			if source, numFamilyFiles, err = dns.lookupFamily(ctx, req.Table,
+				common.NewKeyRange(req.StartKey, req.EndKey), kr, family,
+				filter, req.Reverse, window, now,
+				req.ReadTimestamp); err != nil {
+				// Don't leave the lookups started so far blocked.
+				for _, source = range sources {
+					go source.Drain()
+				}
+
+				numErrors.With(prometheus.Labels{
+					"service":     "DataNodeService",
+					"method":      method,
+					"error_class": "get_sstable_path_description",
+				}).Inc()
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Error fetching sstable path")
+				return nil, err
+			}
+
+			sources = append(sources, source)
+			selectors = append(selectors, columnSelector(family, nil))
+			numFiles += numFamilyFiles
+		}
+
+		span.AddAttributes(
+			trace.Int64Attribute("files-touched", int64(numFiles)))
+
+		/*
+			Paged scans move on to the next tablet once a page without a
+			resume token is returned, so a tablet without any data yields an
+			empty last page rather than an error.
+		*/
+		if numFiles == 0 && paged {
+			span.Annotate(nil, "No data sources found")
+			return nil, nil
+		}
+
+		if numFiles == 0 {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      method,
+				"error_class": "no_data_sources",
+			}).Inc()
+			span.Annotate(nil, "No data sources found")
+			// No sources means no data.
+			return nil, grpc.Errorf(codes.NotFound,
+				"No data sources in column family %s for key: empty",
+				families[0].ColumnFamily)
+		}
+
+		allErrors = append(allErrors, storage.MergeRowsBySource(
+			sources, req.Reverse, emit)...)
+
+		if window == nil || sendErr != nil || len(allErrors) > 0 ||
+			nextToken != nil ||
+			(req.MaxResults > 0 && numFound >= req.MaxResults) {
+			break
+		}
+		if floor, truncated = window.Floor(); !truncated {
+			break
+		}
+		kr = common.NewKeyRange(kr.StartKey, floor)
+	}
+
+	// Stop all lookups which are still running.
+	cancel()
+
+	if sendErr != nil {
+		span.AddAttributes(trace.StringAttribute("error", sendErr.Error()))
+		span.Annotate(nil, "Error sending results")
+		return nil, sendErr
+	}
+
+	if len(allErrors) > 0 {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      method,
+			"error_class": "lookup_errors",
+		}).Inc()
+		span.AddAttributes(
+			trace.Int64Attribute("num-errors", int64(len(allErrors))))
+		span.Annotate(nil, "Read errors encountered")
+		return nil, lookupError(allErrors)
+	}
+
+	if nextToken != nil {
+		var token []byte
+
+		if token, err = proto.Marshal(nextToken); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      method,
+				"error_class": "marshal_resume_token",
+			}).Inc()
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error encoding resume token")
+			return nil, err
+		}
+		return token, nil
+	}
+
+	return nil, nil
+}
+
+/*
+Insert is used to set a very specific data cell to the specified value. If
+requested or configured for the table, the timestamp of the cell is assigned
+by the data node. Returns the timestamp the cell was written with.
+*/
+func (dns *DataNodeService) Insert(
+	parentCtx context.Context, req *redcloud.InsertRequest) (
+	*redcloud.InsertResponse, error) {
+	var ctx context.Context
+	var span *trace.Span
+	var cf redcloud.ColumnFamily
+	var cs *redcloud.ColumnSet
+	var col = req.Column
+	var info *sstableInfo
+	var writer *JournalWriter
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Insert")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", req.Table),
+		trace.StringAttribute("column-family", req.ColumnFamily))
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "Insert",
+	}).Inc()
+
+	// Get the journal writer for the corresponding data set.
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	if info, err = dns.rangeRegistry.lockJournal(
+		ctx, req.Table, req.ColumnFamily, req.Key); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Insert",
+			"error_class": "lock_journal_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error locking journal")
+		return &redcloud.InsertResponse{}, err
+	}
+	defer info.JournalLock.Unlock()
+
+	if req.ServerTimestamp ||
+		dns.rangeRegistry.usesServerTimestamps(req.Table) {
+		// Don't modify the request, the column is written with a new timestamp.
+		if col == nil {
+			col = new(redcloud.Column)
+		} else {
+			col = proto.Clone(col).(*redcloud.Column)
+		}
+
+		col.Timestamp = assignTimestamps([]*sstableInfo{info}, 1,
+			time.Now().UnixNano()/1000000)
+		span.AddAttributes(trace.Int64Attribute("timestamp", col.Timestamp))
+	}
+
+	cs = new(redcloud.ColumnSet)
+	cs.Name = req.ColumnName
+	cs.Column = append(cs.Column, col)
+
+	cf.Key = req.Key
+	cf.ColumnSet = append(cf.ColumnSet, cs)
+
+	if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+		ctx, req.Table, req.ColumnFamily, req.Key, info); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Insert",
+			"error_class": "get_journal_writer_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error setting up journal writer")
+		return &redcloud.InsertResponse{}, err
+	}
+
+	if err = writer.WriteMessage(ctx, &cf); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Insert",
+			"error_class": "write_message_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error writing inserted data to journal")
+		return &redcloud.InsertResponse{}, err
+	}
+
+	dns.rangeRegistry.ReportJournalUsage(
+		ctx, req.Table, req.ColumnFamily, req.Key, int64(proto.Size(&cf)))
+	return &redcloud.InsertResponse{Timestamp: col.GetTimestamp()}, nil
+}
+
+/*
+expectationsMet determines whether current, the latest version of the cell
+or nil if there is none, matches the expectations of the CheckAndInsert
+request.
+*/
+func expectationsMet(req *redcloud.CheckAndInsertRequest,
+	current *redcloud.Column) bool {
+	if req.ExpectAbsent {
+		return current == nil
+	}
+
+	return current != nil && current.Timestamp == req.ExpectedTimestamp &&
+		bytes.Equal(current.Content, req.ExpectedContent)
+}
+
+/*
+CheckAndInsert sets a data cell to the specified value if the latest version
+of the cell matches the expectations from the request. The check and the
+write are performed while holding the journal lock of the tablet, which
+every write to the tablet holds while writing to the journal, so no write
+can slip in between the check and the insert.
+*/
+func (dns *DataNodeService) CheckAndInsert(
+	parentCtx context.Context, req *redcloud.CheckAndInsertRequest) (
+	*redcloud.CheckAndInsertResponse, error) {
+	var ctx context.Context
+	var span *trace.Span
+	var ins = req.Insert
+	var col *redcloud.Column
+	var cf redcloud.ColumnFamily
+	var info *sstableInfo
+	var writer *JournalWriter
+	var current *redcloud.Column
+	var allErrors []string
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataNodeService/CheckAndInsert")
+	defer span.End()
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "CheckAndInsert",
+	}).Inc()
+
+	if ins == nil || ins.Column == nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "invalid_request",
+		}).Inc()
+		span.Annotate(nil, "No insert specified")
+		return nil, grpc.Errorf(codes.InvalidArgument,
+			"No column to insert specified")
+	}
+	col = ins.Column
+
+	span.AddAttributes(
+		trace.StringAttribute("table", ins.Table),
+		trace.StringAttribute("column-family", ins.ColumnFamily))
+
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	if info, err = dns.rangeRegistry.getInfoDescriptor(
+		ctx, ins.Table, ins.ColumnFamily, ins.Key); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "get_info_descriptor_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error looking up tablet")
+		return nil, err
+	}
+
+	if !info.JournalLock.LockWithContext(ctx) {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "journal_lock_timeout",
+		}).Inc()
+		span.Annotate(nil, "Timed out waiting for journal lock")
+		return nil, ctx.Err()
+	}
+	defer info.JournalLock.Unlock()
+
+	if current, _, allErrors, err = dns.lookupLatest(ctx, ins.Table,
+		ins.ColumnFamily, ins.ColumnName, ins.Key, 0); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "get_sstable_path_description",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error fetching sstable path")
+		return nil, err
+	}
+
+	// Without knowing the current value, the condition can't be checked.
+	if len(allErrors) > 0 {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "lookup_errors",
+		}).Inc()
+		span.AddAttributes(
+			trace.Int64Attribute("num-errors", int64(len(allErrors))))
+		span.Annotate(nil, "Read errors encountered")
+		return nil, lookupError(allErrors)
+	}
+
+	if !expectationsMet(req, current) {
+		span.Annotate(nil, "Expectations not met")
+		return &redcloud.CheckAndInsertResponse{Current: current}, nil
+	}
+
+	if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+		ctx, ins.Table, ins.ColumnFamily, ins.Key, info); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "get_journal_writer_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error setting up journal writer")
+		return nil, err
+	}
+
+	if ins.ServerTimestamp ||
+		dns.rangeRegistry.usesServerTimestamps(ins.Table) {
+		// Don't modify the request, the column is written with a new timestamp.
+		col = proto.Clone(col).(*redcloud.Column)
+		col.Timestamp = assignTimestamps([]*sstableInfo{info}, 1,
+			time.Now().UnixNano()/1000000)
+		span.AddAttributes(trace.Int64Attribute("timestamp", col.Timestamp))
+	}
+
+	cf.Key = ins.Key
+	cf.ColumnSet = append(cf.ColumnSet, &redcloud.ColumnSet{
+		Name:   ins.ColumnName,
+		Column: []*redcloud.Column{col},
+	})
+
+	if err = writer.WriteMessage(ctx, &cf); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "CheckAndInsert",
+			"error_class": "write_message_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error writing inserted data to journal")
+		return nil, err
+	}
+
+	dns.rangeRegistry.ReportJournalUsage(
+		ctx, ins.Table, ins.ColumnFamily, ins.Key, int64(proto.Size(&cf)))
+	return &redcloud.CheckAndInsertResponse{
+		Applied:   true,
+		Current:   current,
+		Timestamp: col.Timestamp,
+	}, nil
+}
+
+/*
+streamInsertCheckpointInterval is the number of inserts after which a
+checkpoint is sent to the client during a StreamInsert RPC.
+*/
+const streamInsertCheckpointInterval = 1000
+
+/*
+StreamInsert inserts a stream of data cells. The inserts are processed in
+batches, and after each batch a checkpoint acknowledging the inserts
+written so far is sent back to the client.
+*/
+func (dns *DataNodeService) StreamInsert(
+	stream redcloud.DataNodeService_StreamInsertServer) error {
+	var ctx context.Context
+	var span *trace.Span
+	var numInserted int64
+	var eof bool
+	var err error
+
+	ctx, span = trace.StartSpan(
+		stream.Context(), "red-cloud.DataNodeService/StreamInsert")
+	defer span.End()
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "StreamInsert",
+	}).Inc()
+
+	for !eof {
+		var batch []*redcloud.InsertRequest
+		var numWritten int
+		var sendErr error
+
+		// Receive a batch of inserts before locking the registry.
+		for len(batch) < streamInsertCheckpointInterval {
+			var req *redcloud.InsertRequest
+
+			if req, err = stream.Recv(); err == io.EOF {
+				eof = true
+				break
+			} else if err != nil {
+				numErrors.With(prometheus.Labels{
+					"service":     "DataNodeService",
+					"method":      "StreamInsert",
+					"error_class": "receive_failed",
+				}).Inc()
+				span.AddAttributes(trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Error receiving inserts")
+				return err
+			}
+
+			batch = append(batch, req)
+		}
+
+		numWritten, err = dns.writeInsertBatch(ctx, batch)
+		numInserted += int64(numWritten)
+
+		/*
+			Acknowledge everything written so far, even if the batch failed,
+			so the client knows which inserts to retry.
+		*/
+		if sendErr = stream.Send(&redcloud.StreamInsertCheckpoint{
+			NumInserted: numInserted,
+		}); sendErr != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "StreamInsert",
+				"error_class": "send_failed",
+			}).Inc()
+			span.AddAttributes(trace.StringAttribute("error", sendErr.Error()))
+			span.Annotate(nil, "Error sending checkpoint")
+			return sendErr
+		}
+
+		if err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error writing inserted data to journal")
+			return err
+		}
+	}
+
+	span.AddAttributes(trace.Int64Attribute("num-inserted", numInserted))
+	return nil
+}
+
+/*
+writeInsertBatch writes a batch of inserts received through StreamInsert to
+the corresponding journals. Consecutive inserts into the same tablet and
+column family share the journal lock and writer. The journal lock is
+released at the end of the batch at the latest, so the journal can still be
+rotated and flushed between checkpoints. Returns the number of inserts
+written before an error occurred, if any.
+*/
+func (dns *DataNodeService) writeInsertBatch(ctx context.Context,
+	batch []*redcloud.InsertRequest) (int, error) {
+	var req *redcloud.InsertRequest
+	var info, locked *sstableInfo
+	var writer *JournalWriter
+	var i int
+	var err error
+
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	defer func() {
+		if locked != nil {
+			locked.JournalLock.Unlock()
+		}
+	}()
+
+	for i, req = range batch {
+		if info, err = dns.rangeRegistry.getInfoDescriptor(
+			ctx, req.Table, req.ColumnFamily, req.Key); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "StreamInsert",
+				"error_class": "get_info_descriptor_failed",
+			}).Inc()
+			return i, err
+		}
+
+		if info != locked {
+			if locked != nil {
+				locked.JournalLock.Unlock()
+				locked = nil
+			}
+
+			if !info.JournalLock.LockWithContext(ctx) {
+				numErrors.With(prometheus.Labels{
+					"service":     "DataNodeService",
+					"method":      "StreamInsert",
+					"error_class": "journal_lock_timeout",
+				}).Inc()
+				return i, ctx.Err()
+			}
+			locked = info
+
+			if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+				ctx, req.Table, req.ColumnFamily, req.Key, info); err != nil {
+				numErrors.With(prometheus.Labels{
+					"service":     "DataNodeService",
+					"method":      "StreamInsert",
+					"error_class": "get_journal_writer_failed",
+				}).Inc()
+				return i, err
+			}
+		}
+
+		if err = dns.writeStreamedInsert(ctx, req, info, writer); err != nil {
+			return i, err
+		}
+	}
+
+	return len(batch), nil
+}
+
+/*
+writeStreamedInsert writes a single insert received through StreamInsert to
+the journal of its tablet, described by info, using writer. If requested or
+configured for the table, the timestamp of the cell is assigned by the data
+node. The caller is expected to hold a read lock on the range registry and
+the journal lock of info.
+*/
+func (dns *DataNodeService) writeStreamedInsert(ctx context.Context,
+	req *redcloud.InsertRequest, info *sstableInfo,
+	writer *JournalWriter) error {
+	var cf redcloud.ColumnFamily
+	var col = req.Column
+	var err error
+
+	if req.ServerTimestamp ||
+		dns.rangeRegistry.usesServerTimestamps(req.Table) {
+		// Don't modify the request, the column is written with a new timestamp.
+		if col == nil {
+			col = new(redcloud.Column)
+		} else {
+			col = proto.Clone(col).(*redcloud.Column)
+		}
+
+		col.Timestamp = assignTimestamps([]*sstableInfo{info}, 1,
+			time.Now().UnixNano()/1000000)
+	}
+
+	cf.Key = req.Key
+	cf.ColumnSet = append(cf.ColumnSet, &redcloud.ColumnSet{
+		Name:   req.ColumnName,
+		Column: []*redcloud.Column{col},
+	})
+
+	if err = writer.WriteMessage(ctx, &cf); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "StreamInsert",
+			"error_class": "write_message_failed",
+		}).Inc()
+		return err
+	}
+
+	dns.rangeRegistry.ReportJournalUsage(
+		ctx, req.Table, req.ColumnFamily, req.Key, int64(proto.Size(&cf)))
+	return nil
+}
+
+/*
+makeTombstone creates a ColumnSet holding a tombstone for the specified
+column, or for the column range if no column is given. If neither is given,
+the tombstone covers the entire row. A timestamp of 0 is replaced with the
+current time.
+*/
+func makeTombstone(column string, columnRange *redcloud.ColumnRange,
+	timestamp int64) *redcloud.ColumnSet {
+	var cs = new(redcloud.ColumnSet)
+	var col = &redcloud.Column{
+		Type:      redcloud.Column_TOMBSTONE,
+		Timestamp: timestamp,
+	}
+
+	if col.Timestamp == 0 {
+		col.Timestamp = time.Now().UnixNano() / 1000000
+	}
+
+	if len(column) > 0 {
+		cs.Name = column
+	} else {
+		/*
+			Without a column range, the tombstone covers all columns from
+			the empty column name onwards, i.e. the entire row.
+		*/
+		col.Type = redcloud.Column_RANGE_TOMBSTONE
+		if columnRange != nil {
+			cs.Name = columnRange.StartColumn
+			col.EndColumn = columnRange.EndColumn
+		}
+	}
+	cs.Column = append(cs.Column, col)
+
+	return cs
+}
+
+/*
+Delete writes a tombstone for the specified data cell, column range or row
+to the journal. The tombstone hides all data not newer than itself from
+readers, and the data will be removed for good by the next major compaction.
+If configured for the table, the timestamp of the tombstone is assigned by
+the data node.
+*/
+func (dns *DataNodeService) Delete(
+	parentCtx context.Context, req *redcloud.DeleteRequest) (
+	*redcloud.Empty, error) {
+	var ctx context.Context
+	var span *trace.Span
+	var cf redcloud.ColumnFamily
+	var timestamp = req.Timestamp
+	var info *sstableInfo
+	var writer *JournalWriter
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Delete")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", req.Table),
+		trace.StringAttribute("column-family", req.ColumnFamily))
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "Delete",
+	}).Inc()
+
+	// Get the journal writer for the corresponding data set.
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	if info, err = dns.rangeRegistry.lockJournal(
+		ctx, req.Table, req.ColumnFamily, req.Key); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Delete",
+			"error_class": "lock_journal_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error locking journal")
+		return &redcloud.Empty{}, err
+	}
+	defer info.JournalLock.Unlock()
+
+	if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+		ctx, req.Table, req.ColumnFamily, req.Key, info); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Delete",
 			"error_class": "get_journal_writer_failed",
 		}).Inc()
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
@@ -406,17 +1738,347 @@ func (dns *DataNodeService) Insert(
 		return &redcloud.Empty{}, err
 	}
 
+	if dns.rangeRegistry.usesServerTimestamps(req.Table) {
+		timestamp = assignTimestamps([]*sstableInfo{info}, 1,
+			time.Now().UnixNano()/1000000)
+	}
+
+	cf.Key = req.Key
+	cf.ColumnSet = append(cf.ColumnSet, makeTombstone(
+		req.Column, req.ColumnRange, timestamp))
+
 	if err = writer.WriteMessage(ctx, &cf); err != nil {
 		numErrors.With(prometheus.Labels{
 			"service":     "DataNodeService",
-			"method":      "Insert",
+			"method":      "Delete",
 			"error_class": "write_message_failed",
 		}).Inc()
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
-		span.Annotate(nil, "Error writing inserted data to journal")
+		span.Annotate(nil, "Error writing tombstone to journal")
+	} else {
+		dns.rangeRegistry.ReportJournalUsage(
+			ctx, req.Table, req.ColumnFamily, req.Key, int64(proto.Size(&cf)))
+	}
+	return &redcloud.Empty{}, err
+}
+
+/*
+Increment writes a delta for the specified counter cell to the journal.
+Readers will see the sum of all deltas of the counter. If configured for the
+table, the timestamp of the delta is assigned by the data node.
+*/
+func (dns *DataNodeService) Increment(
+	parentCtx context.Context, req *redcloud.IncrementRequest) (
+	*redcloud.Empty, error) {
+	var ctx context.Context
+	var span *trace.Span
+	var cf redcloud.ColumnFamily
+	var col = &redcloud.Column{
+		Type:      redcloud.Column_COUNTER,
+		Timestamp: req.Timestamp,
+		Content:   storage.EncodeCounter(req.Delta),
+	}
+	var info *sstableInfo
+	var writer *JournalWriter
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.DataNodeService/Increment")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", req.Table),
+		trace.StringAttribute("column-family", req.ColumnFamily))
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "Increment",
+	}).Inc()
+
+	if len(req.Column) == 0 {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Increment",
+			"error_class": "invalid_request",
+		}).Inc()
+		span.Annotate(nil, "No column specified")
+		return &redcloud.Empty{}, grpc.Errorf(codes.InvalidArgument,
+			"No counter column specified")
+	}
+
+	// Get the journal writer for the corresponding data set.
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	if info, err = dns.rangeRegistry.lockJournal(
+		ctx, req.Table, req.ColumnFamily, req.Key); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Increment",
+			"error_class": "lock_journal_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error locking journal")
+		return &redcloud.Empty{}, err
+	}
+	defer info.JournalLock.Unlock()
+
+	if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+		ctx, req.Table, req.ColumnFamily, req.Key, info); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Increment",
+			"error_class": "get_journal_writer_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error setting up journal writer")
+		return &redcloud.Empty{}, err
+	}
+
+	if dns.rangeRegistry.usesServerTimestamps(req.Table) {
+		col.Timestamp = assignTimestamps([]*sstableInfo{info}, 1,
+			time.Now().UnixNano()/1000000)
+	} else if col.Timestamp == 0 {
+		col.Timestamp = time.Now().UnixNano() / 1000000
+	}
+
+	cf.Key = req.Key
+	cf.ColumnSet = append(cf.ColumnSet, &redcloud.ColumnSet{
+		Name:   req.Column,
+		Column: []*redcloud.Column{col},
+	})
+
+	if err = writer.WriteMessage(ctx, &cf); err != nil {
+		numErrors.With(prometheus.Labels{
+			"service":     "DataNodeService",
+			"method":      "Increment",
+			"error_class": "write_message_failed",
+		}).Inc()
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error writing counter delta to journal")
 	} else {
 		dns.rangeRegistry.ReportJournalUsage(
 			ctx, req.Table, req.ColumnFamily, req.Key, int64(proto.Size(&cf)))
 	}
 	return &redcloud.Empty{}, err
 }
+
+/*
+journalRecord describes a record to be written to the journal of a specific
+column family.
+*/
+type journalRecord struct {
+	ColumnFamily string
+	Data         *redcloud.ColumnFamily
+}
+
+/*
+makeJournalRecords combines all mutations of the row into one journal
+record per column family. The records are sorted by column family. If
+"firstTimestamp" is set, the mutations are assigned consecutive timestamps
+starting from it in the order they are listed in, rather than the ones from
+the request. Returns an error if any of the mutations is invalid.
+*/
+func makeJournalRecords(row *redcloud.RowMutation, firstTimestamp int64) (
+	[]*journalRecord, error) {
+	var records []*journalRecord
+	var byFamily = make(map[string]*journalRecord)
+	var mutation *redcloud.Mutation
+	var i int
+	var ok bool
+
+	for i, mutation = range row.Mutation {
+		var record *journalRecord
+		var cs *redcloud.ColumnSet
+		var timestamp = mutation.Timestamp
+
+		if firstTimestamp > 0 {
+			timestamp = firstTimestamp + int64(i)
+		}
+
+		if mutation.Type == redcloud.Mutation_INSERT {
+			var col *redcloud.Column
+
+			if len(mutation.Column) == 0 || mutation.Value == nil {
+				return nil, grpc.Errorf(codes.InvalidArgument,
+					"Insert into %s of row %v lacks column name or value",
+					mutation.ColumnFamily, row.Key)
+			}
+
+			col = mutation.Value
+			if firstTimestamp > 0 {
+				// Don't modify the request.
+				col = proto.Clone(col).(*redcloud.Column)
+				col.Timestamp = timestamp
+			}
+
+			cs = &redcloud.ColumnSet{
+				Name:   mutation.Column,
+				Column: []*redcloud.Column{col},
+			}
+		} else {
+			cs = makeTombstone(mutation.Column, mutation.ColumnRange,
+				timestamp)
+		}
+
+		if record, ok = byFamily[mutation.ColumnFamily]; !ok {
+			record = &journalRecord{
+				ColumnFamily: mutation.ColumnFamily,
+				Data:         &redcloud.ColumnFamily{Key: row.Key},
+			}
+			byFamily[mutation.ColumnFamily] = record
+			records = append(records, record)
+		}
+
+		storage.MergeColumnFamilies(record.Data, &redcloud.ColumnFamily{
+			Key:       row.Key,
+			ColumnSet: []*redcloud.ColumnSet{cs},
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ColumnFamily < records[j].ColumnFamily
+	})
+
+	return records, nil
+}
+
+/*
+BatchMutate applies a number of inserts and deletions to rows of a table.
+The changes to each column family of a row are combined into a single
+journal record, so readers will see either all or none of them. Requests
+containing invalid mutations are rejected before any data is written.
+*/
+func (dns *DataNodeService) BatchMutate(
+	parentCtx context.Context, req *redcloud.BatchMutateRequest) (
+	*redcloud.Empty, error) {
+	var ctx context.Context
+	var span *trace.Span
+	var rows [][]*journalRecord
+	var row *redcloud.RowMutation
+	var i int
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.DataNodeService/BatchMutate")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", req.Table),
+		trace.Int64Attribute("num-rows", int64(len(req.Row))))
+
+	numRequests.With(prometheus.Labels{
+		"service": "DataNodeService",
+		"method":  "BatchMutate",
+	}).Inc()
+
+	// Assemble the journal records of all rows first.
+	for _, row = range req.Row {
+		var records []*journalRecord
+
+		if records, err = makeJournalRecords(row, 0); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "BatchMutate",
+				"error_class": "invalid_mutation",
+			}).Inc()
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Invalid mutation")
+			return &redcloud.Empty{}, err
+		}
+
+		rows = append(rows, records)
+	}
+
+	dns.rangeRegistry.Lock()
+	defer dns.rangeRegistry.Unlock()
+
+	for i, row = range req.Row {
+		if err = dns.writeRowMutation(
+			ctx, req.Table, row, rows[i]); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error writing mutations to journal")
+			return &redcloud.Empty{}, err
+		}
+	}
+
+	return &redcloud.Empty{}, nil
+}
+
+/*
+writeRowMutation writes the journal records of a single row of a
+BatchMutate request. The journals of all column families of the row are
+locked and their writers obtained before anything is written, so the row
+isn't left half written if a column family is unknown. If configured for the
+table, the records are recreated with timestamps assigned by the data node.
+The caller is expected to hold a read lock on the range registry.
+*/
+func (dns *DataNodeService) writeRowMutation(ctx context.Context,
+	table string, row *redcloud.RowMutation, records []*journalRecord) error {
+	var key = row.Key
+	var writers []*JournalWriter
+	var infos []*sstableInfo
+	var record *journalRecord
+	var info *sstableInfo
+	var i int
+	var err error
+
+	/*
+		The records are sorted by column family, so concurrent mutations of
+		the same row always lock the journals in the same order.
+	*/
+	for _, record = range records {
+		var writer *JournalWriter
+
+		if info, err = dns.rangeRegistry.lockJournal(
+			ctx, table, record.ColumnFamily, key); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "BatchMutate",
+				"error_class": "lock_journal_failed",
+			}).Inc()
+			return err
+		}
+		defer info.JournalLock.Unlock()
+
+		if writer, err = dns.rangeRegistry.internalGetJournalWriter(
+			ctx, table, record.ColumnFamily, key, info); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "BatchMutate",
+				"error_class": "get_journal_writer_failed",
+			}).Inc()
+			return err
+		}
+
+		infos = append(infos, info)
+		writers = append(writers, writer)
+	}
+
+	/*
+		The records cover the same column families in the same order as
+		before, only the timestamps differ.
+	*/
+	if dns.rangeRegistry.usesServerTimestamps(table) {
+		if records, err = makeJournalRecords(row, assignTimestamps(
+			infos, len(row.Mutation),
+			time.Now().UnixNano()/1000000)); err != nil {
+			return err
+		}
+	}
+
+	for i, record = range records {
+		if err = writers[i].WriteMessage(ctx, record.Data); err != nil {
+			numErrors.With(prometheus.Labels{
+				"service":     "DataNodeService",
+				"method":      "BatchMutate",
+				"error_class": "write_message_failed",
+			}).Inc()
+			return err
+		}
+
+		dns.rangeRegistry.ReportJournalUsage(ctx, table,
+			record.ColumnFamily, key, int64(proto.Size(record.Data)))
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func testRowMutation() *redcloud.RowMutation {
+	return &redcloud.RowMutation{
+		Key: []byte("row"),
+		Mutation: []*redcloud.Mutation{
+			{Type: redcloud.Mutation_INSERT, ColumnFamily: "b", Column: "x",
+				Value: &redcloud.Column{Content: []byte("1"), Timestamp: 10}},
+			{Type: redcloud.Mutation_INSERT, ColumnFamily: "a", Column: "y",
+				Value: &redcloud.Column{Content: []byte("2"), Timestamp: 20}},
+			{Type: redcloud.Mutation_DELETE, ColumnFamily: "b", Column: "z",
+				Timestamp: 30},
+		},
+	}
+}
+
+func TestMakeJournalRecords(t *testing.T) {
+	var cases = []struct {
+		firstTimestamp int64
+		expected       string
+	}{
+		{0, "[a/y@20 b/x@10 b/z@30]"},
+		{100, "[a/y@101 b/x@100 b/z@102]"},
+	}
+	var i int
+
+	for i = range cases {
+		var row = testRowMutation()
+		var records []*journalRecord
+		var record *journalRecord
+		var columns []string
+		var err error
+
+		if records, err = makeJournalRecords(
+			row, cases[i].firstTimestamp); err != nil {
+			t.Errorf("Error making journal records from %d: %s",
+				cases[i].firstTimestamp, err)
+			continue
+		}
+
+		for _, record = range records {
+			var cs *redcloud.ColumnSet
+			var col *redcloud.Column
+
+			if string(record.Data.Key) != "row" {
+				t.Errorf("Unexpected key in %s record: %s",
+					record.ColumnFamily, record.Data.Key)
+			}
+
+			for _, cs = range record.Data.ColumnSet {
+				for _, col = range cs.Column {
+					columns = append(columns, fmt.Sprintf("%s/%s@%d",
+						record.ColumnFamily, cs.Name, col.Timestamp))
+				}
+			}
+		}
+
+		if fmt.Sprint(columns) != cases[i].expected {
+			t.Errorf("Unexpected records from %d: got %v, want %s",
+				cases[i].firstTimestamp, columns, cases[i].expected)
+		}
+
+		if row.Mutation[0].Value.Timestamp != 10 {
+			t.Errorf("Request modified with first timestamp %d",
+				cases[i].firstTimestamp)
+		}
+	}
+}
+
+func TestMakeJournalRecordsInvalid(t *testing.T) {
+	var row = testRowMutation()
+	var records []*journalRecord
+	var err error
+
+	row.Mutation[1].Value = nil
+
+	if records, err = makeJournalRecords(row, 0); grpc.Code(err) !=
+		codes.InvalidArgument {
+		t.Errorf("Unexpected error for insert without value: %v", err)
+	}
+	if records != nil {
+		t.Errorf("Records returned for invalid mutation: %v", records)
+	}
+}
+
+func TestExpectationsMet(t *testing.T) {
+	var current = &redcloud.Column{Content: []byte("a"), Timestamp: 5}
+	var cases = []struct {
+		name     string
+		req      *redcloud.CheckAndInsertRequest
+		current  *redcloud.Column
+		expected bool
+	}{
+		{"absent", &redcloud.CheckAndInsertRequest{ExpectAbsent: true},
+			nil, true},
+		{"not absent", &redcloud.CheckAndInsertRequest{ExpectAbsent: true},
+			current, false},
+		{"match", &redcloud.CheckAndInsertRequest{
+			ExpectedTimestamp: 5, ExpectedContent: []byte("a")},
+			current, true},
+		{"content mismatch", &redcloud.CheckAndInsertRequest{
+			ExpectedTimestamp: 5, ExpectedContent: []byte("b")},
+			current, false},
+		{"timestamp mismatch", &redcloud.CheckAndInsertRequest{
+			ExpectedTimestamp: 4, ExpectedContent: []byte("a")},
+			current, false},
+		{"missing", &redcloud.CheckAndInsertRequest{
+			ExpectedTimestamp: 5, ExpectedContent: []byte("a")},
+			nil, false},
+		{"empty content", &redcloud.CheckAndInsertRequest{
+			ExpectedTimestamp: 5},
+			&redcloud.Column{Timestamp: 5}, true},
+	}
+	var i int
+
+	for i = range cases {
+		if expectationsMet(cases[i].req, cases[i].current) !=
+			cases[i].expected {
+			t.Errorf("%s: expected %v", cases[i].name, cases[i].expected)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	etcd "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.opencensus.io/trace"
+)
+
+var journalsReplayed = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_journals_replayed",
+	Help:      "Number of journals replayed into memtables when loading tablets.",
+})
+var journalRecordsReplayed = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_journal_records_replayed",
+	Help:      "Number of journal records replayed when loading tablets.",
+})
+var journalReplaysFailed = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_journal_replays_failed",
+	Help:      "Number of journals which could not be replayed when loading tablets.",
+})
+var tornJournals = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_torn_journals",
+	Help:      "Number of journals whose torn tail was dropped when loading tablets.",
+})
+
+func init() {
+	prometheus.MustRegister(journalsReplayed)
+	prometheus.MustRegister(journalRecordsReplayed)
+	prometheus.MustRegister(journalReplaysFailed)
+	prometheus.MustRegister(tornJournals)
+}
+
+/*
+truncateMetadataTimeout limits the time spent replacing a torn journal in
+the metadata of its tablet in etcd while the tablet is being loaded.
+*/
+const truncateMetadataTimeout = 30 * time.Second
+
+/*
+journalRecovery describes the journals of a newly loaded sstable range which
+still have to be replayed into memtables.
+*/
+type journalRecovery struct {
+	info  *sstableInfo
+	paths []string
+}
+
+/*
+lockForRecovery keeps log sorting, compactions and writes away from the
+newly loaded sstable range described by info until recoverJournals has
+replayed its journals, and takes note of the journals to replay. It has to
+be called before the range is added to the registry, so no write can reach
+a journal before it has been replayed, and no journal created for later
+writes is mistaken for one which needs to be replayed.
+*/
+func lockForRecovery(info *sstableInfo) *journalRecovery {
+	info.LogsortLock.Lock()
+	info.CompactionLock.Lock()
+
+	// Nobody else knows about the range yet, so this doesn't block.
+	info.JournalLock.LockWithContext(context.Background())
+
+	return &journalRecovery{
+		info:  info,
+		paths: append([]string{}, info.Descriptor.RelevantJournalPaths...),
+	}
+}
+
+/*
+recoverJournals replays the journals of a newly loaded sstable range, as
+recorded by lockForRecovery, into memtables, so lookups never have to read
+them, and releases the locks taken by lockForRecovery. The memtables are
+sealed, since no more data will ever be written to these journals. Journals
+ending in a torn record, e.g. because the data node writing them died
+halfway through a write, are replaced by a journal without the torn tail,
+and the original is kept in quarantine. Journals which cannot be read for
+any other reason are left for lookups to read, which will report the error.
+
+Until the journal lock has been released, the memtables of replaced journals
+are registered for the original journals, since the range has already been
+published. The new journals are swapped in under the registry lock once the
+journal lock has been released, since writers take the locks the other way
+around.
+*/
+func (reg *ServingRangeRegistry) recoverJournals(parentCtx context.Context,
+	table string, endKey []byte, recovery *journalRecovery) {
+	var ctx context.Context
+	var span *trace.Span
+	var info = recovery.info
+	var replaced = make(map[string]string)
+	var path, newPath string
+
+	defer info.LogsortLock.Unlock()
+	defer info.CompactionLock.Unlock()
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.ServingRangeRegistry/recoverJournals")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", table),
+		trace.StringAttribute("column-family", info.Descriptor.ColumnFamily))
+
+	for _, path = range recovery.paths {
+		var memtable = storage.NewMemtable()
+		var numRecords int64
+		var torn error
+		var err error
+
+		// Never replace a memtable which may already hold live writes.
+		if reg.GetMemtable(path) != nil {
+			continue
+		}
+
+		if numRecords, torn, err = storage.ReplayJournal(
+			ctx, path, memtable); err != nil {
+			span.AddAttributes(
+				trace.StringAttribute("path", path),
+				trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Unable to replay journal")
+			log.Printf("Unable to replay journal %s: %s", path, err)
+			journalReplaysFailed.Inc()
+			continue
+		}
+
+		journalsReplayed.Inc()
+		journalRecordsReplayed.Add(float64(numRecords))
+
+		if torn != nil {
+			span.AddAttributes(
+				trace.StringAttribute("path", path),
+				trace.StringAttribute("error", torn.Error()))
+			span.Annotate(nil, "Dropping torn journal tail")
+			log.Printf("Dropping torn tail of journal %s after %d records: %s",
+				path, numRecords, torn)
+			tornJournals.Inc()
+
+			/*
+				Even if the journal can't be replaced, the memtable holds all
+				records which could be read, so lookups won't read the journal.
+			*/
+			if newPath, err = reg.truncateJournal(ctx, table, endKey,
+				info.Descriptor.ColumnFamily, path, memtable); err != nil {
+				log.Printf("Unable to replace torn journal %s: %s", path, err)
+			} else {
+				replaced[path] = newPath
+			}
+		}
+
+		// Timestamps assigned from now on must be newer than the replayed data.
+		if memtable.NewestTimestamp() > info.LastTimestamp {
+			info.LastTimestamp = memtable.NewestTimestamp()
+		}
+
+		memtable.Freeze()
+		if !reg.registerMemtable(path, memtable) {
+			log.Printf("Not replacing existing memtable of journal %s", path)
+		}
+	}
+
+	info.JournalLock.Unlock()
+
+	if len(replaced) == 0 {
+		return
+	}
+
+	/*
+		Log sorting and compactions are still locked out, and writers only
+		create journals while holding the registry read lock, so the journal
+		list doesn't change meanwhile.
+	*/
+	reg.registryAccessLock.Lock()
+	for path, newPath = range replaced {
+		info.Descriptor.RelevantJournalPaths, _ = replaceJournalPath(
+			info.Descriptor.RelevantJournalPaths, path, newPath)
+		reg.moveMemtable(path, newPath)
+	}
+	reg.registryAccessLock.Unlock()
+
+	for path = range replaced {
+		quarantineJournal(ctx, path)
+	}
+}
+
+/*
+truncateJournal replaces the journal at path, which ends in a torn record,
+with a new journal holding the records replayed from it into memtable, and
+replaces the journal in the metadata of the tablet in etcd. Since the new
+journal is written from the memtable, it is sorted and named like the output
+of log sorting. Returns the path of the new journal; the caller has to swap
+it into the descriptor of the range.
+
+The metadata update is given at most truncateMetadataTimeout and only
+retried up to common.MaxRetries times. If the last attempt failed to
+commit, it is unknown whether the new journal is referenced, so it is kept
+along with the original one.
+*/
+func (reg *ServingRangeRegistry) truncateJournal(parentCtx context.Context,
+	table string, endKey []byte, cf, path string,
+	memtable *storage.Memtable) (string, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var etcdPath = common.EtcdTableConfigPath(reg.instance, table)
+	var newPath = fmt.Sprintf("%s/%s.sorted", reg.prefixes[table],
+		storage.MakePath(reg.instance, table, cf, endKey, time.Now(),
+			storage.SSTableLevelJOURNAL))
+	var u *url.URL
+	var uncertain bool
+	var retries int
+	var err error
+
+	if _, err = storage.WriteJournal(parentCtx, newPath, memtable); err != nil {
+		return "", err
+	}
+
+	if u, err = url.Parse(newPath); err != nil {
+		return "", err
+	}
+
+	ctx, cancel = context.WithTimeout(parentCtx, truncateMetadataTimeout)
+	defer cancel()
+
+	// Unless the new journal may be referenced, it is of no use on errors.
+	defer func() {
+		if err == nil {
+			return
+		}
+		if uncertain {
+			log.Printf("Keeping journal %s of possibly updated tablet %s",
+				newPath, table)
+		} else {
+			filesystem.Remove(parentCtx, u)
+		}
+	}()
+
+	for retries = 0; ; retries++ {
+		var md redcloud.ServerTableMetadata
+		var tabletMd *redcloud.ServerTabletMetadata
+		var pathDescription *redcloud.SSTablePathDescription
+		var gresp *etcd.GetResponse
+		var presp *etcd.TxnResponse
+		var ev *mvccpb.KeyValue
+		var modrev int64
+		var version int64
+		var encData []byte
+		var found, replaced bool
+
+		if retries > 0 {
+			if retries > common.MaxRetries {
+				err = fmt.Errorf("Giving up updating %s after %d attempts: %s",
+					etcdPath, retries, err)
+				return "", err
+			}
+			if err = common.WaitForRetry(ctx, retries-1); err != nil {
+				return "", err
+			}
+		}
+
+		if gresp, err = reg.etcdClient.Get(
+			ctx, etcdPath, etcd.WithLimit(1)); err != nil {
+			return "", err
+		}
+
+		for _, ev = range gresp.Kvs {
+			if err = proto.Unmarshal(ev.Value, &md); err != nil {
+				err = fmt.Errorf(
+					"Unable to parse %s as ServerTableMetadata protobuf at version %d",
+					etcdPath, ev.Version)
+				return "", err
+			}
+
+			modrev = ev.ModRevision
+			version = ev.Version
+		}
+
+		// Replace the torn journal in the tablet the journal belongs to.
+		for _, tabletMd = range md.Tablet {
+			if !bytes.Equal(endKey, tabletMd.EndKey) {
+				continue
+			}
+
+			for _, pathDescription = range tabletMd.SstablePath {
+				if pathDescription.ColumnFamily != cf {
+					continue
+				}
+
+				if pathDescription.RelevantJournalPaths, replaced =
+					replaceJournalPath(pathDescription.RelevantJournalPaths,
+						path, newPath); replaced {
+					found = true
+				}
+			}
+		}
+
+		if !found {
+			// A commit we received an error for may have gone through.
+			if uncertain && tabletHasJournal(&md, endKey, cf, newPath) {
+				err = nil
+				return newPath, nil
+			}
+			uncertain = false
+			err = fmt.Errorf("Journal %s not found in %s", path, etcdPath)
+			return "", err
+		}
+
+		if encData, err = proto.Marshal(&md); err != nil {
+			err = fmt.Errorf("Unable to encode updated metadata: %s", err)
+			return "", err
+		}
+
+		if presp, err = reg.etcdClient.Txn(ctx).If(
+			etcd.Compare(etcd.ModRevision(etcdPath), "=", modrev),
+			etcd.Compare(etcd.Version(etcdPath), "=", version)).Then(
+			etcd.OpPut(etcdPath, string(encData))).Commit(); err != nil {
+			log.Printf("Error committing update to %s: %s", etcdPath, err)
+			uncertain = true
+			continue
+		}
+
+		// Successful update -> we're out.
+		if presp.Succeeded {
+			return newPath, nil
+		}
+
+		uncertain = false
+		err = fmt.Errorf("Concurrent update of %s", etcdPath)
+	}
+}
+
+/*
+replaceJournalPath returns a copy of paths in which the journal path from is
+replaced by to, and whether from was found at all.
+*/
+func replaceJournalPath(paths []string, from, to string) ([]string, bool) {
+	var rv = make([]string, len(paths))
+	var found bool
+	var i int
+
+	for i = range paths {
+		if paths[i] == from {
+			rv[i] = to
+			found = true
+		} else {
+			rv[i] = paths[i]
+		}
+	}
+
+	return rv, found
+}
+
+/*
+tabletHasJournal determines whether the tablet ending at endKey references
+the journal at path for column family cf in the table metadata md.
+*/
+func tabletHasJournal(md *redcloud.ServerTableMetadata, endKey []byte,
+	cf, path string) bool {
+	var tabletMd *redcloud.ServerTabletMetadata
+	var pathDescription *redcloud.SSTablePathDescription
+	var p string
+
+	for _, tabletMd = range md.Tablet {
+		if !bytes.Equal(endKey, tabletMd.EndKey) {
+			continue
+		}
+
+		for _, pathDescription = range tabletMd.SstablePath {
+			if pathDescription.ColumnFamily != cf {
+				continue
+			}
+
+			for _, p = range pathDescription.RelevantJournalPaths {
+				if p == path {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+quarantineJournal moves the journal at path, which has been replaced after
+dropping its torn tail, out of the way by copying it to a file with the
+suffix ".torn" and removing the original. The copy is no longer referenced
+by the tablet and is kept for inspection only. If it can't be written, the
+original is left in place.
+*/
+func quarantineJournal(ctx context.Context, path string) {
+	var from, to *url.URL
+	var r filesystem.ReadCloser
+	var w filesystem.WriteCloser
+	var buf = make([]byte, 65536)
+	var err error
+
+	if from, err = url.Parse(path); err != nil {
+		log.Printf("Error parsing journal path %s: %s", path, err)
+		return
+	}
+	if to, err = url.Parse(path + ".torn"); err != nil {
+		log.Printf("Error parsing quarantine path %s.torn: %s", path, err)
+		return
+	}
+
+	if r, err = filesystem.OpenReader(ctx, from); err != nil {
+		log.Printf("Error opening torn journal %s: %s", path, err)
+		return
+	}
+	defer r.Close(ctx)
+
+	if w, err = filesystem.OpenWriter(ctx, to); err != nil {
+		log.Printf("Error creating quarantine copy %s: %s", to.String(), err)
+		return
+	}
+
+	for {
+		var n int
+		var rerr error
+
+		n, rerr = r.Read(ctx, buf)
+		if n > 0 {
+			if _, err = w.Write(ctx, buf[:n]); err != nil {
+				log.Printf("Error writing quarantine copy %s: %s",
+					to.String(), err)
+				w.Close(ctx)
+				filesystem.Remove(ctx, to)
+				return
+			}
+		}
+		if rerr == io.EOF {
+			break
+		} else if rerr != nil {
+			log.Printf("Error reading torn journal %s: %s", path, rerr)
+			w.Close(ctx)
+			filesystem.Remove(ctx, to)
+			return
+		}
+	}
+
+	if err = w.Close(ctx); err != nil {
+		log.Printf("Error writing quarantine copy %s: %s", to.String(), err)
+		filesystem.Remove(ctx, to)
+		return
+	}
+
+	removeJournal(ctx, path)
+}
@@ -19,6 +19,7 @@ import (
 	_ "github.com/childoftheuniverse/filesystem-file"
 	rados "github.com/childoftheuniverse/filesystem-rados"
 	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/storage"
 	"github.com/childoftheuniverse/tlsconfig"
 	openzipkin "github.com/openzipkin/zipkin-go"
 	openzipkinModel "github.com/openzipkin/zipkin-go/model"
@@ -48,6 +49,8 @@ func main() {
 	var etcdCA string
 	var srv *grpc.Server
 	var maxMsgSize int
+	var readerCacheSize int
+	var blockCacheSize int64
 
 	var privateKeyPath string
 	var certificatePath string
@@ -87,6 +90,11 @@ func main() {
 		"Maximum size of RPC messages received")
 	flag.BoolVar(&exportPort, "export-port", false,
 		"Export the RPC port to etcd as an exported service")
+	flag.IntVar(&readerCacheSize, "sstable-reader-cache-size", 256,
+		"Maximum number of sstable readers to keep open between lookups")
+	flag.Int64Var(&blockCacheSize, "block-cache-bytes", 64*1048576,
+		"Maximum size of the decoded sstable records to keep for repeated "+
+			"lookups. 0 disables the block cache")
 	flag.Int64Var(&etcdTTL, "etcd-ttl", 30,
 		"Number of seconds the etcd exported service will stick around "+
 			"without being renewed. Must be greater than or equal to 5")
@@ -124,6 +132,9 @@ func main() {
 
 	startupDeadline = time.Now().Add(startupWait)
 
+	storage.SetSstableCache(
+		storage.NewSstableCache(readerCacheSize, blockCacheSize))
+
 	if certificatePath != "" && privateKeyPath != "" && caPath != "" {
 		if tlsConfig, err = tlsconfig.TLSConfigWithRootAndClientCAAndCert(
 			caPath, caPath, certificatePath, privateKeyPath); err != nil {
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/recordio"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var memtableBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "memtable_bytes",
+	Help:      "Size of the journal data held in memtables.",
+})
+
+var memtableFlushesTriggered = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_memtable_flushes_triggered",
+	Help:      "Number of memtables flushed early for having reached their maximum size.",
+})
+
+func init() {
+	prometheus.MustRegister(memtableBytes)
+	prometheus.MustRegister(memtableFlushesTriggered)
+}
+
+/*
+maxMemtableSize is the size in bytes at which writes move on from the
+journal of a memtable to a new journal, and the memtable is flushed to the
+minor sstable without waiting for the next round of compactions.
+*/
+const maxMemtableSize = 64 << 20
+
+/*
+JournalWriter writes records to a journal and adds them to the memtable of
+the journal, so lookups don't have to read the journal.
+*/
+type JournalWriter struct {
+	path     string
+	file     filesystem.WriteCloser
+	writer   *recordio.RecordWriter
+	memtable *storage.Memtable
+}
+
+/*
+WriteMessage writes the record to the journal, protected by a checksum, and
+adds it to the memtable. Records are never written to the journal of a
+frozen memtable, so its flush doesn't miss any data. Once the record has
+been written to the journal, adding it to the memtable cannot fail, so a
+write reported as failed has never been applied.
+*/
+func (w *JournalWriter) WriteMessage(ctx context.Context,
+	cf *redcloud.ColumnFamily) error {
+	var record *redcloud.ColumnFamily
+	var err error
+
+	if err = w.memtable.BeginWrite(); err != nil {
+		return err
+	}
+
+	if record, err = storage.ChecksumRecord(cf); err != nil {
+		w.memtable.AbortWrite()
+		return err
+	}
+
+	if err = w.writer.WriteMessage(ctx, record); err != nil {
+		w.memtable.AbortWrite()
+		return err
+	}
+
+	w.memtable.FinishWrite(cf)
+	memtableBytes.Add(float64(proto.Size(cf)))
+	return nil
+}
+
+/*
+full determines whether the memtable of the journal has reached
+maxMemtableSize, so writes should move on to a new journal.
+*/
+func (w *JournalWriter) full() bool {
+	return w.memtable.Size() >= maxMemtableSize
+}
+
+/*
+Close closes the journal file. The caller is expected to hold the journal
+lock of the sstable range and to make sure no further writes use the
+writer, e.g. by replacing it as the journal of the range.
+*/
+func (w *JournalWriter) Close(ctx context.Context) error {
+	return w.file.Close(ctx)
+}
+
+/*
+newJournalWriter creates a JournalWriter writing to the journal file at the
+specified path and registers a new memtable for the journal.
+*/
+func (reg *ServingRangeRegistry) newJournalWriter(
+	path string, file filesystem.WriteCloser) *JournalWriter {
+	var w = &JournalWriter{
+		path:     path,
+		file:     file,
+		writer:   recordio.NewRecordWriter(file),
+		memtable: storage.NewMemtable(),
+	}
+
+	reg.registerMemtable(path, w.memtable)
+	return w
+}
+
+/*
+registerMemtable registers the memtable as holding the data of the journal
+at the specified path. A memtable already registered for the journal is
+never replaced, since it may hold writes the new one lacks; false is
+returned in that case.
+*/
+func (reg *ServingRangeRegistry) registerMemtable(
+	path string, memtable *storage.Memtable) bool {
+	var ok bool
+
+	reg.memtableLock.Lock()
+	defer reg.memtableLock.Unlock()
+
+	if _, ok = reg.memtables[path]; ok {
+		return false
+	}
+
+	reg.memtables[path] = memtable
+	memtableBytes.Add(float64(memtable.Size()))
+	return true
+}
+
+/*
+moveMemtable registers the memtable of the journal at path from for the
+journal at path to instead, e.g. because the journal has been rewritten.
+*/
+func (reg *ServingRangeRegistry) moveMemtable(from, to string) {
+	var memtable *storage.Memtable
+	var ok bool
+
+	reg.memtableLock.Lock()
+	defer reg.memtableLock.Unlock()
+
+	if memtable, ok = reg.memtables[from]; ok {
+		delete(reg.memtables, from)
+		reg.memtables[to] = memtable
+	}
+}
+
+/*
+flushFullMemtable flushes the memtables of the sstable range described by
+info, whose journal has been rotated for having reached maxMemtableSize, to
+the minor sstable. key is any key within the range.
+*/
+func (reg *ServingRangeRegistry) flushFullMemtable(
+	table, cf string, key []byte, info *sstableInfo) {
+	var wg sync.WaitGroup
+
+	memtableFlushesTriggered.Inc()
+
+	wg.Add(1)
+	reg.minorCompaction(context.Background(), table, key, cf, info, &wg)
+}
+
+/*
+GetMemtable returns the memtable holding the data of the journal at the
+specified path, or nil if the journal has to be read instead, e.g. because
+it was written before the tablet was loaded.
+*/
+func (reg *ServingRangeRegistry) GetMemtable(path string) *storage.Memtable {
+	reg.memtableLock.RLock()
+	defer reg.memtableLock.RUnlock()
+
+	return reg.memtables[path]
+}
+
+/*
+journalSize determines the approximate combined size of the data in the
+journals at the specified paths, from the sizes of their memtables.
+*/
+func (reg *ServingRangeRegistry) journalSize(paths []string) int64 {
+	var memtable *storage.Memtable
+	var path string
+	var size int64
+
+	reg.memtableLock.RLock()
+	defer reg.memtableLock.RUnlock()
+
+	for _, path = range paths {
+		if memtable = reg.memtables[path]; memtable != nil {
+			size += memtable.Size()
+		}
+	}
+
+	return size
+}
+
+/*
+dropMemtable forgets about the memtable of the journal at the specified
+path, e.g. because it has been flushed or the tablet is no longer served.
+The memtable is frozen, so no write to the journal may be in progress: the
+caller has to hold the journal lock of the sstable range the journal belongs
+to, or the range must have been removed from the registry.
+*/
+func (reg *ServingRangeRegistry) dropMemtable(path string) {
+	var memtable *storage.Memtable
+	var ok bool
+
+	reg.memtableLock.Lock()
+	if memtable, ok = reg.memtables[path]; ok {
+		delete(reg.memtables, path)
+	}
+	reg.memtableLock.Unlock()
+
+	if ok {
+		memtable.Freeze()
+		memtableBytes.Add(-float64(memtable.Size()))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/url"
 	"sort"
 	"strings"
@@ -131,7 +132,7 @@ type sstableInfo struct {
 	Descriptor *redcloud.SSTablePathDescription
 
 	// Open writer to the journal.
-	Journal *recordio.RecordWriter
+	Journal *JournalWriter
 
 	// Number of times the journal has been written to.
 	JournalNumUses uint64
@@ -158,6 +159,26 @@ type sstableInfo struct {
 		parallel.
 	*/
 	CompactionLock sync.Mutex
+
+	/*
+		Most recent timestamp assigned to a write by the data node, or seen
+		in the data of the range when it was loaded, in milliseconds since
+		the epoch. Protected by JournalLock.
+	*/
+	LastTimestamp int64
+
+	/*
+		Set once the range has been split and its journals have been
+		rewritten for the new ranges, so no more journals may be created
+		for it. Protected by JournalLock.
+	*/
+	Replaced bool
+
+	/*
+		Set while the range is being split, so it isn't unloaded meanwhile.
+		Protected by the registryAccessLock of the registry.
+	*/
+	Splitting bool
 }
 
 /*
@@ -190,6 +211,50 @@ type ServingRangeRegistry struct {
 	*/
 	prefixes map[string]string
 
+	/*
+		Split sizes for each table as seen on the last reload of the
+		metadata. Tablets growing beyond this size will be split in two.
+	*/
+	splitSizes map[string]int64
+
+	/*
+		Maximum number of versions to keep of each cell for each table, as
+		seen on the last reload of the metadata.
+	*/
+	maxVersions map[string]int64
+
+	/*
+		Maximum age of older versions of each cell for each table, in
+		milliseconds, as seen on the last reload of the metadata.
+	*/
+	maxVersionAges map[string]int64
+
+	/*
+		Whether inserts into each table are assigned timestamps by the data
+		node, as seen on the last reload of the metadata.
+	*/
+	serverTimestamps map[string]bool
+
+	/*
+		Compression of the sstables written for each table, as seen on the
+		last reload of the metadata.
+	*/
+	compressions map[string]redcloud.Compression
+
+	/*
+		Bloom filters of the sstables served, by sstable path. Protected by
+		bloomFilterLock, since they are replaced by compactions.
+	*/
+	bloomFilters    map[string]*storage.BloomFilter
+	bloomFilterLock sync.RWMutex
+
+	/*
+		Memtables holding the data of the journals written by this data
+		node, by journal path. Protected by memtableLock.
+	*/
+	memtables    map[string]*storage.Memtable
+	memtableLock sync.RWMutex
+
 	/*
 		registryAccessLock controls read/write access to the registry to
 		prevent trying to access key ranges while they are being written.
@@ -227,6 +292,13 @@ func NewServingRangeRegistry(instance, host string, port uint16,
 		columnFamilies:       make(map[string]map[string]map[string]*sstableInfo),
 		columnFamilyMetadata: make(map[string]map[string]*redcloud.ColumnFamilyMetadata),
 		prefixes:             make(map[string]string),
+		splitSizes:           make(map[string]int64),
+		maxVersions:          make(map[string]int64),
+		maxVersionAges:       make(map[string]int64),
+		serverTimestamps:     make(map[string]bool),
+		compressions:         make(map[string]redcloud.Compression),
+		bloomFilters:         make(map[string]*storage.BloomFilter),
+		memtables:            make(map[string]*storage.Memtable),
 		instance:             instance,
 		host:                 host,
 		port:                 port,
@@ -276,7 +348,14 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 	var endkey = string(ranges.EndKey)
 	var pathdescs map[string]*redcloud.SSTablePathDescription
 	var pathdesc *redcloud.SSTablePathDescription
+	var merged map[string]*redcloud.SSTablePathDescription
+	var obsolete []string
+	var created []string
+	var registered, uncertain bool
+	var loaded []*journalRecovery
+	var path string
 	var ok bool
+	var err error
 
 	ctx, span = trace.StartSpan(
 		parentCtx, "red-cloud.ServingRangeRegistry/LoadRange")
@@ -291,6 +370,63 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 			ranges.EndKey)
 	}
 
+	/*
+		If we have been handed the sstables of the tablets making up the
+		range, e.g. because they are being merged, combine them into one
+		set of sstables for the range.
+	*/
+	if len(ranges.Paths) > 0 {
+		var tableMd *redcloud.TableMetadata
+
+		if tableMd, err = reg.getTableMetadata(ctx, table); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Unable to fetch table metadata")
+			return err
+		}
+
+		if merged, obsolete, created, err = reg.mergePathDescriptions(
+			ctx, tableMd, table, ranges.EndKey, ranges.Paths); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error merging tablet sstables")
+			return err
+		}
+
+		/*
+			Unless the merged tablet gets registered, the sstables created
+			for it are of no use. The context may already have expired by
+			then. If it's unknown whether the metadata update went through,
+			the sstables may still be referenced and have to be kept.
+		*/
+		defer func() {
+			if registered {
+				return
+			}
+			for _, path = range created {
+				if uncertain {
+					log.Printf("Keeping sstable %s of possibly registered "+
+						"tablet %s", path, table)
+				} else {
+					removeSstable(context.Background(), path)
+				}
+			}
+		}()
+	}
+
+	/*
+		The journals of the newly loaded tablets are recovered once the
+		registry lock has been released again, since they may have to be read
+		completely. Until then, the tablets are locked against writes by
+		lockForRecovery. Deferred functions run in reverse order, so this has
+		to be deferred before the unlock.
+	*/
+	defer func() {
+		var recovery *journalRecovery
+
+		for _, recovery = range loaded {
+			reg.recoverJournals(ctx, table, ranges.EndKey, recovery)
+		}
+	}()
+
 	/*
 		Any loading of data should be done before taking this lock in order to
 		keep latency low.
@@ -351,31 +487,60 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 			get rewritten.
 		*/
 		reg.prefixes[table] = md.TableMd.PathPrefix
+		reg.splitSizes[table] = md.TableMd.SplitSize
+		reg.maxVersions[table] = md.TableMd.MaxVersions
+		reg.maxVersionAges[table] = md.TableMd.MaxVersionAge
+		reg.serverTimestamps[table] = md.TableMd.ServerTimestamps
+		reg.compressions[table] = md.TableMd.Compression
 
-		// Find an existing tablet matching the specified range.
-		for _, tabletMd = range md.Tablet {
-			// TODO: maybe care about overlap somehow?
-			if kr.Contains(tabletMd.StartKey) && (kr.Contains(tabletMd.EndKey) ||
-				bytes.Equal(tabletMd.EndKey, kr.EndKey)) {
+		if merged != nil {
+			var tablets []*redcloud.ServerTabletMetadata
+
+			// Replace all tablets within the range with a single one.
+			for _, tabletMd = range md.Tablet {
+				if !kr.ContainsRangeFully(common.NewKeyRange(
+					tabletMd.StartKey, tabletMd.EndKey)) {
+					tablets = append(tablets, tabletMd)
+				}
+			}
+
+			tabletMd = &redcloud.ServerTabletMetadata{
+				StartKey: kr.StartKey,
+				EndKey:   kr.EndKey,
+				Host:     reg.host,
+				Port:     int32(reg.port),
+			}
+			for _, pathdesc = range merged {
+				tabletMd.SstablePath = append(tabletMd.SstablePath, pathdesc)
+			}
+			md.Tablet = append(tablets, tabletMd)
+		} else {
+			// Find an existing tablet matching the specified range.
+			for _, tabletMd = range md.Tablet {
+				// TODO: maybe care about overlap somehow?
+				if kr.Contains(tabletMd.StartKey) &&
+					(kr.Contains(tabletMd.EndKey) ||
+						bytes.Equal(tabletMd.EndKey, kr.EndKey)) {
+					tabletMd.Host = reg.host
+					tabletMd.Port = int32(reg.port)
+					found = true
+				}
+			}
+
+			/*
+				Create a new metadata entry for the range which is apparently
+				not covered at this time.
+			*/
+			if !found {
+				tabletMd = new(redcloud.ServerTabletMetadata)
+				tabletMd.StartKey = kr.StartKey
+				tabletMd.EndKey = kr.EndKey
 				tabletMd.Host = reg.host
 				tabletMd.Port = int32(reg.port)
-				found = true
+				md.Tablet = append(md.Tablet, tabletMd)
 			}
 		}
 
-		/*
-			Create a new metadata entry for the range which is apparently
-			not covered at this time.
-		*/
-		if !found {
-			tabletMd = new(redcloud.ServerTabletMetadata)
-			tabletMd.StartKey = kr.StartKey
-			tabletMd.EndKey = kr.EndKey
-			tabletMd.Host = reg.host
-			tabletMd.Port = int32(reg.port)
-			md.Tablet = append(md.Tablet, tabletMd)
-		}
-
 		pathdescs = make(map[string]*redcloud.SSTablePathDescription)
 		for _, pathdesc = range tabletMd.SstablePath {
 			pathdescs[pathdesc.ColumnFamily] = pathdesc
@@ -384,11 +549,11 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 		// Add all missing column families to the metadata descriptor.
 		for _, cfmd = range md.TableMd.ColumnFamily {
 			if _, ok = pathdescs[cfmd.Name]; !ok {
-				tabletMd.SstablePath = append(
-					tabletMd.SstablePath,
-					&redcloud.SSTablePathDescription{
-						ColumnFamily: cfmd.Name,
-					})
+				pathdesc = &redcloud.SSTablePathDescription{
+					ColumnFamily: cfmd.Name,
+				}
+				tabletMd.SstablePath = append(tabletMd.SstablePath, pathdesc)
+				pathdescs[cfmd.Name] = pathdesc
 			}
 		}
 
@@ -405,15 +570,22 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 			log.Printf("Error committing update to %s: %s", etcdPath, err)
 			span.AddAttributes(trace.StringAttribute("error", err.Error()))
 			span.Annotate(nil, "Error committing metadata update")
+			uncertain = true
 			continue
 		}
 
 		// Successful update -> we're out.
 		if presp.Succeeded {
+			registered = true
 			break
 		}
 	}
 
+	// The sstables which were merged into new ones are no longer needed.
+	for _, path = range obsolete {
+		removeSstable(ctx, path)
+	}
+
 	// Make sure our data structures are complete.
 	if _, ok = reg.coveredRanges[table]; !ok {
 		reg.coveredRanges[table] = make([]*common.KeyRange, 0)
@@ -437,13 +609,26 @@ func (reg *ServingRangeRegistry) LoadRange(parentCtx context.Context,
 	}
 
 	for _, pathdesc = range pathdescs {
-		// Load the tablet for the specified end key.
-		reg.columnFamilies[table][endkey][pathdesc.ColumnFamily] = &sstableInfo{
+		var info = &sstableInfo{
 			Descriptor:        pathdesc,
 			Journal:           nil,
+			MinorSstableSize:  pathdesc.MinorSstableSize,
+			MajorSstableSize:  pathdesc.MajorSstableSize,
 			JournalCreateTime: time.Now(),
 			JournalLock:       fancylocking.NewMutexWithDeadline(),
+			LastTimestamp:     pathdesc.LastTimestamp,
 		}
+
+		// Load the tablet for the specified end key.
+		loaded = append(loaded, lockForRecovery(info))
+		reg.columnFamilies[table][endkey][pathdesc.ColumnFamily] = info
+
+		/*
+			Bloom filters are only an optimization, so load them in the
+			background instead of holding up the registry.
+		*/
+		go reg.loadBloomFilter(context.Background(), pathdesc.MajorSstablePath)
+		go reg.loadBloomFilter(context.Background(), pathdesc.MinorSstablePath)
 	}
 
 	return nil
@@ -459,11 +644,12 @@ func (reg *ServingRangeRegistry) UnloadRange(parentCtx context.Context,
 	var ctx context.Context
 	var span *trace.Span
 	var ncr []*common.KeyRange
-	var kr *common.KeyRange
+	var kr, released *common.KeyRange
 	var table = req.Table
 	var endkey = string(req.EndKey)
 	var cfs map[string]*sstableInfo
 	var sstp *sstableInfo
+	var path string
 	var ok bool
 
 	ctx, span = trace.StartSpan(
@@ -474,7 +660,7 @@ func (reg *ServingRangeRegistry) UnloadRange(parentCtx context.Context,
 		trace.StringAttribute("table", table))
 
 	resp = new(redcloud.RangeReleaseResponse)
-	if kr = common.NewKeyRange(req.StartKey, req.EndKey); kr != nil {
+	if released = common.NewKeyRange(req.StartKey, req.EndKey); released == nil {
 		span.Annotate(nil, "Invalid Key Range specified")
 		return resp, fmt.Errorf("Invalid key range: %v to %v", req.StartKey,
 			req.EndKey)
@@ -503,11 +689,17 @@ func (reg *ServingRangeRegistry) UnloadRange(parentCtx context.Context,
 	}
 
 	cfs = reg.columnFamilies[table][endkey]
+	for _, sstp = range cfs {
+		if sstp.Splitting {
+			reg.registryAccessLock.Unlock()
+			span.Annotate(nil, "Tablet being split")
+			return resp, common.ErrTabletSplitting
+		}
+	}
 	delete(reg.columnFamilies[table], endkey)
 
 	for _, kr = range reg.coveredRanges[table] {
-		if bytes.Compare(kr.EndKey, req.StartKey) <= 0 ||
-			bytes.Compare(kr.StartKey, req.EndKey) >= 0 {
+		if !released.ContainsRange(kr) {
 			ncr = append(ncr, kr)
 		}
 	}
@@ -515,7 +707,27 @@ func (reg *ServingRangeRegistry) UnloadRange(parentCtx context.Context,
 	reg.registryAccessLock.Unlock()
 
 	for _, sstp = range cfs {
+		/*
+			Wait for any log sorting or compaction still working on the
+			tablet so the descriptor we hand out is final.
+		*/
+		sstp.LogsortLock.Lock()
+		sstp.CompactionLock.Lock()
+		if sstp.LastTimestamp > sstp.Descriptor.LastTimestamp {
+			sstp.Descriptor.LastTimestamp = sstp.LastTimestamp
+		}
+		sstp.Descriptor.JournalSize = reg.journalSize(
+			sstp.Descriptor.RelevantJournalPaths)
 		resp.Paths = append(resp.Paths, sstp.Descriptor)
+		reg.dropBloomFilter(sstp.Descriptor.MajorSstablePath)
+		reg.dropBloomFilter(sstp.Descriptor.MinorSstablePath)
+		storage.InvalidateSstable(sstp.Descriptor.MajorSstablePath)
+		storage.InvalidateSstable(sstp.Descriptor.MinorSstablePath)
+		for _, path = range sstp.Descriptor.RelevantJournalPaths {
+			reg.dropMemtable(path)
+		}
+		sstp.CompactionLock.Unlock()
+		sstp.LogsortLock.Unlock()
 	}
 
 	return resp, nil
@@ -645,7 +857,10 @@ func (reg *ServingRangeRegistry) getInfoDescriptors(
 /*
 GetSSTablePathDescription looks up the SSTablePathDescriptions associated
 with the given table, column family and key range. If the triplet cannot be
-found and/or is not handled by this server, nil is returned.
+found and/or is not handled by this server, nil is returned. The caller is
+expected to hold a read lock on the registry, see Lock. It must not be taken
+again here: a writer waiting for the lock in between would block the second
+read lock, and thus itself.
 */
 func (reg *ServingRangeRegistry) GetSSTablePathDescription(
 	ctx context.Context, table, cf string, keyRange *common.KeyRange) (
@@ -655,13 +870,6 @@ func (reg *ServingRangeRegistry) GetSSTablePathDescription(
 	var info *sstableInfo
 	var err error
 
-	/*
-		The caller probably holds a read lock but we should probably protect
-		our access below for cleanliness.
-	*/
-	reg.registryAccessLock.RLock()
-	defer reg.registryAccessLock.RUnlock()
-
 	if infos, err = reg.getInfoDescriptors(
 		ctx, table, cf, keyRange); err != nil {
 		return nil, err
@@ -674,6 +882,75 @@ func (reg *ServingRangeRegistry) GetSSTablePathDescription(
 	return descs, nil
 }
 
+/*
+GetVersionLimits returns the maximum number of versions and the maximum age
+of older versions, in milliseconds, configured for the given table. A value
+of 0 means that the respective limit is not enforced.
+*/
+func (reg *ServingRangeRegistry) GetVersionLimits(table string) (
+	int64, int64) {
+	reg.registryAccessLock.RLock()
+	defer reg.registryAccessLock.RUnlock()
+
+	return reg.versionLimits(table)
+}
+
+/*
+versionLimits is the same as GetVersionLimits, for callers which already
+hold a read lock on the registry, see Lock.
+*/
+func (reg *ServingRangeRegistry) versionLimits(table string) (int64, int64) {
+	return reg.maxVersions[table], reg.maxVersionAges[table]
+}
+
+/*
+usesServerTimestamps determines whether inserts into the given table are
+configured to be assigned timestamps by the data node. The caller is
+expected to hold a read lock on the registry, see Lock; read locks must not
+be taken recursively, since a pending write lock would block the second one.
+*/
+func (reg *ServingRangeRegistry) usesServerTimestamps(table string) bool {
+	return reg.serverTimestamps[table]
+}
+
+/*
+GetCompression returns the compression configured for sstables written for
+the given table.
+*/
+func (reg *ServingRangeRegistry) GetCompression(
+	table string) redcloud.Compression {
+	reg.registryAccessLock.RLock()
+	defer reg.registryAccessLock.RUnlock()
+
+	return reg.compressions[table]
+}
+
+/*
+assignTimestamps reserves n consecutive timestamps for a write to the
+sstable ranges described by infos, in milliseconds since the epoch, and
+returns the first of them. Timestamps are taken from the clock reading
+"now", but are strictly increasing for each sstable range, so writes are
+ordered even if the clock goes back or is behind the one of the data node
+which served the tablet before. Expects the journal locks of all infos to
+be held.
+*/
+func assignTimestamps(infos []*sstableInfo, n int, now int64) int64 {
+	var first = now
+	var info *sstableInfo
+
+	for _, info = range infos {
+		if first <= info.LastTimestamp {
+			first = info.LastTimestamp + 1
+		}
+	}
+
+	for _, info = range infos {
+		info.LastTimestamp = first + int64(n) - 1
+	}
+
+	return first
+}
+
 /*
 internalCreateJournalWriter does the work of creating a new journal writer
 for the specified table, cf and key tuple and registers it. It assumes the
@@ -681,7 +958,7 @@ corresponding write locks are already held.
 */
 func (reg *ServingRangeRegistry) internalCreateJournalWriter(
 	ctx context.Context, table, cf string, key []byte, info *sstableInfo) (
-	*recordio.RecordWriter, error) {
+	*JournalWriter, error) {
 	var journalPath string
 	var journalFile filesystem.WriteCloser
 	var now = time.Now()
@@ -746,7 +1023,11 @@ func (reg *ServingRangeRegistry) internalCreateJournalWriter(
 		*/
 		reg.prefixes[table] = md.TableMd.PathPrefix
 
-		// Find an existing tablet containing the specified key.
+		/*
+			Find an existing tablet containing the specified key. All writes
+			to the previous journals have been assigned timestamps by now, so
+			the latest one is recorded for the next data node to continue.
+		*/
 		for _, tabletMd = range md.Tablet {
 			var kr = common.NewKeyRange(tabletMd.StartKey, tabletMd.EndKey)
 			if kr.Contains(key) {
@@ -754,6 +1035,11 @@ func (reg *ServingRangeRegistry) internalCreateJournalWriter(
 					if pathDescription.ColumnFamily == cf {
 						pathDescription.RelevantJournalPaths = append(
 							pathDescription.RelevantJournalPaths, journalPath)
+						pathDescription.JournalSize = reg.journalSize(
+							pathDescription.RelevantJournalPaths)
+						if info.LastTimestamp > pathDescription.LastTimestamp {
+							pathDescription.LastTimestamp = info.LastTimestamp
+						}
 						found = true
 					}
 				}
@@ -789,8 +1075,13 @@ func (reg *ServingRangeRegistry) internalCreateJournalWriter(
 
 	info.Descriptor.RelevantJournalPaths = append(
 		info.Descriptor.RelevantJournalPaths, journalPath)
+	info.Descriptor.JournalSize = reg.journalSize(
+		info.Descriptor.RelevantJournalPaths)
+	if info.LastTimestamp > info.Descriptor.LastTimestamp {
+		info.Descriptor.LastTimestamp = info.LastTimestamp
+	}
 
-	return recordio.NewRecordWriter(journalFile), nil
+	return reg.newJournalWriter(journalPath, journalFile), nil
 }
 
 /*
@@ -800,8 +1091,8 @@ file will be registered with red-cloud.
 */
 func (reg *ServingRangeRegistry) CreateJournalWriter(
 	ctx context.Context, table, cf string, key []byte) (
-	*recordio.RecordWriter, error) {
-	var writer *recordio.RecordWriter
+	*JournalWriter, error) {
+	var writer *JournalWriter
 	var info *sstableInfo
 	var err error
 
@@ -814,11 +1105,21 @@ func (reg *ServingRangeRegistry) CreateJournalWriter(
 	}
 	defer info.JournalLock.Unlock()
 
+	if info.Replaced {
+		return nil, common.ErrTabletNotLoaded
+	}
+
 	if writer, err = reg.internalCreateJournalWriter(
 		ctx, table, cf, key, info); err != nil {
 		return nil, err
 	}
 
+	if info.Journal != nil {
+		if err = info.Journal.Close(ctx); err != nil {
+			log.Printf("Error closing journal %s: %s", info.Journal.path, err)
+		}
+	}
+
 	info.Journal = writer
 	info.JournalNumUses = 0
 	info.JournalCreateTime = time.Now()
@@ -826,16 +1127,15 @@ func (reg *ServingRangeRegistry) CreateJournalWriter(
 }
 
 /*
-GetJournalWriter determines a currently open writer to the journal file of
-the specified sstable range and returns it. If no writer is currently open,
-a new journal file will be created and registered and a writer to that file
-will be returned. Therefore, it is expected that a write lock on the
-registry be held.
+lockJournal looks up the sstable range holding the specified key and locks
+its journal. Writes have to hold the journal lock from obtaining the journal
+writer until their records have been written, so the journal can't be
+rotated and flushed while a write to it is still in progress. The caller is
+expected to hold a read lock on the registry, and to release the journal
+lock once done.
 */
-func (reg *ServingRangeRegistry) GetJournalWriter(
-	ctx context.Context, table, cf string, key []byte) (
-	*recordio.RecordWriter, error) {
-	var writer *recordio.RecordWriter
+func (reg *ServingRangeRegistry) lockJournal(
+	ctx context.Context, table, cf string, key []byte) (*sstableInfo, error) {
 	var info *sstableInfo
 	var err error
 
@@ -846,18 +1146,52 @@ func (reg *ServingRangeRegistry) GetJournalWriter(
 	if !info.JournalLock.LockWithContext(ctx) {
 		return nil, ctx.Err()
 	}
-	defer info.JournalLock.Unlock()
 
-	if info.Journal != nil {
+	return info, nil
+}
+
+/*
+internalGetJournalWriter determines the currently open writer to the journal
+file of the sstable range described by info. If no writer is currently open,
+a new journal file will be created and registered and a writer to that file
+will be returned. The same happens if the memtable of the current journal
+is full, which is then flushed in the background. It assumes the journal
+lock of info is already held, see lockJournal.
+*/
+func (reg *ServingRangeRegistry) internalGetJournalWriter(
+	ctx context.Context, table, cf string, key []byte, info *sstableInfo) (
+	*JournalWriter, error) {
+	var writer *JournalWriter
+	var err error
+
+	if info.Replaced {
+		return nil, common.ErrTabletNotLoaded
+	}
+
+	if info.Journal != nil && !info.Journal.full() {
 		info.JournalNumUses++
 		return info.Journal, nil
 	}
 
 	if writer, err = reg.internalCreateJournalWriter(
 		ctx, table, cf, key, info); err != nil {
+		if info.Journal != nil {
+			// The full memtable just keeps growing until the next attempt.
+			log.Printf("Unable to replace full journal %s: %s",
+				info.Journal.path, err)
+			info.JournalNumUses++
+			return info.Journal, nil
+		}
 		return nil, err
 	}
 
+	if info.Journal != nil {
+		if err = info.Journal.Close(ctx); err != nil {
+			log.Printf("Error closing journal %s: %s", info.Journal.path, err)
+		}
+		go reg.flushFullMemtable(table, cf, key, info)
+	}
+
 	info.Journal = writer
 	info.JournalNumUses = 1
 	info.JournalCreateTime = time.Now()
@@ -924,12 +1258,13 @@ func (reg *ServingRangeRegistry) sortLogs(
 	parentCtx context.Context, table string, endKey []byte, cf string,
 	info *sstableInfo, wg *sync.WaitGroup) {
 	var paths []string
+	var unsorted []string
 	var childCtx context.Context
 	var ctx context.Context
 	var span *trace.Span
 	var path string
 	var started = time.Now()
-	var startedMs = int64(started.Nanosecond() / 1000000)
+	var startedMs = started.UnixNano() / 1000000
 	var err error
 
 	ctx, span = trace.StartSpan(
@@ -997,13 +1332,28 @@ func (reg *ServingRangeRegistry) sortLogs(
 		}
 	}
 
+	/*
+		Journals whose data is held in a memtable don't need to be sorted,
+		minorCompaction flushes the memtable to the minor sstable directly.
+	*/
 	for _, path = range paths {
+		if reg.GetMemtable(path) == nil {
+			unsorted = append(unsorted, path)
+		}
+	}
+
+	if len(unsorted) == 0 {
+		span.Annotate(nil, "All journals held in memtables")
+		return
+	}
+
+	for _, path = range unsorted {
 		var inurl, outurl *url.URL
 		var input filesystem.ReadCloser
 		var output filesystem.WriteCloser
 		var journalWriter *recordio.RecordWriter
 		var cfs ColumnFamilySlice
-		var columnFamily = new(redcloud.ColumnFamily)
+		var columnFamily *redcloud.ColumnFamily
 		var reader *recordio.RecordReader
 		var cancel context.CancelFunc
 		var i int
@@ -1029,6 +1379,7 @@ func (reg *ServingRangeRegistry) sortLogs(
 		reader = recordio.NewRecordReader(input)
 
 		for {
+			columnFamily = new(redcloud.ColumnFamily)
 			if err = reader.ReadMessage(ctx, columnFamily); err == io.EOF {
 				break
 			} else if err != nil {
@@ -1040,6 +1391,15 @@ func (reg *ServingRangeRegistry) sortLogs(
 				logSortsFailed.Inc()
 				return
 			}
+			if err = storage.VerifyChecksum(columnFamily); err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("path", path),
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Corrupted record in log")
+				log.Print("Corrupted record in ", path, ": ", err)
+				logSortsFailed.Inc()
+				return
+			}
 			cfs = append(cfs, columnFamily)
 		}
 
@@ -1048,31 +1408,8 @@ func (reg *ServingRangeRegistry) sortLogs(
 
 		// Merge all records touching the same key.
 		for i < len(cfs) {
-			var j int
-
 			// Eliminate all entries whose TTL has expired.
-			for j < len(cfs[i].ColumnSet) {
-				var cs = cfs[i].ColumnSet[j]
-				var k int
-
-				for k < len(cs.Column) {
-					var col = cs.Column[k]
-					if col.Ttl > 0 && col.Timestamp+col.Ttl < startedMs {
-						cs.Column = append(cs.Column[:k], cs.Column[k+1:]...)
-					} else {
-						k++
-					}
-				}
-
-				if len(cs.Column) == 0 {
-					cfs[i].ColumnSet = append(cfs[i].ColumnSet[:j],
-						cfs[i].ColumnSet[j+1:]...)
-				} else {
-					j++
-				}
-			}
-
-			if len(cfs[i].ColumnSet) == 0 {
+			if !storage.ExpireColumns(cfs[i], startedMs) {
 				// Remove now-empty write.
 				cfs = append(cfs[:i], cfs[i+1:]...)
 				continue
@@ -1089,8 +1426,7 @@ func (reg *ServingRangeRegistry) sortLogs(
 
 			if bytes.Equal(cfs[i].Key, cfs[i-1].Key) {
 				// Merge columns for the same row. Shorten list by 1.
-				cfs[i-1].ColumnSet = append(cfs[i-1].ColumnSet,
-					cfs[i].ColumnSet...)
+				storage.MergeColumnFamilies(cfs[i-1], cfs[i])
 				cfs = append(cfs[:i], cfs[i+1:]...)
 			} else {
 				// Go to next record.
@@ -1113,7 +1449,20 @@ func (reg *ServingRangeRegistry) sortLogs(
 		journalWriter = recordio.NewRecordWriter(output)
 
 		for _, columnFamily = range cfs {
-			if err = journalWriter.WriteMessage(ctx, columnFamily); err != nil {
+			var record *redcloud.ColumnFamily
+
+			if record, err = storage.ChecksumRecord(columnFamily); err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("path", outurl.String()),
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Unable to compute record checksum")
+				log.Print("Unable to compute checksum of record for ", outurl,
+					": ", err)
+				filesystem.Remove(ctx, outurl)
+				logSortsFailed.Inc()
+				return
+			}
+			if err = journalWriter.WriteMessage(ctx, record); err != nil {
 				span.AddAttributes(
 					trace.StringAttribute("path", outurl.String()),
 					trace.StringAttribute("error", err.Error()))
@@ -1289,79 +1638,174 @@ func (reg *ServingRangeRegistry) sortLogs(
 }
 
 /*
-mergeSstables does the actual merging of two sstable readers a and b
-into a new sstable out.
+writeCompactedRow writes the row data to the sstable block writer out,
+unless no data is left in the row, and records the key in the bloom filter
+and, if set, the key index of the sstable. Returns the size of the data
+written.
+*/
+func writeCompactedRow(ctx context.Context, out *storage.BlockWriter,
+	filter *storage.BloomFilterBuilder, keys *storage.KeyIndex,
+	data *redcloud.ColumnFamily, hasData bool) (int64, error) {
+	var size int64
+	var err error
+
+	if !hasData {
+		return 0, nil
+	}
+
+	if err = out.WriteProto(ctx, string(data.Key), data); err != nil {
+		return 0, err
+	}
+	size = int64(proto.Size(data))
+
+	filter.Add(data.Key)
+	if keys != nil {
+		keys.Add(data.Key, size)
+	}
 
-TODO: delete data which has expired (TTL) or is explicitly deleted.
+	return size, nil
+}
+
+/*
+compactMinorRow applies all the rules of a minor compaction to the row data:
+expired data is dropped and counter deltas are combined. Returns whether any
+data is left in the row.
+*/
+func compactMinorRow(data *redcloud.ColumnFamily, now int64) bool {
+	if !storage.ExpireColumns(data, now) {
+		return false
+	}
+
+	storage.CollapseCounters(data, false)
+	return true
+}
+
+/*
+compactMajorRow applies all the rules of a major compaction to the row data:
+expired data is dropped, the data covered by tombstones is removed along
+with all tombstones older than cutoff, counter deltas are combined and
+versions exceeding the version limits of the table are removed. Returns
+whether any data or tombstones are left in the row.
+*/
+func compactMajorRow(data *redcloud.ColumnFamily, now, cutoff, maxVersions,
+	maxVersionAge int64) bool {
+	if !storage.ExpireColumns(data, now) ||
+		!storage.PurgeTombstonesBefore(data, cutoff) {
+		return false
+	}
+
+	storage.CollapseCounters(data, false)
+	return storage.LimitVersions(data, maxVersions, maxVersionAge, now)
+}
+
+/*
+tombstoneCutoff determines the timestamp before which tombstones can be
+purged by a major compaction of the sstable range. All data in the journals
+of the range is newer than that, so it can't be covered by any of these
+tombstones. If the contents of a journal are unknown, no tombstones may be
+purged at all. Expects the journal lock to be held.
+*/
+func (reg *ServingRangeRegistry) tombstoneCutoff(info *sstableInfo) int64 {
+	var cutoff int64 = math.MaxInt64
+	var memtable *storage.Memtable
+	var path string
+	var oldest int64
+
+	for _, path = range info.Descriptor.RelevantJournalPaths {
+		if memtable = reg.GetMemtable(path); memtable == nil {
+			return math.MinInt64
+		}
+		if oldest = memtable.OldestTimestamp(); oldest > 0 &&
+			oldest < cutoff {
+			cutoff = oldest
+		}
+	}
+
+	return cutoff
+}
+
+/*
+mergeSstables does the actual merging of two sstable readers a and b
+into a new sstable out. Since this is a major compaction, data whose TTL
+has expired is dropped, the data covered by tombstones is removed along with
+the tombstones older than cutoff, counter deltas are combined, and only the
+versions within maxVersions and maxVersionAge are kept. All keys written are
+added to filter and keys.
 */
 func (reg *ServingRangeRegistry) mergeSstables(
-	ctx context.Context, a, b *sstable.Reader, out *sstable.Writer) (
-	int64, error) {
+	ctx context.Context, a, b *storage.BlockReader, out *storage.BlockWriter,
+	filter *storage.BloomFilterBuilder, keys *storage.KeyIndex,
+	cutoff, maxVersions, maxVersionAge int64) (int64, error) {
 	var aHasData, bHasData bool
-	var aKey, bKey string
 	var aData, bData redcloud.ColumnFamily
-	var size int64
+	var now = time.Now().UnixNano() / 1000000
+	var size, rowSize int64
 	var err error
 
 	// Fill in initial data.
-	if aKey, err = a.ReadNextProto(ctx, &aData); err != nil && err != io.EOF {
+	if _, err = a.ReadNextProto(ctx, &aData); err != nil && err != io.EOF {
 		return 0, err
 	} else if err != io.EOF {
 		aHasData = true
 	}
 
-	if bKey, err = b.ReadNextProto(ctx, &bData); err != nil && err != io.EOF {
+	if _, err = b.ReadNextProto(ctx, &bData); err != nil && err != io.EOF {
 		return 0, err
 	} else if err != io.EOF {
 		bHasData = true
 	}
 
 	for aHasData || bHasData {
+
 		if aHasData && bHasData && bytes.Equal(aData.Key, bData.Key) {
 			// If both sstables have data for the same key, merge it.
-			var columnSets = make(map[string]*redcloud.ColumnSet)
-			var cset *redcloud.ColumnSet
-
-			for _, cset = range append(aData.ColumnSet, bData.ColumnSet...) {
-				var ok bool
+			storage.MergeColumnFamilies(&aData, &bData)
+			if rowSize, err = writeCompactedRow(ctx, out, filter, keys,
+				&aData, compactMajorRow(&aData, now,
+					cutoff, maxVersions, maxVersionAge)); err != nil {
+				return 0, err
+			}
+			size += rowSize
 
-				if _, ok = columnSets[cset.Name]; ok {
-					columnSets[cset.Name].Column = append(
-						columnSets[cset.Name].Column, cset.Column...)
-				} else {
-					columnSets[cset.Name] = cset
-				}
+			aData.Reset()
+			if _, err = a.ReadNextProto(ctx, &aData); err == io.EOF {
+				aHasData = false
+			} else if err != nil {
+				return 0, err
 			}
 
-			// Treat data in a as original, fetch next result from b.
 			bData.Reset()
-			if bKey, err = b.ReadNextProto(ctx, &bData); err == io.EOF {
+			if _, err = b.ReadNextProto(ctx, &bData); err == io.EOF {
 				bHasData = false
 			} else if err != nil {
 				return 0, err
 			}
 		} else if !bHasData || (aHasData && bytes.Compare(aData.Key, bData.Key) < 0) {
 			// Data in a is next.
-			if err = out.WriteProto(ctx, aKey, &aData); err != nil {
+			if rowSize, err = writeCompactedRow(ctx, out, filter, keys,
+				&aData, compactMajorRow(&aData, now,
+					cutoff, maxVersions, maxVersionAge)); err != nil {
 				return 0, err
 			}
-			size += int64(proto.Size(&aData))
+			size += rowSize
 
 			aData.Reset()
-			if aKey, err = a.ReadNextProto(ctx, &aData); err == io.EOF {
+			if _, err = a.ReadNextProto(ctx, &aData); err == io.EOF {
 				aHasData = false
 			} else if err != nil {
 				return 0, err
 			}
 		} else if bHasData {
 			// Data in b is next.
-			if err = out.WriteProto(ctx, bKey, &bData); err != nil {
+			if rowSize, err = writeCompactedRow(ctx, out, filter, keys,
+				&bData, compactMajorRow(&bData, now,
+					cutoff, maxVersions, maxVersionAge)); err != nil {
 				return 0, err
 			}
-			size += int64(proto.Size(&bData))
+			size += rowSize
 
 			bData.Reset()
-			if bKey, err = b.ReadNextProto(ctx, &bData); err == io.EOF {
+			if _, err = b.ReadNextProto(ctx, &bData); err == io.EOF {
 				bHasData = false
 			} else if err != nil {
 				return 0, err
@@ -1369,21 +1813,38 @@ func (reg *ServingRangeRegistry) mergeSstables(
 		}
 	}
 
+	// Write out the rows of the last block.
+	if err = out.Flush(ctx); err != nil {
+		return 0, err
+	}
+
 	return size, nil
 }
 
 /*
-mergeLogsToSstable gets input from a sorted journal log and merges it with
-the data in the given sstable into a new sstable out.
+sortedJournalReader reads the records of a journal in key order, with all
+records for the same key combined. It is implemented both by readers of
+sorted journal files and by readers of memtables.
+*/
+type sortedJournalReader interface {
+	ReadMessage(ctx context.Context, msg proto.Message) error
+}
 
-TODO: delete data which has expired (TTL) or is explicitly deleted.
+/*
+mergeLogsToSstable gets input from a sorted journal log and merges it with
+the data in the given sstable into a new sstable out. Data whose TTL has
+expired is dropped and counter deltas are combined. Tombstones are retained
+since they may still cover data in the major sstable. All keys written are
+added to filter.
 */
 func (reg *ServingRangeRegistry) mergeLogsToSstable(
-	ctx context.Context, a *sstable.Reader, b *recordio.RecordReader,
-	out *sstable.Writer) (int64, error) {
+	ctx context.Context, a *storage.BlockReader, b sortedJournalReader,
+	out *storage.BlockWriter, filter *storage.BloomFilterBuilder) (
+	int64, error) {
 	var aHasData, bHasData bool
 	var aData, bData redcloud.ColumnFamily
-	var size int64
+	var now = time.Now().UnixNano() / 1000000
+	var size, rowSize int64
 	var err error
 
 	// Fill in initial data.
@@ -1400,23 +1861,36 @@ func (reg *ServingRangeRegistry) mergeLogsToSstable(
 	}
 
 	for aHasData || bHasData {
-		if aHasData && bHasData && bytes.Equal(aData.Key, bData.Key) {
-			// If both sstables have data for the same key, merge it.
-			var columnSets = make(map[string]*redcloud.ColumnSet)
-			var cset *redcloud.ColumnSet
+		/*
+			Rows from the sstable have been verified along with their block,
+			journal records carry a checksum of their own. Corrupted records
+			must not be written to the new sstable, where they would be
+			protected by a new, valid checksum.
+		*/
+		if err = storage.VerifyChecksum(&bData); err != nil {
+			return 0, err
+		}
 
-			for _, cset = range append(aData.ColumnSet, bData.ColumnSet...) {
-				var ok bool
+		if aHasData && bHasData && bytes.Equal(aData.Key, bData.Key) {
+			/*
+				If both have data for the same key, merge it. Log sorting
+				has already combined all records for the same key, so there
+				won't be any more data for this key in b.
+			*/
+			storage.MergeColumnFamilies(&aData, &bData)
+			if rowSize, err = writeCompactedRow(ctx, out, filter, nil,
+				&aData, compactMinorRow(&aData, now)); err != nil {
+				return 0, err
+			}
+			size += rowSize
 
-				if _, ok = columnSets[cset.Name]; ok {
-					columnSets[cset.Name].Column = append(
-						columnSets[cset.Name].Column, cset.Column...)
-				} else {
-					columnSets[cset.Name] = cset
-				}
+			aData.Reset()
+			if _, err = a.ReadNextProto(ctx, &aData); err == io.EOF {
+				aHasData = false
+			} else if err != nil {
+				return 0, err
 			}
 
-			// Treat data in a as original, fetch next result from b.
 			bData.Reset()
 			if err = b.ReadMessage(ctx, &bData); err == io.EOF {
 				bHasData = false
@@ -1425,11 +1899,11 @@ func (reg *ServingRangeRegistry) mergeLogsToSstable(
 			}
 		} else if !bHasData || (aHasData && bytes.Compare(aData.Key, bData.Key) < 0) {
 			// Data in a is next.
-			if err = out.WriteProto(
-				ctx, string(aData.Key), &aData); err != nil {
+			if rowSize, err = writeCompactedRow(ctx, out, filter, nil,
+				&aData, compactMinorRow(&aData, now)); err != nil {
 				return 0, err
 			}
-			size += int64(proto.Size(&aData))
+			size += rowSize
 
 			aData.Reset()
 			if _, err = a.ReadNextProto(ctx, &aData); err == io.EOF {
@@ -1439,11 +1913,11 @@ func (reg *ServingRangeRegistry) mergeLogsToSstable(
 			}
 		} else if bHasData {
 			// Data in b is next.
-			err = out.WriteProto(ctx, string(bData.Key), &bData)
-			if err != nil {
+			if rowSize, err = writeCompactedRow(ctx, out, filter, nil,
+				&bData, compactMinorRow(&bData, now)); err != nil {
 				return 0, err
 			}
-			size += int64(proto.Size(&bData))
+			size += rowSize
 
 			bData.Reset()
 			if err = b.ReadMessage(ctx, &bData); err == io.EOF {
@@ -1454,6 +1928,11 @@ func (reg *ServingRangeRegistry) mergeLogsToSstable(
 		}
 	}
 
+	// Write out the rows of the last block.
+	if err = out.Flush(ctx); err != nil {
+		return 0, err
+	}
+
 	return size, nil
 }
 
@@ -1468,10 +1947,15 @@ func (reg *ServingRangeRegistry) majorCompaction(
 	var span *trace.Span
 	var started = time.Now()
 	var sstPath string
-	var a, b *sstable.Reader
+	var a, b *storage.BlockReader
 	var usst, uidx *url.URL
-	var out *sstable.Writer
+	var out *storage.BlockWriter
 	var outsst, outidx filesystem.WriteCloser
+	var cutoff, maxVersions, maxVersionAge int64
+	var filter = storage.NewBloomFilterBuilder()
+	var keys = storage.NewKeyIndex()
+	var bloom *storage.BloomFilter
+	var origMajorPath, origMinorPath string
 	var size int64
 	var err error
 
@@ -1543,8 +2027,8 @@ func (reg *ServingRangeRegistry) majorCompaction(
 			return
 		}
 
-		out = sstable.NewIndexedWriter(ctx, outsst, outidx,
-			sstable.IndexType_EVERY_N, 32)
+		out = storage.NewBlockWriter(
+			ctx, outsst, outidx, reg.GetCompression(table))
 
 		if origMinorSst, err = url.Parse(
 			info.Descriptor.MinorSstablePath + ".sst"); err != nil {
@@ -1582,7 +2066,7 @@ func (reg *ServingRangeRegistry) majorCompaction(
 		}
 		defer ssta.Close(ctx)
 
-		a = sstable.NewReader(ssta)
+		a = storage.NewBlockReader(sstable.NewReader(ssta))
 
 		if len(info.Descriptor.MajorSstablePath) > 0 {
 			if origMajorSst, err = url.Parse(
@@ -1623,12 +2107,32 @@ func (reg *ServingRangeRegistry) majorCompaction(
 			}
 			defer sstb.Close(ctx)
 
-			b = sstable.NewReader(sstb)
+			b = storage.NewBlockReader(sstable.NewReader(sstb))
 		} else {
-			b = sstable.NewReader(internal.NewAnonymousFile())
+			b = storage.NewBlockReader(
+				sstable.NewReader(internal.NewAnonymousFile()))
+		}
+
+		/*
+			The journals aren't part of the compaction, so tombstones which
+			may still cover data in them have to be kept.
+		*/
+		if !info.JournalLock.LockWithContext(ctx) {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			log.Print("Context expired while merging sstable")
+			majorCompactionsFailed.Inc()
+			filesystem.Remove(ctx, usst)
+			filesystem.Remove(ctx, uidx)
+			return
 		}
+		cutoff = reg.tombstoneCutoff(info)
+		info.JournalLock.Unlock()
 
-		if size, err = reg.mergeSstables(ctx, a, b, out); err != nil {
+		maxVersions, maxVersionAge = reg.GetVersionLimits(table)
+		if size, err = reg.mergeSstables(ctx, a, b, out, filter, keys,
+			cutoff, maxVersions, maxVersionAge); err != nil {
 			span.AddAttributes(trace.StringAttribute("error", err.Error()))
 			span.Annotate(nil, "Error merging minor and major sstables")
 			log.Printf("Error sorting sstable %s: %s", sstPath, err)
@@ -1762,6 +2266,8 @@ func (reg *ServingRangeRegistry) majorCompaction(
 		}
 
 		// Update our internal account of the sstable paths.
+		origMajorPath = info.Descriptor.MajorSstablePath
+		origMinorPath = info.Descriptor.MinorSstablePath
 		info.Descriptor.MajorSstablePath = sstPath
 		info.Descriptor.MinorSstablePath = ""
 		info.MajorSstableSize = size
@@ -1769,6 +2275,26 @@ func (reg *ServingRangeRegistry) majorCompaction(
 
 		info.JournalLock.Unlock()
 
+		/*
+			Lookups just read the sstable if there is no bloom filter for it,
+			so failing to write the filter isn't fatal.
+		*/
+		bloom = filter.Build()
+		reg.setBloomFilter(sstPath, bloom)
+		if err = storage.WriteBloomFilter(ctx, sstPath, bloom); err != nil {
+			log.Printf("Error writing bloom filter for %s: %s", sstPath, err)
+		}
+		reg.removeBloomFilter(ctx, origMajorPath)
+		reg.removeBloomFilter(ctx, origMinorPath)
+
+		// Without a key index, splits read the whole sstable instead.
+		if err = storage.WriteKeyIndex(ctx, sstPath, keys); err != nil {
+			log.Printf("Error writing key index for %s: %s", sstPath, err)
+		}
+		removeKeyIndex(ctx, origMajorPath)
+		storage.InvalidateSstable(origMajorPath)
+		storage.InvalidateSstable(origMinorPath)
+
 		if origMajorSst != nil {
 			filesystem.Remove(ctx, origMajorSst)
 		}
@@ -1787,6 +2313,8 @@ func (reg *ServingRangeRegistry) majorCompaction(
 /*
 minorCompaction runs a major compaction on two sorted recordio files to
 produce a third one which will be registered as the official minor sstable.
+Journals whose data is held in a memtable are flushed from the memtable
+once writes have moved on to a newer journal, without sorting them first.
 */
 func (reg *ServingRangeRegistry) minorCompaction(
 	parentCtx context.Context, table string, endKey []byte, cf string,
@@ -1795,10 +2323,10 @@ func (reg *ServingRangeRegistry) minorCompaction(
 	var span *trace.Span
 	var started = time.Now()
 	var sstPath string
-	var a *sstable.Reader
-	var b *recordio.RecordReader
+	var a *storage.BlockReader
+	var b sortedJournalReader
 	var usst, uidx *url.URL
-	var out *sstable.Writer
+	var out *storage.BlockWriter
 	var outsst, outidx filesystem.WriteCloser
 	var size int64
 	var err error
@@ -1808,6 +2336,8 @@ func (reg *ServingRangeRegistry) minorCompaction(
 	var sortedLogPaths []string
 	var sortedLogPath string
 	var sortedLogU *url.URL
+	var bloom *storage.BloomFilter
+	var origMinorPath string
 
 	ctx, span = trace.StartSpan(
 		parentCtx, "red-cloud.ServingRangeRegistry/minorCompaction")
@@ -1828,11 +2358,28 @@ func (reg *ServingRangeRegistry) minorCompaction(
 	defer minorCompactionsInProgress.Add(-1)
 	defer wg.Done()
 
+	if !info.JournalLock.LockWithContext(ctx) {
+		span.Annotate(nil, "Context expired")
+		return
+	}
 	for _, p = range info.Descriptor.RelevantJournalPaths {
-		if strings.HasSuffix(p, ".sorted") {
+		var memtable = reg.GetMemtable(p)
+
+		/*
+			Memtables of the current journal still receive writes, so they
+			can only be flushed after the journal has been rotated. They are
+			frozen while holding the journal lock, so no write is still
+			in progress.
+		*/
+		if memtable != nil &&
+			(info.Journal == nil || info.Journal.path != p) {
+			memtable.Freeze()
+			sortedLogPaths = append(sortedLogPaths, p)
+		} else if strings.HasSuffix(p, ".sorted") {
 			sortedLogPaths = append(sortedLogPaths, p)
 		}
 	}
+	info.JournalLock.Unlock()
 
 	// Wait for logsorting to give us some input.
 	if len(sortedLogPaths) == 0 {
@@ -1844,6 +2391,8 @@ func (reg *ServingRangeRegistry) minorCompaction(
 		trace.Int64Attribute("num-sorted-logs-input", int64(len(sortedLogPath))))
 
 	for _, sortedLogPath = range sortedLogPaths {
+		var filter = storage.NewBloomFilterBuilder()
+		var memtable *storage.Memtable
 		var i int
 		var p string
 
@@ -1887,9 +2436,10 @@ func (reg *ServingRangeRegistry) minorCompaction(
 			}
 			defer ssta.Close(ctx)
 
-			a = sstable.NewReader(ssta)
+			a = storage.NewBlockReader(sstable.NewReader(ssta))
 		} else {
-			a = sstable.NewReader(internal.NewAnonymousFile())
+			a = storage.NewBlockReader(
+				sstable.NewReader(internal.NewAnonymousFile()))
 		}
 
 		if sortedLogU, err = url.Parse(sortedLogPath); err != nil {
@@ -1903,20 +2453,25 @@ func (reg *ServingRangeRegistry) minorCompaction(
 			filesystem.Remove(ctx, uidx)
 			return
 		}
-		if sstb, err = filesystem.OpenReader(ctx, sortedLogU); err != nil {
-			span.AddAttributes(
-				trace.StringAttribute("error", err.Error()),
-				trace.StringAttribute("path", sortedLogU.String()))
-			span.Annotate(nil, "Error generating sorted lag URL")
-			log.Printf("Error opening log %s: %s", sortedLogPath, err)
-			minorCompactionsFailed.Inc()
-			filesystem.Remove(ctx, usst)
-			filesystem.Remove(ctx, uidx)
-			return
-		}
-		defer sstb.Close(ctx)
 
-		b = recordio.NewRecordReader(sstb)
+		if memtable = reg.GetMemtable(sortedLogPath); memtable != nil {
+			b = memtable.NewReader()
+		} else {
+			if sstb, err = filesystem.OpenReader(ctx, sortedLogU); err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("error", err.Error()),
+					trace.StringAttribute("path", sortedLogU.String()))
+				span.Annotate(nil, "Error generating sorted lag URL")
+				log.Printf("Error opening log %s: %s", sortedLogPath, err)
+				minorCompactionsFailed.Inc()
+				filesystem.Remove(ctx, usst)
+				filesystem.Remove(ctx, uidx)
+				return
+			}
+			defer sstb.Close(ctx)
+
+			b = recordio.NewRecordReader(sstb)
+		}
 
 		sstPath = fmt.Sprintf("%s/%s", reg.prefixes[table], storage.MakePath(
 			reg.instance, table, cf, endKey, time.Now(),
@@ -1969,10 +2524,11 @@ func (reg *ServingRangeRegistry) minorCompaction(
 			return
 		}
 
-		out = sstable.NewIndexedWriter(ctx, outsst, outidx,
-			sstable.IndexType_EVERY_N, 32)
+		out = storage.NewBlockWriter(
+			ctx, outsst, outidx, reg.GetCompression(table))
 
-		if size, err = reg.mergeLogsToSstable(ctx, a, b, out); err != nil {
+		if size, err = reg.mergeLogsToSstable(
+			ctx, a, b, out, filter); err != nil {
 			span.AddAttributes(
 				trace.StringAttribute("prefix", sstPath),
 				trace.StringAttribute("error", err.Error()))
@@ -2079,6 +2635,8 @@ func (reg *ServingRangeRegistry) minorCompaction(
 										pathDescription.RelevantJournalPaths[idx+1:]...)
 								}
 							}
+							pathDescription.JournalSize = reg.journalSize(
+								pathDescription.RelevantJournalPaths)
 						}
 					}
 				}
@@ -2118,6 +2676,7 @@ func (reg *ServingRangeRegistry) minorCompaction(
 		}
 
 		// Update our internal account of the journal list.
+		origMinorPath = info.Descriptor.MinorSstablePath
 		info.Descriptor.MinorSstablePath = sstPath
 		info.MinorSstableSize = size
 		for i, p = range info.Descriptor.RelevantJournalPaths {
@@ -2127,9 +2686,24 @@ func (reg *ServingRangeRegistry) minorCompaction(
 					info.Descriptor.RelevantJournalPaths[i+1:]...)
 			}
 		}
+		info.Descriptor.JournalSize = reg.journalSize(
+			info.Descriptor.RelevantJournalPaths)
 
 		info.JournalLock.Unlock()
 
+		/*
+			Lookups just read the sstable if there is no bloom filter for it,
+			so failing to write the filter isn't fatal.
+		*/
+		bloom = filter.Build()
+		reg.setBloomFilter(sstPath, bloom)
+		if err = storage.WriteBloomFilter(ctx, sstPath, bloom); err != nil {
+			log.Printf("Error writing bloom filter for %s: %s", sstPath, err)
+		}
+		reg.removeBloomFilter(ctx, origMinorPath)
+		storage.InvalidateSstable(origMinorPath)
+		reg.dropMemtable(sortedLogPath)
+
 		if origMinorSst != nil {
 			filesystem.Remove(ctx, origMinorSst)
 		}
@@ -2242,6 +2816,9 @@ func (reg *ServingRangeRegistry) findSortableLogs() {
 		if weThinkAreRunning > 0 {
 			wg.Wait()
 		}
+
+		// Split all tablets which have grown too large in the process.
+		reg.splitOversizedTablets(ctx)
 		span.End()
 
 		// Wait until we should run for the next time.
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAssignTimestamps(t *testing.T) {
+	var cases = []struct {
+		name  string
+		last  []int64
+		n     int
+		now   int64
+		first int64
+	}{
+		{"clock ahead", []int64{50}, 1, 100, 100},
+		{"clock behind", []int64{200}, 1, 100, 201},
+		{"same millisecond", []int64{100}, 1, 100, 101},
+		{"multiple", []int64{0}, 3, 100, 100},
+		{"multiple ranges", []int64{50, 300}, 2, 100, 301},
+	}
+	var i int
+
+	for i = range cases {
+		var infos []*sstableInfo
+		var info *sstableInfo
+		var last int64
+		var first int64
+
+		for _, last = range cases[i].last {
+			infos = append(infos, &sstableInfo{LastTimestamp: last})
+		}
+
+		if first = assignTimestamps(
+			infos, cases[i].n, cases[i].now); first != cases[i].first {
+			t.Errorf("%s: unexpected first timestamp: got %d, want %d",
+				cases[i].name, first, cases[i].first)
+		}
+
+		for _, info = range infos {
+			if info.LastTimestamp != first+int64(cases[i].n)-1 {
+				t.Errorf("%s: unexpected last timestamp %d after %d from %d",
+					cases[i].name, info.LastTimestamp, cases[i].n, first)
+			}
+		}
+	}
+}
+
+func TestAssignTimestampsMonotonic(t *testing.T) {
+	var info = new(sstableInfo)
+	var clock = []int64{100, 100, 90, 150, 149, 150}
+	var now, timestamp, previous int64
+
+	for _, now = range clock {
+		if timestamp = assignTimestamps(
+			[]*sstableInfo{info}, 1, now); timestamp <= previous {
+			t.Errorf("Timestamp %d at %d not after %d", timestamp, now,
+				previous)
+		}
+		previous = timestamp
+	}
+}
+
+func TestReplaceJournalPath(t *testing.T) {
+	var cases = []struct {
+		name     string
+		paths    []string
+		expected []string
+		found    bool
+	}{
+		{"present", []string{"a", "torn", "b"}, []string{"a", "new", "b"},
+			true},
+		{"absent", []string{"a", "b"}, []string{"a", "b"}, false},
+		{"empty", nil, []string{}, false},
+	}
+	var i int
+
+	for i = range cases {
+		var paths []string
+		var orig = append([]string{}, cases[i].paths...)
+		var found bool
+
+		paths, found = replaceJournalPath(cases[i].paths, "torn", "new")
+		if fmt.Sprint(paths) != fmt.Sprint(cases[i].expected) ||
+			found != cases[i].found {
+			t.Errorf("%s: got %v, %v; want %v, %v", cases[i].name, paths,
+				found, cases[i].expected, cases[i].found)
+		}
+		if fmt.Sprint(cases[i].paths) != fmt.Sprint(orig) {
+			t.Errorf("%s: original paths modified: %v", cases[i].name,
+				cases[i].paths)
+		}
+	}
+}
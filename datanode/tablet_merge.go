@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/childoftheuniverse/sstable"
+	"github.com/golang/protobuf/proto"
+	etcd "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+/*
+concatSstables copies the records of all sstables at the specified paths,
+in order, into a new sstable at outPath, compressed with the specified
+compression. The input sstables must cover disjoint key ranges and be
+ordered by key, so the output will be sorted. If keys is set, all records
+are added to it. Returns the size of the data written. If an error occurs,
+the partially written sstable is removed.
+*/
+func concatSstables(ctx context.Context, paths []string, outPath string,
+	compression redcloud.Compression, keys *storage.KeyIndex) (int64, error) {
+	var usst, uidx *url.URL
+	var out *storage.BlockWriter
+	var outsst, outidx filesystem.WriteCloser
+	var size int64
+	var closeErr error
+	var err error
+
+	if usst, err = url.Parse(outPath + ".sst"); err != nil {
+		return 0, err
+	}
+	if uidx, err = url.Parse(outPath + ".idx"); err != nil {
+		return 0, err
+	}
+
+	if outsst, err = filesystem.OpenWriter(ctx, usst); err != nil {
+		return 0, err
+	}
+	if outidx, err = filesystem.OpenWriter(ctx, uidx); err != nil {
+		outsst.Close(ctx)
+		removeSstable(ctx, outPath)
+		return 0, err
+	}
+
+	out = storage.NewBlockWriter(ctx, outsst, outidx, compression)
+	if size, err = copySstables(ctx, paths, out, keys); err == nil {
+		err = out.Flush(ctx)
+	}
+
+	// Both files have to be closed, even if writing or closing one failed.
+	if closeErr = outsst.Close(ctx); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if closeErr = outidx.Close(ctx); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		removeSstable(ctx, outPath)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+/*
+copySstables copies the records of all sstables at the specified paths, in
+order, to out. If keys is set, all records are added to it. Returns the
+size of the data written.
+*/
+func copySstables(ctx context.Context, paths []string,
+	out sstableRecordWriter, keys *storage.KeyIndex) (int64, error) {
+	var path string
+	var size int64
+	var err error
+
+	for _, path = range paths {
+		var u *url.URL
+		var sst filesystem.ReadCloser
+		var reader *storage.BlockReader
+
+		if u, err = url.Parse(path + ".sst"); err != nil {
+			return 0, err
+		}
+		if sst, err = filesystem.OpenReader(ctx, u); err != nil {
+			return 0, err
+		}
+
+		reader = storage.NewBlockReader(sstable.NewReader(sst))
+
+		for {
+			var data redcloud.ColumnFamily
+			var key string
+
+			if key, err = reader.ReadNextProto(ctx, &data); err == io.EOF {
+				break
+			} else if err != nil {
+				sst.Close(ctx)
+				return 0, err
+			}
+
+			if err = out.WriteProto(ctx, key, &data); err != nil {
+				sst.Close(ctx)
+				return 0, err
+			}
+			if keys != nil {
+				keys.Add([]byte(key), int64(proto.Size(&data)))
+			}
+			size += int64(proto.Size(&data))
+		}
+
+		sst.Close(ctx)
+	}
+
+	return size, nil
+}
+
+/*
+mergePathDescriptions combines the SSTablePathDescriptions of a number of
+adjacent tablets into a single SSTablePathDescription per column family,
+for use by the tablet of the specified table ending at endKey. The
+descriptions must be ordered by the key ranges of their tablets.
+
+Where more than one major or minor sstable exists for a column family, they
+are concatenated into a new one, written with the path prefix and
+compression from tableMd. Journals are simply carried over. The
+merged descriptions are returned along with the paths of the sstables which
+are no longer needed once the merged tablet has been registered, and the
+paths of the newly created sstables, which have to be removed again if the
+merged tablet can't be registered.
+*/
+func (reg *ServingRangeRegistry) mergePathDescriptions(
+	ctx context.Context, tableMd *redcloud.TableMetadata, table string,
+	endKey []byte, descs []*redcloud.SSTablePathDescription) (
+	map[string]*redcloud.SSTablePathDescription, []string, []string,
+	error) {
+	var rv = make(map[string]*redcloud.SSTablePathDescription)
+	var majors = make(map[string][]string)
+	var minors = make(map[string][]string)
+	var obsolete []string
+	var created []string
+	var desc, merged *redcloud.SSTablePathDescription
+	var now = time.Now()
+	var cf string
+	var ok bool
+	var err error
+
+	for _, desc = range descs {
+		if merged, ok = rv[desc.ColumnFamily]; !ok {
+			merged = &redcloud.SSTablePathDescription{
+				ColumnFamily: desc.ColumnFamily,
+			}
+			rv[desc.ColumnFamily] = merged
+		}
+
+		if len(desc.MajorSstablePath) > 0 {
+			majors[desc.ColumnFamily] = append(
+				majors[desc.ColumnFamily], desc.MajorSstablePath)
+			merged.MajorSstablePath = desc.MajorSstablePath
+		}
+		if len(desc.MinorSstablePath) > 0 {
+			minors[desc.ColumnFamily] = append(
+				minors[desc.ColumnFamily], desc.MinorSstablePath)
+			merged.MinorSstablePath = desc.MinorSstablePath
+		}
+		merged.MajorSstableSize += desc.MajorSstableSize
+		merged.MinorSstableSize += desc.MinorSstableSize
+		merged.JournalSize += desc.JournalSize
+		merged.RelevantJournalPaths = append(merged.RelevantJournalPaths,
+			desc.RelevantJournalPaths...)
+		if desc.LastTimestamp > merged.LastTimestamp {
+			merged.LastTimestamp = desc.LastTimestamp
+		}
+	}
+
+	for cf, merged = range rv {
+		var levels = map[storage.SSTableLevel][]string{
+			storage.SSTableLevelMAJOR: majors[cf],
+			storage.SSTableLevelMINOR: minors[cf],
+		}
+		var level storage.SSTableLevel
+		var paths []string
+
+		for level, paths = range levels {
+			var keys *storage.KeyIndex
+			var outPath string
+			var size int64
+
+			// A single sstable can be used as it is.
+			if len(paths) < 2 {
+				continue
+			}
+
+			outPath = fmt.Sprintf("%s/%s", tableMd.PathPrefix, storage.MakePath(
+				reg.instance, table, cf, endKey, now, level))
+
+			if level == storage.SSTableLevelMAJOR {
+				keys = storage.NewKeyIndex()
+			}
+
+			// concatSstables removes its own output if it fails.
+			if size, err = concatSstables(
+				ctx, paths, outPath, tableMd.Compression, keys); err != nil {
+				for _, outPath = range created {
+					removeSstable(ctx, outPath)
+				}
+				return nil, nil, nil, err
+			}
+			created = append(created, outPath)
+
+			// Without a key index, splits read the whole sstable instead.
+			if keys != nil {
+				if err = storage.WriteKeyIndex(ctx, outPath, keys); err != nil {
+					log.Printf("Error writing key index for %s: %s", outPath, err)
+				}
+			}
+
+			if level == storage.SSTableLevelMAJOR {
+				merged.MajorSstablePath = outPath
+				merged.MajorSstableSize = size
+			} else {
+				merged.MinorSstablePath = outPath
+				merged.MinorSstableSize = size
+			}
+			obsolete = append(obsolete, paths...)
+		}
+	}
+
+	return rv, obsolete, created, nil
+}
+
+/*
+getTableMetadata fetches the current configuration of the specified table,
+e.g. its path prefix, from etcd.
+*/
+func (reg *ServingRangeRegistry) getTableMetadata(
+	ctx context.Context, table string) (*redcloud.TableMetadata, error) {
+	var etcdPath = common.EtcdTableConfigPath(reg.instance, table)
+	var md redcloud.ServerTableMetadata
+	var gresp *etcd.GetResponse
+	var ev *mvccpb.KeyValue
+	var err error
+
+	if gresp, err = reg.etcdClient.Get(
+		ctx, etcdPath, etcd.WithLimit(1)); err != nil {
+		return nil, err
+	}
+
+	for _, ev = range gresp.Kvs {
+		if err = proto.Unmarshal(ev.Value, &md); err != nil {
+			return nil, fmt.Errorf(
+				"Unable to parse %s as ServerTableMetadata protobuf at version %d",
+				etcdPath, ev.Version)
+		}
+	}
+
+	if md.TableMd == nil {
+		return nil, fmt.Errorf("No metadata found for table %s", table)
+	}
+
+	return md.TableMd, nil
+}
@@ -0,0 +1,1229 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"context"
+	"github.com/childoftheuniverse/fancylocking"
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/recordio"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/childoftheuniverse/sstable"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	etcd "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.opencensus.io/trace"
+)
+
+var tabletSplitsInProgress = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "tablet_splits_in_progress",
+	Help:      "Number of tablet splits currently in progress.",
+})
+var tabletSplitsDone = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_tablet_splits_done",
+	Help:      "Cumulative number of tablet splits which have been done.",
+})
+var tabletSplitsFailed = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "num_tablet_splits_failed",
+	Help:      "Cumulative number of tablet splits which have failed.",
+})
+var tabletSplitLatency = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "red_cloud",
+	Subsystem: "range_registry",
+	Name:      "tablet_split_latency",
+	Help:      "Latency of tablet split operations (in seconds).",
+})
+
+func init() {
+	prometheus.MustRegister(tabletSplitsInProgress)
+	prometheus.MustRegister(tabletSplitsDone)
+	prometheus.MustRegister(tabletSplitsFailed)
+	prometheus.MustRegister(tabletSplitLatency)
+}
+
+/*
+splitMetadataTimeout limits the time a tablet split may spend replacing the
+metadata of the tablet in etcd, during which writes to the tablet are
+blocked.
+*/
+const splitMetadataTimeout = 30 * time.Second
+
+/*
+splitCandidate describes a tablet which has outgrown the split size
+configured for its table.
+*/
+type splitCandidate struct {
+	Table  string
+	EndKey []byte
+	Size   int64
+}
+
+/*
+findSplitCandidates determines all tablets whose combined journal, minor and
+major sstable sizes across all column families exceed the split size of the
+table they belong to.
+*/
+func (reg *ServingRangeRegistry) findSplitCandidates() []*splitCandidate {
+	var candidates []*splitCandidate
+	var table string
+	var tablets map[string]map[string]*sstableInfo
+
+	reg.registryAccessLock.RLock()
+	defer reg.registryAccessLock.RUnlock()
+
+	for table, tablets = range reg.columnFamilies {
+		var splitSize = reg.splitSizes[table]
+		var cfs map[string]*sstableInfo
+		var endKey string
+
+		// Tables without a configured split size are never split.
+		if splitSize <= 0 {
+			continue
+		}
+
+		for endKey, cfs = range tablets {
+			var info *sstableInfo
+			var size int64
+
+			for _, info = range cfs {
+				size += info.JournalSize + info.MinorSstableSize +
+					info.MajorSstableSize
+			}
+
+			if size > splitSize {
+				candidates = append(candidates, &splitCandidate{
+					Table:  table,
+					EndKey: []byte(endKey),
+					Size:   size,
+				})
+			}
+		}
+	}
+
+	return candidates
+}
+
+/*
+splitOversizedTablets splits all tablets which have grown beyond the split
+size of their table into two. This is supposed to run after the major
+compactions so the split works mostly on major sstables.
+*/
+func (reg *ServingRangeRegistry) splitOversizedTablets(ctx context.Context) {
+	var candidate *splitCandidate
+	var err error
+
+	for _, candidate = range reg.findSplitCandidates() {
+		if err = reg.splitTablet(
+			ctx, candidate.Table, candidate.EndKey); err != nil {
+			log.Printf("Error splitting tablet %s:%v (%s): %s",
+				candidate.Table, candidate.EndKey,
+				common.PrettyBytes(uint64(candidate.Size)), err)
+		}
+	}
+}
+
+/*
+findSplitKey determines a key roughly in the middle of the data contained
+in the specified sstable, weighted by record size, from the key index of
+the sstable. Sstables written without a key index are read completely
+instead.
+*/
+func findSplitKey(ctx context.Context, path string) ([]byte, error) {
+	var u *url.URL
+	var sst filesystem.ReadCloser
+	var index *storage.KeyIndex
+	var key []byte
+	var err error
+
+	if index, err = storage.ReadKeyIndex(ctx, path); err != nil {
+		log.Printf("Unable to read key index of %s, reading sstable: %s",
+			path, err)
+
+		if u, err = url.Parse(path + ".sst"); err != nil {
+			return nil, err
+		}
+
+		if sst, err = filesystem.OpenReader(ctx, u); err != nil {
+			return nil, err
+		}
+		defer sst.Close(ctx)
+
+		if index, err = buildKeyIndex(ctx, storage.NewBlockReader(
+			sstable.NewReader(sst))); err != nil {
+			return nil, err
+		}
+	}
+
+	if key = index.MiddleKey(); key == nil {
+		return nil, fmt.Errorf("No suitable split key found in %s", path)
+	}
+
+	return key, nil
+}
+
+/*
+sstableRecordReader reads the records of an sstable in key order.
+*/
+type sstableRecordReader interface {
+	ReadNextProto(ctx context.Context, msg proto.Message) (string, error)
+}
+
+/*
+sstableRecordWriter writes records to an sstable in key order.
+*/
+type sstableRecordWriter interface {
+	WriteProto(ctx context.Context, key string, msg proto.Message) error
+}
+
+/*
+buildKeyIndex creates a key index of all records read from in.
+*/
+func buildKeyIndex(ctx context.Context, in sstableRecordReader) (
+	*storage.KeyIndex, error) {
+	var index = storage.NewKeyIndex()
+	var err error
+
+	for {
+		var data redcloud.ColumnFamily
+		var key string
+
+		if key, err = in.ReadNextProto(ctx, &data); err == io.EOF {
+			return index, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		index.Add([]byte(key), int64(proto.Size(&data)))
+	}
+}
+
+/*
+splitSstableRecords copies the records read from in to the first of the
+writers if their key sorts before splitKey, or to the second one otherwise,
+and adds them to the respective key index. The sizes of the data written to
+the two writers are returned.
+*/
+func splitSstableRecords(ctx context.Context, in sstableRecordReader,
+	splitKey []byte, out [2]sstableRecordWriter,
+	keys [2]*storage.KeyIndex) ([2]int64, error) {
+	var sizes [2]int64
+	var err error
+
+	for {
+		var data redcloud.ColumnFamily
+		var key string
+		var size int64
+		var i int
+
+		if key, err = in.ReadNextProto(ctx, &data); err == io.EOF {
+			return sizes, nil
+		} else if err != nil {
+			return sizes, err
+		}
+
+		if bytes.Compare([]byte(key), splitKey) >= 0 {
+			i = 1
+		}
+
+		if err = out[i].WriteProto(ctx, key, &data); err != nil {
+			return sizes, err
+		}
+
+		size = int64(proto.Size(&data))
+		keys[i].Add([]byte(key), size)
+		sizes[i] += size
+	}
+}
+
+/*
+splitSstable distributes the records of the sstable at path into two new
+sstables at lowerPath and upperPath, depending on whether their key sorts
+before splitKey or not. The new sstables are compressed with the specified
+compression. If withKeys is set, key indices are written for both new
+sstables. The sizes of the data written to the two new sstables are
+returned.
+*/
+func splitSstable(ctx context.Context, path string, splitKey []byte,
+	lowerPath, upperPath string, compression redcloud.Compression,
+	withKeys bool) (int64, int64, error) {
+	var u *url.URL
+	var sst filesystem.ReadCloser
+	var outputs []filesystem.WriteCloser
+	var output filesystem.WriteCloser
+	var blocks [2]*storage.BlockWriter
+	var writers [2]sstableRecordWriter
+	var keys = [2]*storage.KeyIndex{
+		storage.NewKeyIndex(), storage.NewKeyIndex()}
+	var sizes [2]int64
+	var outPath string
+	var i int
+	var err error
+
+	if u, err = url.Parse(path + ".sst"); err != nil {
+		return 0, 0, err
+	}
+
+	if sst, err = filesystem.OpenReader(ctx, u); err != nil {
+		return 0, 0, err
+	}
+	defer sst.Close(ctx)
+
+	for i, outPath = range []string{lowerPath, upperPath} {
+		var usst, uidx *url.URL
+		var outsst, outidx filesystem.WriteCloser
+
+		if usst, err = url.Parse(outPath + ".sst"); err != nil {
+			return 0, 0, err
+		}
+		if uidx, err = url.Parse(outPath + ".idx"); err != nil {
+			return 0, 0, err
+		}
+
+		if outsst, err = filesystem.OpenWriter(ctx, usst); err != nil {
+			return 0, 0, err
+		}
+		outputs = append(outputs, outsst)
+
+		if outidx, err = filesystem.OpenWriter(ctx, uidx); err != nil {
+			return 0, 0, err
+		}
+		outputs = append(outputs, outidx)
+
+		blocks[i] = storage.NewBlockWriter(ctx, outsst, outidx, compression)
+		writers[i] = blocks[i]
+	}
+
+	if sizes, err = splitSstableRecords(ctx, storage.NewBlockReader(
+		sstable.NewReader(sst)), splitKey, writers, keys); err != nil {
+		return 0, 0, err
+	}
+
+	for i = range blocks {
+		if err = blocks[i].Flush(ctx); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for _, output = range outputs {
+		if err = output.Close(ctx); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if withKeys {
+		for i, outPath = range []string{lowerPath, upperPath} {
+			if err = storage.WriteKeyIndex(ctx, outPath, keys[i]); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	return sizes[0], sizes[1], nil
+}
+
+/*
+journalRecordReader reads the records of a journal, or of the memtable
+holding its data.
+*/
+type journalRecordReader interface {
+	ReadMessage(ctx context.Context, msg proto.Message) error
+}
+
+/*
+journalRecordWriter receives the records of one half of a journal being
+split.
+*/
+type journalRecordWriter interface {
+	WriteRecord(ctx context.Context, cf *redcloud.ColumnFamily) error
+}
+
+/*
+splitJournalRecords copies the records read from in to the first of the
+writers if their key sorts before splitKey, or to the second one otherwise.
+Since the records are copied in order, sorted input remains sorted.
+*/
+func splitJournalRecords(ctx context.Context, in journalRecordReader,
+	splitKey []byte, out [2]journalRecordWriter) error {
+	var err error
+
+	for {
+		var data redcloud.ColumnFamily
+		var i int
+
+		if err = in.ReadMessage(ctx, &data); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if bytes.Compare(data.Key, splitKey) >= 0 {
+			i = 1
+		}
+
+		if err = out[i].WriteRecord(ctx, &data); err != nil {
+			return err
+		}
+	}
+}
+
+/*
+journalHalf is one of the two journals a journal is split into. All records
+written to it are added to a memtable as well, so the new journal never has
+to be read back.
+*/
+type journalHalf struct {
+	path     string
+	file     filesystem.WriteCloser
+	writer   *recordio.RecordWriter
+	memtable *storage.Memtable
+}
+
+/*
+newJournalHalf creates a new journal at the specified path, along with the
+memtable for its data.
+*/
+func newJournalHalf(ctx context.Context, path string) (*journalHalf, error) {
+	var u *url.URL
+	var file filesystem.WriteCloser
+	var err error
+
+	if u, err = url.Parse(path); err != nil {
+		return nil, err
+	}
+
+	if file, err = filesystem.OpenWriter(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &journalHalf{
+		path:     path,
+		file:     file,
+		writer:   recordio.NewRecordWriter(file),
+		memtable: storage.NewMemtable(),
+	}, nil
+}
+
+/*
+WriteRecord writes the record to the journal, protected by a checksum, and
+adds it to the memtable. Records read from the original journal still carry
+their checksum, which is verified first.
+*/
+func (h *journalHalf) WriteRecord(
+	ctx context.Context, cf *redcloud.ColumnFamily) error {
+	var record *redcloud.ColumnFamily
+	var err error
+
+	if err = storage.VerifyChecksum(cf); err != nil {
+		return err
+	}
+
+	if record, err = storage.ChecksumRecord(cf); err != nil {
+		return err
+	}
+
+	if err = h.writer.WriteMessage(ctx, record); err != nil {
+		return err
+	}
+
+	return h.memtable.Add(cf)
+}
+
+/*
+splitJournal distributes the records of the journal at path into the two
+halves, depending on whether their key sorts before splitKey or not. If the
+data of the journal is held in a memtable, the memtable is read instead of
+the journal.
+*/
+func (reg *ServingRangeRegistry) splitJournal(ctx context.Context,
+	path string, splitKey []byte, lower, upper *journalHalf) error {
+	var memtable *storage.Memtable
+	var u *url.URL
+	var input filesystem.ReadCloser
+	var in journalRecordReader
+	var err error
+
+	if memtable = reg.GetMemtable(path); memtable != nil {
+		in = memtable.NewReader()
+	} else {
+		if u, err = url.Parse(path); err != nil {
+			return err
+		}
+
+		if input, err = filesystem.OpenReader(ctx, u); err != nil {
+			return err
+		}
+		defer input.Close(ctx)
+
+		in = recordio.NewRecordReader(input)
+	}
+
+	return splitJournalRecords(
+		ctx, in, splitKey, [2]journalRecordWriter{lower, upper})
+}
+
+/*
+splitJournals splits the journals of column family cf at the specified
+paths, appending the new journals to the lower and upper descriptors. The
+memtables of the new journals are recorded in memtables, to be registered
+once the split is done. The paths of all journals created are returned,
+even if an error occurred.
+*/
+func (reg *ServingRangeRegistry) splitJournals(ctx context.Context,
+	table, cf string, paths []string, splitKey, endKey []byte,
+	lower, upper *redcloud.SSTablePathDescription,
+	memtables map[string]*storage.Memtable) ([]string, error) {
+	var created []string
+	var path string
+	var err error
+
+	for _, path = range paths {
+		var now = time.Now()
+		var halves [2]*journalHalf
+		var outPaths [2]string
+		var half *journalHalf
+		var i int
+
+		outPaths[0] = fmt.Sprintf("%s/%s", reg.prefixes[table],
+			storage.MakePath(reg.instance, table, cf, splitKey, now,
+				storage.SSTableLevelJOURNAL))
+		outPaths[1] = fmt.Sprintf("%s/%s", reg.prefixes[table],
+			storage.MakePath(reg.instance, table, cf, endKey, now,
+				storage.SSTableLevelJOURNAL))
+
+		for i = range outPaths {
+			if strings.HasSuffix(path, ".sorted") {
+				outPaths[i] += ".sorted"
+			}
+			if halves[i], err = newJournalHalf(ctx, outPaths[i]); err != nil {
+				if halves[0] != nil {
+					halves[0].file.Close(ctx)
+				}
+				return created, err
+			}
+			created = append(created, outPaths[i])
+		}
+
+		err = reg.splitJournal(ctx, path, splitKey, halves[0], halves[1])
+
+		for _, half = range halves {
+			var closeErr error
+
+			if closeErr = half.file.Close(ctx); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			return created, fmt.Errorf("Error splitting %s: %s", path, err)
+		}
+
+		for _, half = range halves {
+			memtables[half.path] = half.memtable
+		}
+
+		lower.RelevantJournalPaths = append(
+			lower.RelevantJournalPaths, outPaths[0])
+		lower.JournalSize += halves[0].memtable.Size()
+		upper.RelevantJournalPaths = append(
+			upper.RelevantJournalPaths, outPaths[1])
+		upper.JournalSize += halves[1].memtable.Size()
+	}
+
+	return created, nil
+}
+
+/*
+sealJournals starts a new journal for the sstable range if its current
+journal has been written to, so all other journals of the range will no
+longer change. Their paths are returned.
+*/
+func (reg *ServingRangeRegistry) sealJournals(ctx context.Context,
+	table, cf string, key []byte, info *sstableInfo) ([]string, error) {
+	var writer *JournalWriter
+	var sealed []string
+	var path string
+	var err error
+
+	if !info.JournalLock.LockWithContext(ctx) {
+		return nil, ctx.Err()
+	}
+	defer info.JournalLock.Unlock()
+
+	if info.Journal != nil && info.JournalNumUses > 0 {
+		if writer, err = reg.internalCreateJournalWriter(
+			ctx, table, cf, key, info); err != nil {
+			return nil, err
+		}
+
+		if err = info.Journal.Close(ctx); err != nil {
+			log.Printf("Error closing journal %s: %s", info.Journal.path, err)
+		}
+		info.Journal = writer
+		info.JournalNumUses = 0
+		info.JournalCreateTime = time.Now()
+	}
+
+	for _, path = range info.Descriptor.RelevantJournalPaths {
+		if info.Journal == nil || path != info.Journal.path {
+			sealed = append(sealed, path)
+		}
+	}
+
+	return sealed, nil
+}
+
+/*
+removeSstable removes the data, index, bloom filter and key index files of
+the sstable at the specified path, if the path is set.
+*/
+func removeSstable(ctx context.Context, path string) {
+	var u *url.URL
+	var suffix string
+	var err error
+
+	if len(path) == 0 {
+		return
+	}
+
+	storage.InvalidateSstable(path)
+
+	for _, suffix = range []string{".sst", ".idx", ".bloom", ".keys"} {
+		if u, err = url.Parse(path + suffix); err != nil {
+			log.Printf("Error parsing sstable path %s: %s", path+suffix, err)
+			continue
+		}
+		if err = filesystem.Remove(ctx, u); err != nil {
+			log.Printf("Error removing %s: %s", u.String(), err)
+		}
+	}
+}
+
+/*
+removeKeyIndex removes the key index file of the sstable at the specified
+path, if the path is set, once the sstable has been replaced.
+*/
+func removeKeyIndex(ctx context.Context, path string) {
+	var u *url.URL
+	var err error
+
+	if len(path) == 0 {
+		return
+	}
+
+	if u, err = url.Parse(path + ".keys"); err != nil {
+		log.Printf("Error parsing key index path %s: %s", path, err)
+		return
+	}
+	if err = filesystem.Remove(ctx, u); err != nil {
+		log.Printf("Error removing %s: %s", u.String(), err)
+	}
+}
+
+/*
+removeJournal removes the journal at the specified path.
+*/
+func removeJournal(ctx context.Context, path string) {
+	var u *url.URL
+	var err error
+
+	if u, err = url.Parse(path); err != nil {
+		log.Printf("Error parsing journal path %s: %s", path, err)
+		return
+	}
+	if err = filesystem.Remove(ctx, u); err != nil {
+		log.Printf("Error removing %s: %s", u.String(), err)
+	}
+}
+
+/*
+splitTablet splits the tablet of the specified table ending at endKey into
+two tablets at a key roughly in the middle of its data. The major and minor
+sstables as well as the journals of all column families are rewritten into
+files for the two new tablets, and the single ServerTabletMetadata entry in
+etcd is atomically replaced by two new ones. Both new tablets continue to
+be served by this data node.
+
+Most of the data is split while the tablet keeps serving: the sstables are
+only replaced by compactions, which are locked out, and the journals are
+sealed by starting new ones first. Writes to the tablet are only blocked
+while the journals written during the split are split and the metadata is
+replaced in etcd, and the registry is only locked to swap in the new
+tablets. The tablet cannot be unloaded while it is being split.
+*/
+func (reg *ServingRangeRegistry) splitTablet(
+	parentCtx context.Context, table string, endKey []byte) error {
+	var ctx context.Context
+	var span *trace.Span
+	var started = time.Now()
+	var kr, knownRange *common.KeyRange
+	var lowerRange, upperRange *common.KeyRange
+	var cfs = make(map[string]*sstableInfo)
+	var cfNames []string
+	var cf string
+	var info, largest *sstableInfo
+	var lowerDescs = make(map[string]*redcloud.SSTablePathDescription)
+	var upperDescs = make(map[string]*redcloud.SSTablePathDescription)
+	var lowerInfos = make(map[string]*sstableInfo)
+	var upperInfos = make(map[string]*sstableInfo)
+	var sealed = make(map[string][]string)
+	var memtables = make(map[string]*storage.Memtable)
+	var newSstables, newJournals, created []string
+	var splitKey []byte
+	var ncr []*common.KeyRange
+	var lower, upper *redcloud.ServerTabletMetadata
+	var locked []*sstableInfo
+	var memtable *storage.Memtable
+	var path string
+	var splitSize int64
+	var compression redcloud.Compression
+	var uncertain bool
+	var err error
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.ServingRangeRegistry/splitTablet")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("table", table))
+
+	reg.registryAccessLock.Lock()
+	for _, knownRange = range reg.coveredRanges[table] {
+		if bytes.Equal(knownRange.EndKey, endKey) {
+			kr = knownRange
+		}
+	}
+	for cf, info = range reg.columnFamilies[table][string(endKey)] {
+		if info.Splitting {
+			reg.registryAccessLock.Unlock()
+			span.Annotate(nil, "Tablet already being split")
+			return common.ErrTabletSplitting
+		}
+		cfs[cf] = info
+		cfNames = append(cfNames, cf)
+	}
+	if kr != nil {
+		for _, info = range cfs {
+			info.Splitting = true
+		}
+	}
+	compression = reg.compressions[table]
+	reg.registryAccessLock.Unlock()
+
+	if kr == nil || len(cfs) == 0 {
+		span.Annotate(nil, "Tablet not loaded")
+		return common.ErrTabletNotLoaded
+	}
+
+	/*
+		Once the split is over, the original tablet can be unloaded again if
+		it is still registered. Deferred functions run in reverse order, so
+		this runs once all locks of the tablet have been released.
+	*/
+	defer func() {
+		reg.registryAccessLock.Lock()
+		for _, info = range cfs {
+			info.Splitting = false
+		}
+		reg.registryAccessLock.Unlock()
+	}()
+
+	/*
+		Keep log sorting and compactions away from the tablet while we are
+		rewriting its files. Always lock in the same order.
+	*/
+	sort.Strings(cfNames)
+	for _, cf = range cfNames {
+		cfs[cf].LogsortLock.Lock()
+		defer cfs[cf].LogsortLock.Unlock()
+		cfs[cf].CompactionLock.Lock()
+		defer cfs[cf].CompactionLock.Unlock()
+	}
+
+	tabletSplitsInProgress.Inc()
+	defer tabletSplitsInProgress.Add(-1)
+
+	// Use the column family with the most data to determine the split key.
+	for _, cf = range cfNames {
+		info = cfs[cf]
+		if len(info.Descriptor.MajorSstablePath) > 0 && (largest == nil ||
+			info.MajorSstableSize > largest.MajorSstableSize) {
+			largest = info
+		}
+	}
+
+	if largest == nil {
+		span.Annotate(nil, "No major sstable to determine split key from")
+		tabletSplitsFailed.Inc()
+		return fmt.Errorf("No major sstable in tablet %s:%v to split",
+			table, endKey)
+	}
+
+	if splitKey, err = findSplitKey(
+		ctx, largest.Descriptor.MajorSstablePath); err != nil {
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error determining split key")
+		tabletSplitsFailed.Inc()
+		return err
+	}
+
+	// Both halves must be non-empty ranges.
+	if !kr.Contains(splitKey) || bytes.Equal(splitKey, kr.StartKey) {
+		span.Annotate(nil, "Split key at the edge of the tablet")
+		tabletSplitsFailed.Inc()
+		return fmt.Errorf("Split key %v does not split tablet %s", splitKey,
+			kr.String())
+	}
+
+	lowerRange = common.NewKeyRange(kr.StartKey, splitKey)
+	upperRange = common.NewKeyRange(splitKey, kr.EndKey)
+
+	span.AddAttributes(
+		trace.StringAttribute("split-key", string(splitKey)))
+
+	/*
+		Major and minor sstables are only ever replaced by compactions, which
+		we have locked out, so they can be split before blocking any access.
+	*/
+	for _, cf = range cfNames {
+		var levels = []storage.SSTableLevel{
+			storage.SSTableLevelMAJOR, storage.SSTableLevelMINOR}
+		var paths []string
+		var level int
+
+		info = cfs[cf]
+		paths = []string{info.Descriptor.MajorSstablePath,
+			info.Descriptor.MinorSstablePath}
+		lowerDescs[cf] = &redcloud.SSTablePathDescription{ColumnFamily: cf}
+		upperDescs[cf] = &redcloud.SSTablePathDescription{ColumnFamily: cf}
+		lowerInfos[cf] = &sstableInfo{
+			Descriptor:        lowerDescs[cf],
+			JournalCreateTime: time.Now(),
+			JournalLock:       fancylocking.NewMutexWithDeadline(),
+		}
+		upperInfos[cf] = &sstableInfo{
+			Descriptor:        upperDescs[cf],
+			JournalCreateTime: time.Now(),
+			JournalLock:       fancylocking.NewMutexWithDeadline(),
+		}
+
+		for level = range levels {
+			var lowerPath, upperPath string
+			var lowerSize, upperSize int64
+
+			if len(paths[level]) == 0 {
+				continue
+			}
+
+			lowerPath = fmt.Sprintf("%s/%s", reg.prefixes[table],
+				storage.MakePath(reg.instance, table, cf, splitKey, started,
+					levels[level]))
+			upperPath = fmt.Sprintf("%s/%s", reg.prefixes[table],
+				storage.MakePath(reg.instance, table, cf, endKey, started,
+					levels[level]))
+			newSstables = append(newSstables, lowerPath, upperPath)
+
+			if lowerSize, upperSize, err = splitSstable(
+				ctx, paths[level], splitKey, lowerPath, upperPath, compression,
+				levels[level] == storage.SSTableLevelMAJOR); err != nil {
+				span.AddAttributes(
+					trace.StringAttribute("path", paths[level]),
+					trace.StringAttribute("error", err.Error()))
+				span.Annotate(nil, "Error splitting sstable")
+				reg.abortSplit(ctx, newSstables, newJournals)
+				tabletSplitsFailed.Inc()
+				return err
+			}
+
+			if levels[level] == storage.SSTableLevelMAJOR {
+				lowerDescs[cf].MajorSstablePath = lowerPath
+				lowerDescs[cf].MajorSstableSize = lowerSize
+				lowerInfos[cf].MajorSstableSize = lowerSize
+				upperDescs[cf].MajorSstablePath = upperPath
+				upperDescs[cf].MajorSstableSize = upperSize
+				upperInfos[cf].MajorSstableSize = upperSize
+			} else {
+				lowerDescs[cf].MinorSstablePath = lowerPath
+				lowerDescs[cf].MinorSstableSize = lowerSize
+				lowerInfos[cf].MinorSstableSize = lowerSize
+				upperDescs[cf].MinorSstablePath = upperPath
+				upperDescs[cf].MinorSstableSize = upperSize
+				upperInfos[cf].MinorSstableSize = upperSize
+			}
+		}
+	}
+
+	/*
+		Start new journals for all column families, so the journals written
+		so far no longer change and can be split without blocking writes.
+	*/
+	reg.registryAccessLock.RLock()
+	for _, cf = range cfNames {
+		if sealed[cf], err = reg.sealJournals(
+			ctx, table, cf, kr.StartKey, cfs[cf]); err != nil {
+			break
+		}
+	}
+	reg.registryAccessLock.RUnlock()
+
+	if err != nil {
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error starting new journals")
+		reg.abortSplit(ctx, newSstables, newJournals)
+		tabletSplitsFailed.Inc()
+		return err
+	}
+
+	for _, cf = range cfNames {
+		created, err = reg.splitJournals(ctx, table, cf, sealed[cf], splitKey,
+			endKey, lowerDescs[cf], upperDescs[cf], memtables)
+		newJournals = append(newJournals, created...)
+		if err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error splitting journal")
+			reg.abortSplit(ctx, newSstables, newJournals)
+			tabletSplitsFailed.Inc()
+			return err
+		}
+	}
+
+	/*
+		Only the journals written since then remain to be split. From here
+		on, writes to the tablet are blocked by holding all of its journal
+		locks. Reads continue on the original files, which hold all data
+		until the new tablets have been registered.
+	*/
+	defer func() {
+		unlockJournals(locked)
+	}()
+
+	for _, cf = range cfNames {
+		var isSealed = make(map[string]bool)
+		var remaining []string
+
+		info = cfs[cf]
+		if !info.JournalLock.LockWithContext(ctx) {
+			span.AddAttributes(
+				trace.StringAttribute("error", ctx.Err().Error()))
+			span.Annotate(nil, "Context expired")
+			reg.abortSplit(ctx, newSstables, newJournals)
+			tabletSplitsFailed.Inc()
+			return ctx.Err()
+		}
+		locked = append(locked, info)
+
+		for _, path = range sealed[cf] {
+			isSealed[path] = true
+		}
+		for _, path = range info.Descriptor.RelevantJournalPaths {
+			if !isSealed[path] {
+				remaining = append(remaining, path)
+			}
+		}
+
+		created, err = reg.splitJournals(ctx, table, cf, remaining, splitKey,
+			endKey, lowerDescs[cf], upperDescs[cf], memtables)
+		newJournals = append(newJournals, created...)
+		if err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Error splitting journal")
+			reg.abortSplit(ctx, newSstables, newJournals)
+			tabletSplitsFailed.Inc()
+			return err
+		}
+
+		lowerInfos[cf].JournalSize = lowerDescs[cf].JournalSize
+		upperInfos[cf].JournalSize = upperDescs[cf].JournalSize
+
+		// Both halves continue the timestamps of the original tablet.
+		lowerInfos[cf].LastTimestamp = info.LastTimestamp
+		lowerDescs[cf].LastTimestamp = info.LastTimestamp
+		upperInfos[cf].LastTimestamp = info.LastTimestamp
+		upperDescs[cf].LastTimestamp = info.LastTimestamp
+	}
+
+	lower = &redcloud.ServerTabletMetadata{
+		StartKey: lowerRange.StartKey,
+		EndKey:   lowerRange.EndKey,
+		Host:     reg.host,
+		Port:     int32(reg.port),
+	}
+	upper = &redcloud.ServerTabletMetadata{
+		StartKey: upperRange.StartKey,
+		EndKey:   upperRange.EndKey,
+		Host:     reg.host,
+		Port:     int32(reg.port),
+	}
+	for _, cf = range cfNames {
+		lower.SstablePath = append(lower.SstablePath, lowerDescs[cf])
+		upper.SstablePath = append(upper.SstablePath, upperDescs[cf])
+	}
+
+	// Replace the tablet in etcd with the two new tablets.
+	if splitSize, uncertain, err = reg.replaceTabletMetadata(
+		ctx, table, kr, cfs, lower, upper); err != nil {
+		span.AddAttributes(trace.StringAttribute("error", err.Error()))
+		span.Annotate(nil, "Error replacing tablet metadata")
+		tabletSplitsFailed.Inc()
+		if uncertain {
+			/*
+				The new tablets may have been registered after all, so their
+				files have to be kept. The original tablet is still served
+				until it gets reassigned.
+			*/
+			log.Printf("Keeping files of possibly registered split of "+
+				"tablet %s: %s", kr.String(), err)
+		} else {
+			reg.abortSplit(ctx, newSstables, newJournals)
+		}
+		return err
+	}
+
+	/*
+		The original tablet must not be written to anymore. Writers which
+		were waiting for the journal locks will find the tablet replaced and
+		retry on the new tablets.
+	*/
+	for _, info = range locked {
+		if info.Journal != nil {
+			if err = info.Journal.Close(ctx); err != nil {
+				log.Printf("Error closing journal %s: %s", info.Journal.path,
+					err)
+			}
+			info.Journal = nil
+		}
+		info.Replaced = true
+	}
+	unlockJournals(locked)
+	locked = nil
+
+	// Update our internal account of the served ranges.
+	reg.registryAccessLock.Lock()
+	for _, knownRange = range reg.coveredRanges[table] {
+		if knownRange.Equals(kr) {
+			ncr = append(ncr, lowerRange, upperRange)
+		} else {
+			ncr = append(ncr, knownRange)
+		}
+	}
+	reg.coveredRanges[table] = ncr
+	reg.splitSizes[table] = splitSize
+
+	delete(reg.columnFamilies[table], string(endKey))
+	reg.columnFamilies[table][string(splitKey)] = lowerInfos
+	reg.columnFamilies[table][string(endKey)] = upperInfos
+
+	for path, memtable = range memtables {
+		reg.registerMemtable(path, memtable)
+	}
+	reg.registryAccessLock.Unlock()
+
+	// The files of the original tablet are no longer referenced.
+	for _, cf = range cfNames {
+		info = cfs[cf]
+		reg.dropBloomFilter(info.Descriptor.MajorSstablePath)
+		reg.dropBloomFilter(info.Descriptor.MinorSstablePath)
+		removeSstable(ctx, info.Descriptor.MajorSstablePath)
+		removeSstable(ctx, info.Descriptor.MinorSstablePath)
+		for _, path = range info.Descriptor.RelevantJournalPaths {
+			reg.dropMemtable(path)
+			removeJournal(ctx, path)
+		}
+	}
+
+	span.Annotate(nil, "Tablet split done")
+	tabletSplitsDone.Inc()
+	tabletSplitLatency.Set(time.Now().Sub(started).Seconds())
+	return nil
+}
+
+/*
+unlockJournals releases the journal locks of all specified column families.
+*/
+func unlockJournals(infos []*sstableInfo) {
+	var info *sstableInfo
+
+	for _, info = range infos {
+		info.JournalLock.Unlock()
+	}
+}
+
+/*
+replaceTabletMetadata replaces the tablet of the specified table covering kr
+with the lower and upper tablets in etcd, and returns the split size of the
+table. Column families not among the loaded ones are added to both tablets
+without any data.
+
+Writes to the tablet are blocked meanwhile, so etcd is given at most
+splitMetadataTimeout, and conflicting updates and errors are only retried
+up to common.MaxRetries times. If the last attempt failed to commit, it is
+unknown whether the tablets were replaced, which is reported along with the
+error.
+*/
+func (reg *ServingRangeRegistry) replaceTabletMetadata(
+	parentCtx context.Context, table string, kr *common.KeyRange,
+	loaded map[string]*sstableInfo,
+	lower, upper *redcloud.ServerTabletMetadata) (int64, bool, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var etcdPath = common.EtcdTableConfigPath(reg.instance, table)
+	var uncertain bool
+	var retries int
+	var err error
+
+	ctx, cancel = context.WithTimeout(parentCtx, splitMetadataTimeout)
+	defer cancel()
+
+	for retries = 0; ; retries++ {
+		var md redcloud.ServerTableMetadata
+		var tabletMd *redcloud.ServerTabletMetadata
+		var newTablets []*redcloud.ServerTabletMetadata
+		var lowerMd, upperMd *redcloud.ServerTabletMetadata
+		var cfmd *redcloud.ColumnFamilyMetadata
+		var gresp *etcd.GetResponse
+		var presp *etcd.TxnResponse
+		var ev *mvccpb.KeyValue
+		var modrev int64
+		var version int64
+		var encData []byte
+		var found, ok bool
+
+		if retries > 0 {
+			if retries > common.MaxRetries {
+				return 0, uncertain, fmt.Errorf(
+					"Giving up updating %s after %d attempts: %s", etcdPath,
+					retries, err)
+			}
+			if err = common.WaitForRetry(ctx, retries-1); err != nil {
+				return 0, uncertain, err
+			}
+		}
+
+		if gresp, err = reg.etcdClient.Get(
+			ctx, etcdPath, etcd.WithLimit(1)); err != nil {
+			return 0, uncertain, err
+		}
+
+		for _, ev = range gresp.Kvs {
+			if err = proto.Unmarshal(ev.Value, &md); err != nil {
+				return 0, uncertain, fmt.Errorf(
+					"Unable to parse %s as ServerTableMetadata protobuf at version %d",
+					etcdPath, ev.Version)
+			}
+
+			modrev = ev.ModRevision
+			version = ev.Version
+		}
+
+		lowerMd = proto.Clone(lower).(*redcloud.ServerTabletMetadata)
+		upperMd = proto.Clone(upper).(*redcloud.ServerTabletMetadata)
+
+		// Column families we haven't loaded yet don't have any data.
+		for _, cfmd = range md.TableMd.ColumnFamily {
+			if _, ok = loaded[cfmd.Name]; !ok {
+				lowerMd.SstablePath = append(lowerMd.SstablePath,
+					&redcloud.SSTablePathDescription{ColumnFamily: cfmd.Name})
+				upperMd.SstablePath = append(upperMd.SstablePath,
+					&redcloud.SSTablePathDescription{ColumnFamily: cfmd.Name})
+			}
+		}
+
+		for _, tabletMd = range md.Tablet {
+			if bytes.Equal(tabletMd.StartKey, kr.StartKey) &&
+				bytes.Equal(tabletMd.EndKey, kr.EndKey) {
+				newTablets = append(newTablets, lowerMd, upperMd)
+				found = true
+			} else {
+				newTablets = append(newTablets, tabletMd)
+			}
+		}
+
+		if !found {
+			// A commit we received an error for may have gone through.
+			if uncertain && containsTablets(md.Tablet, lowerMd, upperMd) {
+				return md.TableMd.SplitSize, false, nil
+			}
+			return 0, false, common.ErrTabletNotLoaded
+		}
+
+		md.Tablet = newTablets
+
+		if encData, err = proto.Marshal(&md); err != nil {
+			return 0, uncertain, fmt.Errorf(
+				"Unable to encode updated metadata: %s", err)
+		}
+
+		if presp, err = reg.etcdClient.Txn(ctx).If(
+			etcd.Compare(etcd.ModRevision(etcdPath), "=", modrev),
+			etcd.Compare(etcd.Version(etcdPath), "=", version)).Then(
+			etcd.OpPut(etcdPath, string(encData))).Commit(); err != nil {
+			log.Printf("Error committing update to %s: %s", etcdPath, err)
+			uncertain = true
+			continue
+		}
+
+		// Successful update -> we're out.
+		if presp.Succeeded {
+			return md.TableMd.SplitSize, false, nil
+		}
+
+		uncertain = false
+		err = fmt.Errorf("Concurrent update of %s", etcdPath)
+	}
+}
+
+/*
+containsTablets determines whether all of the wanted tablets are part of the
+specified table metadata, as they were written.
+*/
+func containsTablets(tablets []*redcloud.ServerTabletMetadata,
+	wanted ...*redcloud.ServerTabletMetadata) bool {
+	var tabletMd, want *redcloud.ServerTabletMetadata
+
+	for _, want = range wanted {
+		var found bool
+
+		for _, tabletMd = range tablets {
+			if proto.Equal(tabletMd, want) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+abortSplit removes all files which have been created for a tablet split
+which could not be completed.
+*/
+func (reg *ServingRangeRegistry) abortSplit(
+	ctx context.Context, sstables, journals []string) {
+	var path string
+
+	for _, path = range sstables {
+		removeSstable(ctx, path)
+	}
+	for _, path = range journals {
+		removeJournal(ctx, path)
+	}
+}
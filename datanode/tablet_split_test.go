@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/storage"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+fakeRecords serves a list of rows both as an sstable and as a journal.
+*/
+type fakeRecords struct {
+	rows []*redcloud.ColumnFamily
+}
+
+func (f *fakeRecords) ReadNextProto(
+	ctx context.Context, msg proto.Message) (string, error) {
+	var row *redcloud.ColumnFamily
+
+	if len(f.rows) == 0 {
+		return "", io.EOF
+	}
+
+	row, f.rows = f.rows[0], f.rows[1:]
+	proto.Merge(msg, row)
+	return string(row.Key), nil
+}
+
+func (f *fakeRecords) ReadMessage(
+	ctx context.Context, msg proto.Message) error {
+	var err error
+
+	_, err = f.ReadNextProto(ctx, msg)
+	return err
+}
+
+func (f *fakeRecords) WriteProto(
+	ctx context.Context, key string, msg proto.Message) error {
+	f.rows = append(f.rows, proto.Clone(msg).(*redcloud.ColumnFamily))
+	return nil
+}
+
+func (f *fakeRecords) WriteRecord(
+	ctx context.Context, cf *redcloud.ColumnFamily) error {
+	f.rows = append(f.rows, proto.Clone(cf).(*redcloud.ColumnFamily))
+	return nil
+}
+
+func (f *fakeRecords) keys() []string {
+	var keys []string
+	var row *redcloud.ColumnFamily
+
+	for _, row = range f.rows {
+		keys = append(keys, string(row.Key))
+	}
+
+	return keys
+}
+
+func testRow(key string) *redcloud.ColumnFamily {
+	return &redcloud.ColumnFamily{
+		Key: []byte(key),
+		ColumnSet: []*redcloud.ColumnSet{
+			{Name: "col", Column: []*redcloud.Column{
+				{Content: []byte("data-" + key), Timestamp: 1}}},
+		},
+	}
+}
+
+func testRows(keys ...string) []*redcloud.ColumnFamily {
+	var rv []*redcloud.ColumnFamily
+	var key string
+
+	for _, key = range keys {
+		rv = append(rv, testRow(key))
+	}
+
+	return rv
+}
+
+func TestBuildKeyIndex(t *testing.T) {
+	var in fakeRecords
+	var index *storage.KeyIndex
+	var i int
+	var err error
+
+	for i = 0; i < 128; i++ {
+		in.rows = append(in.rows, testRow(fmt.Sprintf("key%03d", i)))
+	}
+
+	if index, err = buildKeyIndex(context.Background(), &in); err != nil {
+		t.Errorf("Error building key index: %s", err)
+		return
+	}
+
+	if string(index.MiddleKey()) != "key064" {
+		t.Errorf("Unexpected split key: got %q, want %q",
+			index.MiddleKey(), "key064")
+	}
+}
+
+func TestSplitSstableRecords(t *testing.T) {
+	var cases = []struct {
+		name     string
+		input    []string
+		splitKey string
+		lower    []string
+		upper    []string
+	}{
+		{"middle", []string{"a", "b", "c", "d", "e"}, "c",
+			[]string{"a", "b"}, []string{"c", "d", "e"}},
+		{"between keys", []string{"a", "b", "d", "e"}, "c",
+			[]string{"a", "b"}, []string{"d", "e"}},
+		{"all lower", []string{"a", "b"}, "c",
+			[]string{"a", "b"}, nil},
+		{"empty", nil, "c", nil, nil},
+	}
+	var i int
+
+	for i = range cases {
+		var in = fakeRecords{rows: testRows(cases[i].input...)}
+		var lower, upper fakeRecords
+		var sizes [2]int64
+		var err error
+
+		if sizes, err = splitSstableRecords(context.Background(), &in,
+			[]byte(cases[i].splitKey), [2]sstableRecordWriter{&lower, &upper},
+			[2]*storage.KeyIndex{
+				storage.NewKeyIndex(), storage.NewKeyIndex()}); err != nil {
+			t.Errorf("%s: error splitting sstable: %s", cases[i].name, err)
+			continue
+		}
+
+		if fmt.Sprint(lower.keys()) != fmt.Sprint(cases[i].lower) {
+			t.Errorf("%s: unexpected lower keys: got %v, want %v",
+				cases[i].name, lower.keys(), cases[i].lower)
+		}
+		if fmt.Sprint(upper.keys()) != fmt.Sprint(cases[i].upper) {
+			t.Errorf("%s: unexpected upper keys: got %v, want %v",
+				cases[i].name, upper.keys(), cases[i].upper)
+		}
+		if sizes[0] != int64(len(lower.rows)*proto.Size(testRow("a"))) ||
+			sizes[1] != int64(len(upper.rows)*proto.Size(testRow("a"))) {
+			t.Errorf("%s: unexpected sizes %v", cases[i].name, sizes)
+		}
+	}
+}
+
+func TestSplitJournalRecords(t *testing.T) {
+	var in = fakeRecords{rows: testRows("d", "a", "c", "b", "a")}
+	var lower, upper fakeRecords
+	var err error
+
+	if err = splitJournalRecords(context.Background(), &in, []byte("c"),
+		[2]journalRecordWriter{&lower, &upper}); err != nil {
+		t.Errorf("Error splitting journal: %s", err)
+	}
+
+	// Journals are split in their original order.
+	if fmt.Sprint(lower.keys()) != "[a b a]" {
+		t.Errorf("Unexpected lower keys: %v", lower.keys())
+	}
+	if fmt.Sprint(upper.keys()) != "[d c]" {
+		t.Errorf("Unexpected upper keys: %v", upper.keys())
+	}
+}
+
+func TestContainsTablets(t *testing.T) {
+	var lower = &redcloud.ServerTabletMetadata{
+		StartKey: []byte("a"), EndKey: []byte("m"), Host: "node", Port: 1}
+	var upper = &redcloud.ServerTabletMetadata{
+		StartKey: []byte("m"), EndKey: []byte("z"), Host: "node", Port: 1}
+	var moved = &redcloud.ServerTabletMetadata{
+		StartKey: []byte("m"), EndKey: []byte("z"), Host: "other", Port: 1}
+	var cases = []struct {
+		name     string
+		tablets  []*redcloud.ServerTabletMetadata
+		expected bool
+	}{
+		{"both", []*redcloud.ServerTabletMetadata{lower, upper}, true},
+		{"lower only", []*redcloud.ServerTabletMetadata{lower}, false},
+		{"upper moved", []*redcloud.ServerTabletMetadata{lower, moved}, false},
+		{"none", nil, false},
+	}
+	var i int
+
+	for i = range cases {
+		if containsTablets(cases[i].tablets, lower, upper) !=
+			cases[i].expected {
+			t.Errorf("%s: expected %v", cases[i].name, cases[i].expected)
+		}
+	}
+}
@@ -63,6 +63,18 @@ type SSTablePathDescription struct {
 	MajorSstableSize int64 `protobuf:"varint,5,opt,name=major_sstable_size,json=majorSstableSize" json:"major_sstable_size,omitempty"`
 	// Size of the minor sstable on the most recent compaction.
 	MinorSstableSize int64 `protobuf:"varint,6,opt,name=minor_sstable_size,json=minorSstableSize" json:"minor_sstable_size,omitempty"`
+	//
+	// Most recent timestamp assigned to a write to the column family of the
+	// tablet by a data node, as of the creation of the latest journal. All
+	// data in older journals and sstables was written before, so data nodes
+	// loading the tablet only assign newer timestamps than this one and the
+	// newest one in the remaining journals, even if their clock is behind.
+	LastTimestamp int64 `protobuf:"varint,7,opt,name=last_timestamp,json=lastTimestamp" json:"last_timestamp,omitempty"`
+	//
+	// Approximate combined size of the data in the journals listed in
+	// relevant_journal_paths, as of the most recent update of this
+	// description by a data node.
+	JournalSize int64 `protobuf:"varint,8,opt,name=journal_size,json=journalSize" json:"journal_size,omitempty"`
 }
 
 func (m *SSTablePathDescription) Reset()                    { *m = SSTablePathDescription{} }
@@ -112,6 +124,88 @@ func (m *SSTablePathDescription) GetMinorSstableSize() int64 {
 	return 0
 }
 
+func (m *SSTablePathDescription) GetLastTimestamp() int64 {
+	if m != nil {
+		return m.LastTimestamp
+	}
+	return 0
+}
+
+func (m *SSTablePathDescription) GetJournalSize() int64 {
+	if m != nil {
+		return m.JournalSize
+	}
+	return 0
+}
+
+//
+// BloomFilterData holds a bloom filter over the row keys of an sstable. It is
+// stored next to the sstable in a file with the suffix ".bloom".
+type BloomFilterData struct {
+	// Number of bits set for each key.
+	NumHashes uint32 `protobuf:"varint,1,opt,name=num_hashes,json=numHashes" json:"num_hashes,omitempty"`
+	// Bits of the filter.
+	Bits []byte `protobuf:"bytes,2,opt,name=bits,proto3" json:"bits,omitempty"`
+}
+
+func (m *BloomFilterData) Reset()                    { *m = BloomFilterData{} }
+func (m *BloomFilterData) String() string            { return proto.CompactTextString(m) }
+func (*BloomFilterData) ProtoMessage()               {}
+func (*BloomFilterData) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
+
+func (m *BloomFilterData) GetNumHashes() uint32 {
+	if m != nil {
+		return m.NumHashes
+	}
+	return 0
+}
+
+func (m *BloomFilterData) GetBits() []byte {
+	if m != nil {
+		return m.Bits
+	}
+	return nil
+}
+
+//
+// KeyIndexData holds a sample of the row keys of an sstable along with the
+// amount of data stored before each of them. It is stored next to the sstable
+// in a file with the suffix ".keys".
+type KeyIndexData struct {
+	// Every sampled key, in key order.
+	Key [][]byte `protobuf:"bytes,1,rep,name=key,proto3" json:"key,omitempty"`
+	// Size of the rows stored before each of the keys, in bytes.
+	Offset []int64 `protobuf:"varint,2,rep,packed,name=offset" json:"offset,omitempty"`
+	// Size of all rows of the sstable, in bytes.
+	TotalSize int64 `protobuf:"varint,3,opt,name=total_size,json=totalSize" json:"total_size,omitempty"`
+}
+
+func (m *KeyIndexData) Reset()                    { *m = KeyIndexData{} }
+func (m *KeyIndexData) String() string            { return proto.CompactTextString(m) }
+func (*KeyIndexData) ProtoMessage()               {}
+func (*KeyIndexData) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
+
+func (m *KeyIndexData) GetKey() [][]byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *KeyIndexData) GetOffset() []int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return nil
+}
+
+func (m *KeyIndexData) GetTotalSize() int64 {
+	if m != nil {
+		return m.TotalSize
+	}
+	return 0
+}
+
 //
 // ServerTabletMetadata holds metadata for tablets, i.e. individual pieces of
 // tables living on specific servers.
@@ -139,7 +233,7 @@ type ServerTabletMetadata struct {
 func (m *ServerTabletMetadata) Reset()                    { *m = ServerTabletMetadata{} }
 func (m *ServerTabletMetadata) String() string            { return proto.CompactTextString(m) }
 func (*ServerTabletMetadata) ProtoMessage()               {}
-func (*ServerTabletMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{1} }
+func (*ServerTabletMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{3} }
 
 func (m *ServerTabletMetadata) GetStartKey() []byte {
 	if m != nil {
@@ -188,7 +282,7 @@ type ColumnFamilyMetadata struct {
 func (m *ColumnFamilyMetadata) Reset()                    { *m = ColumnFamilyMetadata{} }
 func (m *ColumnFamilyMetadata) String() string            { return proto.CompactTextString(m) }
 func (*ColumnFamilyMetadata) ProtoMessage()               {}
-func (*ColumnFamilyMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{2} }
+func (*ColumnFamilyMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{4} }
 
 func (m *ColumnFamilyMetadata) GetName() string {
 	if m != nil {
@@ -226,12 +320,24 @@ type TableMetadata struct {
 	//
 	// Declaration of the type of data contained in the table.
 	DataUsage DataUsage `protobuf:"varint,7,opt,name=data_usage,json=dataUsage,enum=redcloud.DataUsage" json:"data_usage,omitempty"`
+	//
+	// Whether data nodes assign the timestamps of all writes to the table,
+	// including deletions and counter increments, rather than using the ones
+	// set by the clients. This protects against writes being lost due to
+	// skewed client clocks.
+	ServerTimestamps bool `protobuf:"varint,8,opt,name=server_timestamps,json=serverTimestamps" json:"server_timestamps,omitempty"`
+	//
+	// Compression applied to the blocks of rows written to sstables of the
+	// table. Each block records its own compression, so changes only take
+	// effect for sstables written afterwards; existing sstables remain
+	// readable with whichever compression they were written.
+	Compression Compression `protobuf:"varint,9,opt,name=compression,enum=redcloud.Compression" json:"compression,omitempty"`
 }
 
 func (m *TableMetadata) Reset()                    { *m = TableMetadata{} }
 func (m *TableMetadata) String() string            { return proto.CompactTextString(m) }
 func (*TableMetadata) ProtoMessage()               {}
-func (*TableMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{3} }
+func (*TableMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{5} }
 
 func (m *TableMetadata) GetName() string {
 	if m != nil {
@@ -282,6 +388,20 @@ func (m *TableMetadata) GetDataUsage() DataUsage {
 	return DataUsage_UNKNOWN
 }
 
+func (m *TableMetadata) GetServerTimestamps() bool {
+	if m != nil {
+		return m.ServerTimestamps
+	}
+	return false
+}
+
+func (m *TableMetadata) GetCompression() Compression {
+	if m != nil {
+		return m.Compression
+	}
+	return Compression_UNCOMPRESSED
+}
+
 //
 // ServerTableMetadata holds Server-side table metadata for redcloud tables.
 type ServerTableMetadata struct {
@@ -298,7 +418,7 @@ type ServerTableMetadata struct {
 func (m *ServerTableMetadata) Reset()                    { *m = ServerTableMetadata{} }
 func (m *ServerTableMetadata) String() string            { return proto.CompactTextString(m) }
 func (*ServerTableMetadata) ProtoMessage()               {}
-func (*ServerTableMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{4} }
+func (*ServerTableMetadata) Descriptor() ([]byte, []int) { return fileDescriptor2, []int{6} }
 
 func (m *ServerTableMetadata) GetName() string {
 	if m != nil {
@@ -323,6 +443,8 @@ func (m *ServerTableMetadata) GetTablet() []*ServerTabletMetadata {
 
 func init() {
 	proto.RegisterType((*SSTablePathDescription)(nil), "redcloud.SSTablePathDescription")
+	proto.RegisterType((*BloomFilterData)(nil), "redcloud.BloomFilterData")
+	proto.RegisterType((*KeyIndexData)(nil), "redcloud.KeyIndexData")
 	proto.RegisterType((*ServerTabletMetadata)(nil), "redcloud.ServerTabletMetadata")
 	proto.RegisterType((*ColumnFamilyMetadata)(nil), "redcloud.ColumnFamilyMetadata")
 	proto.RegisterType((*TableMetadata)(nil), "redcloud.TableMetadata")
@@ -333,42 +455,52 @@ func init() {
 func init() { proto.RegisterFile("metadata.proto", fileDescriptor2) }
 
 var fileDescriptor2 = []byte{
-	// 579 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0x4d, 0x4f, 0xdb, 0x40,
-	0x10, 0xad, 0x93, 0x90, 0xe0, 0x49, 0xa0, 0xd1, 0x82, 0x20, 0x6a, 0x55, 0xea, 0xba, 0x52, 0x65,
-	0xa1, 0x8a, 0x43, 0x5a, 0xf5, 0x9e, 0x42, 0x90, 0x5c, 0x8a, 0x83, 0xd6, 0x81, 0xf6, 0xb6, 0x5a,
-	0xf0, 0x02, 0xa6, 0xfe, 0xd2, 0xee, 0x06, 0x01, 0xff, 0xa3, 0xbf, 0xa1, 0x87, 0xfe, 0x82, 0xfe,
-	0xbb, 0x6a, 0xc7, 0x86, 0x84, 0x10, 0x71, 0x1b, 0xbd, 0xf7, 0x76, 0xf4, 0xe6, 0xcd, 0x68, 0x61,
-	0x35, 0x15, 0x9a, 0x47, 0x5c, 0xf3, 0x9d, 0x42, 0xe6, 0x3a, 0x27, 0xcb, 0x52, 0x44, 0x67, 0x49,
-	0x3e, 0x89, 0xdc, 0xbf, 0x35, 0xd8, 0x08, 0xc3, 0x31, 0x3f, 0x4d, 0xc4, 0x11, 0xd7, 0x97, 0x7b,
-	0x42, 0x9d, 0xc9, 0xb8, 0xd0, 0x71, 0x9e, 0x91, 0xf7, 0xb0, 0x72, 0x96, 0x27, 0x93, 0x34, 0x63,
-	0xe7, 0x3c, 0x8d, 0x93, 0xdb, 0x9e, 0xe5, 0x58, 0x9e, 0x4d, 0x3b, 0x25, 0xb8, 0x8f, 0x18, 0xf9,
-	0x08, 0x24, 0xe5, 0x57, 0xb9, 0x64, 0x4a, 0x69, 0xd3, 0x84, 0x15, 0x5c, 0x5f, 0xf6, 0x6a, 0xa8,
-	0xec, 0x22, 0x13, 0x96, 0x84, 0xe9, 0x8e, 0xea, 0x38, 0x9b, 0x57, 0xd7, 0x2b, 0xb5, 0x61, 0x66,
-	0xd5, 0x9f, 0x61, 0x43, 0x8a, 0x44, 0x5c, 0xf3, 0x4c, 0xb3, 0xab, 0x7c, 0x22, 0x33, 0x9e, 0xe0,
-	0x03, 0xd5, 0x6b, 0x38, 0x75, 0xcf, 0xa6, 0xeb, 0xf7, 0xec, 0xb7, 0x92, 0x34, 0x8f, 0xd4, 0x53,
-	0x47, 0x2a, 0xbe, 0x13, 0xbd, 0x25, 0xc7, 0xf2, 0xea, 0x8f, 0x1d, 0x85, 0xf1, 0x9d, 0x78, 0xea,
-	0x08, 0xd5, 0xcd, 0x4a, 0x3d, 0xe3, 0xc8, 0xa8, 0xdd, 0x7f, 0x16, 0xac, 0x87, 0x42, 0x5e, 0x0b,
-	0x89, 0x89, 0xe9, 0xc3, 0x2a, 0x56, 0xf2, 0x1a, 0x6c, 0xa5, 0xb9, 0xd4, 0xec, 0x97, 0x28, 0x73,
-	0xea, 0xd0, 0x65, 0x04, 0x0e, 0xc4, 0x2d, 0xd9, 0x84, 0x96, 0xc8, 0x22, 0xa4, 0x6a, 0x48, 0x35,
-	0x45, 0x16, 0x19, 0x82, 0x40, 0xe3, 0x32, 0x57, 0xba, 0x0a, 0x00, 0x6b, 0x83, 0x15, 0xb9, 0xd4,
-	0xbd, 0x86, 0x63, 0x79, 0x4b, 0x14, 0x6b, 0xb2, 0x0b, 0x9d, 0x47, 0x81, 0x2d, 0x39, 0x75, 0xaf,
-	0xdd, 0x77, 0x76, 0xee, 0xb7, 0xb8, 0xb3, 0x78, 0x83, 0xb4, 0xad, 0xa6, 0x69, 0xba, 0xdb, 0xb0,
-	0xbe, 0x3b, 0xb3, 0xb9, 0x07, 0xeb, 0x04, 0x1a, 0x19, 0x4f, 0x45, 0xb5, 0x5d, 0xac, 0xdd, 0x3f,
-	0x35, 0x58, 0xc1, 0x8e, 0xcf, 0xa9, 0xc8, 0x1b, 0x00, 0x55, 0x24, 0xb1, 0x2e, 0x33, 0xab, 0x61,
-	0x66, 0x36, 0x22, 0x18, 0xed, 0x3b, 0xe8, 0xa4, 0xfc, 0x86, 0x5d, 0x0b, 0xa9, 0xe2, 0x3c, 0x53,
-	0x38, 0x65, 0x9d, 0xb6, 0x53, 0x7e, 0x73, 0x52, 0x41, 0xe4, 0x03, 0xbc, 0x9c, 0x91, 0x30, 0x7e,
-	0x21, 0x70, 0xee, 0x3a, 0x5d, 0x99, 0xaa, 0x06, 0x17, 0x82, 0xbc, 0x85, 0xb6, 0x19, 0x9c, 0x15,
-	0x52, 0x9c, 0xc7, 0x37, 0xb8, 0x4c, 0x9b, 0x82, 0x81, 0x8e, 0x10, 0x21, 0xbb, 0xf3, 0xb7, 0xda,
-	0xc4, 0x88, 0xb6, 0xa6, 0x11, 0x2d, 0x9a, 0x7d, 0xee, 0x96, 0xfb, 0x00, 0x06, 0x65, 0x13, 0x65,
-	0x8c, 0xb4, 0x1c, 0xcb, 0x5b, 0xed, 0xaf, 0x4d, 0x3b, 0xec, 0x71, 0xcd, 0x8f, 0x0d, 0x45, 0xed,
-	0xe8, 0xbe, 0x74, 0x7f, 0x5b, 0xb0, 0x36, 0x73, 0x11, 0xcf, 0xe6, 0xd5, 0x87, 0xe5, 0x72, 0x89,
-	0x69, 0x84, 0x69, 0xb5, 0xfb, 0x9b, 0xd3, 0xee, 0x8f, 0x9e, 0xd3, 0x16, 0x0a, 0x0f, 0x23, 0xf2,
-	0x05, 0x9a, 0x58, 0x9a, 0x23, 0x99, 0x9b, 0x68, 0xd1, 0x21, 0xd2, 0x4a, 0xbd, 0xfd, 0x13, 0xec,
-	0x07, 0xbf, 0xa4, 0x0d, 0xad, 0xe3, 0xe0, 0x20, 0x18, 0xfd, 0x08, 0xba, 0x2f, 0x88, 0x0b, 0x5b,
-	0xe1, 0x30, 0x08, 0xfd, 0xb1, 0x7f, 0x32, 0x64, 0x47, 0x43, 0x1a, 0x8e, 0x82, 0xc1, 0x77, 0xe6,
-	0x07, 0xfb, 0x23, 0x7a, 0x38, 0x18, 0xfb, 0xa3, 0xa0, 0x6b, 0x91, 0x57, 0xb0, 0xe1, 0x07, 0xe3,
-	0x21, 0x35, 0xcc, 0xd7, 0xe3, 0xd0, 0x0f, 0x86, 0x61, 0xc8, 0xf6, 0x06, 0xe3, 0x41, 0xb7, 0x76,
-	0xda, 0xc4, 0x2f, 0xe4, 0xd3, 0xff, 0x00, 0x00, 0x00, 0xff, 0xff, 0xeb, 0x0c, 0xcf, 0x91, 0x54,
-	0x04, 0x00, 0x00,
+	// 748 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0x5d, 0x53, 0x22, 0x47,
+	0x14, 0x0d, 0x0c, 0x02, 0x73, 0x07, 0x94, 0xb4, 0x06, 0xa7, 0x4c, 0xc5, 0x20, 0xa9, 0xa4, 0x28,
+	0x93, 0xf2, 0x81, 0xa4, 0x92, 0x67, 0x14, 0xac, 0x10, 0xe3, 0x60, 0xf5, 0xa0, 0xee, 0xdb, 0x54,
+	0xcb, 0x34, 0x32, 0xee, 0x7c, 0xd5, 0x74, 0x63, 0x81, 0xff, 0x61, 0x1f, 0xf7, 0xc7, 0xec, 0x0f,
+	0xdb, 0xf7, 0xad, 0xbe, 0x33, 0x7c, 0x6a, 0xf9, 0x76, 0x39, 0xf7, 0xf4, 0xed, 0xd3, 0xe7, 0x9e,
+	0x01, 0x76, 0x03, 0x2e, 0x99, 0xcb, 0x24, 0x3b, 0x8b, 0x93, 0x48, 0x46, 0xa4, 0x9c, 0x70, 0x77,
+	0xe4, 0x47, 0x53, 0xf7, 0xc8, 0x90, 0xf3, 0x98, 0x8b, 0x14, 0x6e, 0x7e, 0xcd, 0x43, 0xdd, 0xb6,
+	0x87, 0xec, 0xc1, 0xe7, 0x37, 0x4c, 0x4e, 0xba, 0x5c, 0x8c, 0x12, 0x2f, 0x96, 0x5e, 0x14, 0x92,
+	0x5f, 0xa0, 0x3a, 0x8a, 0xfc, 0x69, 0x10, 0x3a, 0x63, 0x16, 0x78, 0xfe, 0xdc, 0xcc, 0x35, 0x72,
+	0x2d, 0x9d, 0x56, 0x52, 0xf0, 0x12, 0x31, 0xf2, 0x07, 0x90, 0x80, 0x3d, 0x45, 0x89, 0x23, 0x84,
+	0x54, 0x43, 0x9c, 0x98, 0xc9, 0x89, 0x99, 0x47, 0x66, 0x0d, 0x3b, 0xb6, 0x90, 0x8b, 0xe9, 0xc8,
+	0xf6, 0xc2, 0x6d, 0xb6, 0x96, 0xb1, 0xbd, 0x70, 0x93, 0xfd, 0x17, 0xd4, 0x13, 0xee, 0xf3, 0x67,
+	0x16, 0x4a, 0xe7, 0x29, 0x9a, 0x26, 0x21, 0xf3, 0xf1, 0x80, 0x30, 0x0b, 0x0d, 0xad, 0xa5, 0xd3,
+	0x83, 0x45, 0xf7, 0xbf, 0xb4, 0xa9, 0x0e, 0x89, 0xd7, 0x8a, 0x84, 0xf7, 0xc2, 0xcd, 0x9d, 0x46,
+	0xae, 0xa5, 0x6d, 0x2a, 0xb2, 0xbd, 0x17, 0xfe, 0x5a, 0x11, 0xb2, 0x8b, 0x19, 0xdb, 0x0b, 0x37,
+	0xd9, 0xbf, 0xc2, 0xae, 0xcf, 0x84, 0x74, 0xa4, 0x17, 0x70, 0x21, 0x59, 0x10, 0x9b, 0x25, 0x64,
+	0x56, 0x15, 0x3a, 0x5c, 0x80, 0xe4, 0x04, 0x2a, 0x0b, 0xbd, 0x38, 0xae, 0x8c, 0x24, 0x23, 0xc3,
+	0xd4, 0xa4, 0x66, 0x17, 0xf6, 0xce, 0xfd, 0x28, 0x0a, 0x2e, 0x3d, 0x5f, 0xf2, 0xa4, 0xcb, 0x24,
+	0x23, 0x3f, 0x01, 0x84, 0xd3, 0xc0, 0x99, 0x30, 0x31, 0xe1, 0x02, 0xcd, 0xae, 0x52, 0x3d, 0x9c,
+	0x06, 0xff, 0x22, 0x40, 0x08, 0x14, 0x1e, 0x3c, 0x29, 0xd0, 0xdb, 0x0a, 0xc5, 0xba, 0x79, 0x0f,
+	0x95, 0x2b, 0x3e, 0xef, 0x87, 0x2e, 0x9f, 0xe1, 0x88, 0x1a, 0x68, 0x1f, 0xb9, 0x5a, 0x94, 0xd6,
+	0xaa, 0x50, 0x55, 0x92, 0x3a, 0x14, 0xa3, 0xf1, 0x58, 0x70, 0x69, 0xe6, 0x1b, 0x5a, 0x4b, 0xa3,
+	0xd9, 0x2f, 0x75, 0x99, 0x8c, 0xe4, 0x42, 0xa0, 0x86, 0x02, 0x75, 0x44, 0x50, 0xde, 0x97, 0x1c,
+	0x1c, 0xd8, 0x3c, 0x79, 0xe6, 0x09, 0x46, 0x43, 0x5e, 0x67, 0x61, 0x22, 0x3f, 0x82, 0x2e, 0x24,
+	0x4b, 0xa4, 0x93, 0xde, 0xa3, 0xa4, 0x94, 0x11, 0xb8, 0xe2, 0x73, 0x72, 0x08, 0x25, 0x1e, 0xba,
+	0xd8, 0x4a, 0x55, 0x16, 0x79, 0xe8, 0xaa, 0x06, 0x81, 0xc2, 0x24, 0x12, 0x32, 0xdb, 0x34, 0xd6,
+	0x0a, 0x8b, 0xa3, 0x44, 0x9a, 0x85, 0x46, 0xae, 0xb5, 0x43, 0xb1, 0x26, 0x17, 0x50, 0xd9, 0x48,
+	0xc6, 0x4e, 0x43, 0x6b, 0x19, 0xed, 0xc6, 0xd9, 0x22, 0xbb, 0x67, 0x6f, 0x47, 0x95, 0x1a, 0x62,
+	0x15, 0x9b, 0xe6, 0x29, 0x1c, 0x5c, 0xac, 0x45, 0x74, 0x29, 0x9d, 0x40, 0x21, 0x64, 0x01, 0xcf,
+	0x62, 0x8c, 0x75, 0xf3, 0x93, 0x06, 0x55, 0x9c, 0xf8, 0x1e, 0x4b, 0x99, 0x25, 0x62, 0xdf, 0x93,
+	0xa9, 0x59, 0xf9, 0xd4, 0x2c, 0x44, 0x30, 0x15, 0x27, 0x50, 0x09, 0xd8, 0xcc, 0x79, 0xe6, 0x89,
+	0xf0, 0xa2, 0x50, 0x64, 0x6e, 0x1a, 0x01, 0x9b, 0xdd, 0x65, 0x10, 0xf9, 0x0d, 0xf6, 0xd6, 0x28,
+	0x0e, 0x7b, 0xe4, 0xf8, 0x6e, 0x8d, 0x56, 0x57, 0xac, 0xce, 0x23, 0x27, 0x3f, 0x83, 0xa1, 0x1e,
+	0xee, 0xc4, 0x09, 0x1f, 0x7b, 0x33, 0x4c, 0xad, 0x4e, 0x41, 0x41, 0x37, 0x88, 0x90, 0x8b, 0xed,
+	0x8f, 0xb2, 0x88, 0x16, 0x1d, 0xaf, 0x2c, 0x7a, 0xeb, 0xed, 0x5b, 0x1f, 0x6d, 0x1b, 0x40, 0xa1,
+	0xce, 0x54, 0x28, 0x21, 0x2a, 0xc2, 0xbb, 0xed, 0xfd, 0xd5, 0x04, 0x15, 0xa5, 0x5b, 0xd5, 0xa2,
+	0xba, 0xbb, 0x28, 0xc9, 0xef, 0xf0, 0xbd, 0xc0, 0x40, 0xac, 0xc2, 0x2f, 0x30, 0xd8, 0x65, 0x5a,
+	0x4b, 0x1b, 0xcb, 0xfc, 0x0b, 0xf2, 0x0f, 0x18, 0xa3, 0x28, 0x88, 0x13, 0x2e, 0xd4, 0xc3, 0x4c,
+	0x1d, 0x6f, 0xf8, 0x61, 0x5d, 0xe3, 0xb2, 0x49, 0xd7, 0x99, 0xcd, 0xcf, 0x39, 0xd8, 0x5f, 0xcb,
+	0xdd, 0xbb, 0x5b, 0x69, 0x43, 0x39, 0x8d, 0x4a, 0xe0, 0xe2, 0x4e, 0x8c, 0xf6, 0xe1, 0xea, 0x86,
+	0x8d, 0xe3, 0xb4, 0x84, 0xc4, 0x6b, 0x97, 0xfc, 0x0d, 0x45, 0x2c, 0x55, 0x14, 0xb7, 0x7c, 0x7b,
+	0x2b, 0xee, 0x34, 0x63, 0x9f, 0x7e, 0x00, 0x7d, 0xe9, 0x0a, 0x31, 0xa0, 0x74, 0x6b, 0x5d, 0x59,
+	0x83, 0x7b, 0xab, 0xf6, 0x1d, 0x69, 0xc2, 0xb1, 0xdd, 0xb3, 0xec, 0xfe, 0xb0, 0x7f, 0xd7, 0x73,
+	0x6e, 0x7a, 0xd4, 0x1e, 0x58, 0x9d, 0xff, 0x9d, 0xbe, 0x75, 0x39, 0xa0, 0xd7, 0x9d, 0x61, 0x7f,
+	0x60, 0xd5, 0x72, 0xe4, 0x08, 0xea, 0x7d, 0x6b, 0xd8, 0xa3, 0xaa, 0x73, 0x7e, 0x6b, 0xf7, 0xad,
+	0x9e, 0x6d, 0x3b, 0xdd, 0xce, 0xb0, 0x53, 0xcb, 0x3f, 0x14, 0xf1, 0x7f, 0xf8, 0xcf, 0x6f, 0x01,
+	0x00, 0x00, 0xff, 0xff, 0x4e, 0x80, 0x28, 0x9f, 0xb0, 0x05, 0x00, 0x00,
 }
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"hash/fnv"
+	"net/url"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/recordio"
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+Number of bits used per key and number of bits set for each key. Together,
+they yield a false positive rate of about 1%.
+*/
+const bloomFilterBitsPerKey = 10
+const bloomFilterNumHashes = 7
+
+/*
+BloomFilter determines whether a key may be contained in an sstable. If the
+filter says that a key is not contained, the sstable doesn't need to be read
+at all. A nil BloomFilter may contain all keys.
+*/
+type BloomFilter struct {
+	bits      []byte
+	numHashes uint32
+}
+
+/*
+BloomFilterBuilder collects the keys written to an sstable in order to build
+a BloomFilter for them once the number of keys is known.
+*/
+type BloomFilterBuilder struct {
+	hashes []uint64
+}
+
+/*
+hashKey calculates the hash of the key all bits of the filter are derived
+from.
+*/
+func hashKey(key []byte) uint64 {
+	var h = fnv.New64a()
+
+	h.Write(key)
+	return h.Sum64()
+}
+
+/*
+bitPositions determines the positions of the bits set for the key with the
+specified hash in a filter of numBits bits. The positions are derived from
+the two halves of the hash, so the key only needs to be hashed once.
+*/
+func bitPositions(hash uint64, numHashes uint32, numBits uint64) []uint64 {
+	var rv = make([]uint64, numHashes)
+	var h1 = hash & 0xffffffff
+	var h2 = hash >> 32
+	var i uint64
+
+	for i = 0; i < uint64(numHashes); i++ {
+		rv[i] = (h1 + i*h2) % numBits
+	}
+
+	return rv
+}
+
+/*
+NewBloomFilterBuilder creates a BloomFilterBuilder without any keys.
+*/
+func NewBloomFilterBuilder() *BloomFilterBuilder {
+	return new(BloomFilterBuilder)
+}
+
+/*
+Add records the key as being contained in the sstable.
+*/
+func (b *BloomFilterBuilder) Add(key []byte) {
+	b.hashes = append(b.hashes, hashKey(key))
+}
+
+/*
+Build creates a BloomFilter containing all keys added so far.
+*/
+func (b *BloomFilterBuilder) Build() *BloomFilter {
+	var numBits = uint64(len(b.hashes)*bloomFilterBitsPerKey+7) / 8 * 8
+	var rv *BloomFilter
+	var hash, pos uint64
+
+	// Avoid dividing by zero for empty sstables.
+	if numBits == 0 {
+		numBits = 8
+	}
+
+	rv = &BloomFilter{
+		bits:      make([]byte, numBits/8),
+		numHashes: bloomFilterNumHashes,
+	}
+
+	for _, hash = range b.hashes {
+		for _, pos = range bitPositions(hash, rv.numHashes, numBits) {
+			rv.bits[pos/8] |= 1 << (pos % 8)
+		}
+	}
+
+	return rv
+}
+
+/*
+MayContain determines whether the key may be contained in the sstable. If
+false is returned, the key is definitely not contained.
+*/
+func (f *BloomFilter) MayContain(key []byte) bool {
+	var pos uint64
+
+	if f == nil || len(f.bits) == 0 {
+		return true
+	}
+
+	for _, pos = range bitPositions(
+		hashKey(key), f.numHashes, uint64(len(f.bits))*8) {
+		if f.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+WriteBloomFilter writes the BloomFilter to the bloom filter file of the
+sstable at the specified path.
+*/
+func WriteBloomFilter(ctx context.Context, path string,
+	filter *BloomFilter) error {
+	var u *url.URL
+	var out filesystem.WriteCloser
+	var err error
+
+	if u, err = url.Parse(path + ".bloom"); err != nil {
+		return err
+	}
+
+	if out, err = filesystem.OpenWriter(ctx, u); err != nil {
+		return err
+	}
+
+	if err = recordio.NewRecordWriter(out).WriteMessage(
+		ctx, &redcloud.BloomFilterData{
+			NumHashes: filter.numHashes,
+			Bits:      filter.bits,
+		}); err != nil {
+		out.Close(ctx)
+		filesystem.Remove(ctx, u)
+		return err
+	}
+
+	return out.Close(ctx)
+}
+
+/*
+ReadBloomFilter reads the BloomFilter from the bloom filter file of the
+sstable at the specified path.
+*/
+func ReadBloomFilter(ctx context.Context, path string) (*BloomFilter, error) {
+	var u *url.URL
+	var in filesystem.ReadCloser
+	var data redcloud.BloomFilterData
+	var err error
+
+	if u, err = url.Parse(path + ".bloom"); err != nil {
+		return nil, err
+	}
+
+	if in, err = filesystem.OpenReader(ctx, u); err != nil {
+		return nil, err
+	}
+	defer in.Close(ctx)
+
+	if err = recordio.NewRecordReader(in).ReadMessage(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	return &BloomFilter{
+		bits:      data.Bits,
+		numHashes: data.NumHashes,
+	}, nil
+}
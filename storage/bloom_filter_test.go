@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	var builder = NewBloomFilterBuilder()
+	var filter *BloomFilter
+	var numFalsePositives int
+	var i int
+
+	for i = 0; i < 1000; i++ {
+		builder.Add([]byte(fmt.Sprintf("key%d", i)))
+	}
+	filter = builder.Build()
+
+	for i = 0; i < 1000; i++ {
+		if !filter.MayContain([]byte(fmt.Sprintf("key%d", i))) {
+			t.Errorf("Filter doesn't contain added key key%d", i)
+		}
+	}
+
+	for i = 0; i < 1000; i++ {
+		if filter.MayContain([]byte(fmt.Sprintf("other%d", i))) {
+			numFalsePositives++
+		}
+	}
+
+	if numFalsePositives > 50 {
+		t.Errorf("Too many false positives: %d of 1000", numFalsePositives)
+	}
+}
+
+func TestBloomFilterEmpty(t *testing.T) {
+	var filter *BloomFilter
+
+	if !filter.MayContain([]byte("key")) {
+		t.Error("Missing filter should contain all keys")
+	}
+
+	filter = NewBloomFilterBuilder().Build()
+	if filter.MayContain([]byte("key")) {
+		t.Error("Filter without keys contains a key")
+	}
+}
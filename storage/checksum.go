@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"hash/crc32"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+/*
+ChecksumRecord creates the record to store in a journal for the row. The
+key and column sets of the row are encoded once and stored along with the
+checksum of exactly these bytes, so verifying the checksum doesn't depend on
+encoding the row the same way again. The row itself is left unchanged.
+*/
+func ChecksumRecord(cf *redcloud.ColumnFamily) (
+	*redcloud.ColumnFamily, error) {
+	var data []byte
+	var err error
+
+	if data, err = proto.Marshal(&redcloud.ColumnFamily{
+		Key:       cf.Key,
+		ColumnSet: cf.ColumnSet,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &redcloud.ColumnFamily{
+		Checksum:        crc32.Checksum(data, castagnoliTable),
+		ChecksummedData: data,
+		HasChecksum:     true,
+	}, nil
+}
+
+/*
+VerifyChecksum verifies the checksum of a record read from a journal and
+restores the key and column sets of the row stored in it. Returns
+common.ErrChecksumMismatch if the record has been corrupted. Records without
+a checksum, e.g. those written before checksums were introduced or rows
+read from memtables, are accepted as they are.
+*/
+func VerifyChecksum(cf *redcloud.ColumnFamily) error {
+	var decoded redcloud.ColumnFamily
+	var err error
+
+	if !cf.HasChecksum {
+		// The flag may have been lost, but the data can't be trusted then.
+		if len(cf.ChecksummedData) > 0 {
+			return common.ErrChecksumMismatch
+		}
+		return nil
+	}
+
+	if crc32.Checksum(cf.ChecksummedData, castagnoliTable) != cf.Checksum {
+		return common.ErrChecksumMismatch
+	}
+
+	if err = proto.Unmarshal(cf.ChecksummedData, &decoded); err != nil {
+		return err
+	}
+
+	cf.Key = decoded.Key
+	cf.ColumnSet = decoded.ColumnSet
+	cf.Checksum = 0
+	cf.ChecksummedData = nil
+	cf.HasChecksum = false
+	return nil
+}
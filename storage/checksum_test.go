@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestChecksum(t *testing.T) {
+	var expected = map[string]error{
+		"intact":    nil,
+		"corrupted": common.ErrChecksumMismatch,
+		"flag lost": common.ErrChecksumMismatch,
+		"missing":   nil,
+	}
+	var name string
+	var want error
+
+	for name, want = range expected {
+		var cf = singleColumnRow("row", "col", dataColumn(1, 0, "data"))
+		var orig = proto.Clone(cf).(*redcloud.ColumnFamily)
+		var record = cf
+		var err error
+
+		if name != "missing" {
+			if record, err = ChecksumRecord(cf); err != nil {
+				t.Errorf("Error checksumming %s row: %s", name, err)
+				continue
+			}
+			if !record.HasChecksum || len(record.ColumnSet) > 0 {
+				t.Errorf("Unexpected record for %s row: %v", name, record)
+			}
+			if !proto.Equal(cf, orig) {
+				t.Errorf("Row modified by checksumming %s row: %v", name, cf)
+			}
+		}
+
+		switch name {
+		case "corrupted":
+			record.ChecksummedData[len(record.ChecksummedData)-1] ^= 1
+		case "flag lost":
+			record.HasChecksum = false
+		}
+
+		if err = VerifyChecksum(record); err != want {
+			t.Errorf("Unexpected result verifying %s row: got %v, want %v",
+				name, err, want)
+		}
+		if want == nil && !proto.Equal(record, orig) {
+			t.Errorf("Unexpected row after verifying %s row: got %v, want %v",
+				name, record, orig)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+ColumnSelector describes the columns of a row a lookup is interested in: a
+number of individual columns, ranges of column names, or both. Columns
+starting with a prefix can be selected through PrefixRange, all columns
+through AllColumns.
+*/
+type ColumnSelector struct {
+	// Names of the selected individual columns, sorted.
+	columns []string
+
+	/*
+		Ranges of selected column names. An empty end column selects all
+		columns following the start column.
+	*/
+	ranges []*redcloud.ColumnRange
+}
+
+/*
+NewColumnSelector creates a ColumnSelector selecting all the named columns
+and all columns in any of the ranges. A selector without any columns or
+ranges matches nothing.
+*/
+func NewColumnSelector(columns []string,
+	ranges ...*redcloud.ColumnRange) *ColumnSelector {
+	var rv = &ColumnSelector{
+		columns: append([]string{}, columns...),
+	}
+	var cr *redcloud.ColumnRange
+
+	sort.Strings(rv.columns)
+
+	for _, cr = range ranges {
+		if cr != nil {
+			rv.ranges = append(rv.ranges, cr)
+		}
+	}
+
+	return rv
+}
+
+/*
+AllColumns returns a ColumnRange covering all possible column names.
+*/
+func AllColumns() *redcloud.ColumnRange {
+	return &redcloud.ColumnRange{}
+}
+
+/*
+PrefixRange returns a ColumnRange covering all column names starting with
+the specified prefix.
+*/
+func PrefixRange(prefix string) *redcloud.ColumnRange {
+	var end = []byte(prefix)
+
+	/*
+		The first name not starting with the prefix is found by incrementing
+		the last byte of the prefix which can be incremented, dropping all
+		bytes after it. If there is no such byte, the range is unbounded.
+	*/
+	for len(end) > 0 && end[len(end)-1] == 0xff {
+		end = end[:len(end)-1]
+	}
+	if len(end) > 0 {
+		end[len(end)-1]++
+	}
+
+	return &redcloud.ColumnRange{
+		StartColumn: prefix,
+		EndColumn:   string(end),
+	}
+}
+
+/*
+rangeContains determines whether the name is part of the column range.
+*/
+func rangeContains(cr *redcloud.ColumnRange, name string) bool {
+	return name >= cr.StartColumn &&
+		(len(cr.EndColumn) == 0 || name < cr.EndColumn)
+}
+
+/*
+Matches determines whether the column with the specified name is selected.
+*/
+func (s *ColumnSelector) Matches(name string) bool {
+	var cr *redcloud.ColumnRange
+	var off int
+
+	off = sort.SearchStrings(s.columns, name)
+	if off < len(s.columns) && s.columns[off] == name {
+		return true
+	}
+
+	for _, cr = range s.ranges {
+		if rangeContains(cr, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Overlaps determines whether any selected column is part of the range of
+column names from start to end. An empty end means that all columns
+following start are part of the range.
+*/
+func (s *ColumnSelector) Overlaps(start, end string) bool {
+	var cr *redcloud.ColumnRange
+	var off int
+
+	/*
+		s.columns[off] is the first selected column following the start of
+		the range, so it is the only one which needs to be checked.
+	*/
+	off = sort.SearchStrings(s.columns, start)
+	if off < len(s.columns) &&
+		(len(end) == 0 || s.columns[off] < end) {
+		return true
+	}
+
+	for _, cr = range s.ranges {
+		if (len(cr.EndColumn) == 0 || start < cr.EndColumn) &&
+			(len(end) == 0 || cr.StartColumn < end) {
+			return true
+		}
+	}
+
+	return false
+}
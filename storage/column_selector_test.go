@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+func TestPrefixRange(t *testing.T) {
+	var testdata = map[string]redcloud.ColumnRange{
+		"":             {},
+		"abc":          {StartColumn: "abc", EndColumn: "abd"},
+		"ab\xff":       {StartColumn: "ab\xff", EndColumn: "ac"},
+		"\xff\xff":     {StartColumn: "\xff\xff"},
+		"2018-01-\x00": {StartColumn: "2018-01-\x00", EndColumn: "2018-01-\x01"},
+	}
+	var prefix string
+	var expected redcloud.ColumnRange
+
+	for prefix, expected = range testdata {
+		var cr = PrefixRange(prefix)
+
+		if cr.StartColumn != expected.StartColumn ||
+			cr.EndColumn != expected.EndColumn {
+			t.Errorf("Unexpected range for prefix %q: %v (expected %v)",
+				prefix, cr, expected)
+		}
+	}
+}
+
+func TestColumnSelectorMatches(t *testing.T) {
+	var selector = NewColumnSelector([]string{"x", "a"},
+		&redcloud.ColumnRange{StartColumn: "c", EndColumn: "e"},
+		PrefixRange("p"))
+	var testdata = map[string]bool{
+		"a":  true,
+		"b":  false,
+		"c":  true,
+		"d9": true,
+		"e":  false,
+		"p":  true,
+		"pq": true,
+		"q":  false,
+		"x":  true,
+	}
+	var name string
+	var expected bool
+
+	for name, expected = range testdata {
+		if selector.Matches(name) != expected {
+			t.Errorf("Unexpected match result for %q: %v", name, !expected)
+		}
+	}
+
+	if NewColumnSelector(nil).Matches("a") {
+		t.Error("Empty selector matches a column")
+	}
+	if !NewColumnSelector(nil, AllColumns()).Matches("a") {
+		t.Error("Selector for all columns doesn't match a column")
+	}
+}
+
+func TestColumnSelectorOverlaps(t *testing.T) {
+	var selector = NewColumnSelector([]string{"b"},
+		&redcloud.ColumnRange{StartColumn: "m", EndColumn: "o"})
+	var testdata = map[redcloud.ColumnRange]bool{
+		{StartColumn: "a", EndColumn: "c"}: true,
+		{StartColumn: "c", EndColumn: "m"}: false,
+		{StartColumn: "c", EndColumn: "n"}: true,
+		{StartColumn: "n"}:                 true,
+		{StartColumn: "o"}:                 false,
+		{StartColumn: "a", EndColumn: "b"}: false,
+	}
+	var cr redcloud.ColumnRange
+	var expected bool
+
+	for cr, expected = range testdata {
+		if selector.Overlaps(cr.StartColumn, cr.EndColumn) != expected {
+			t.Errorf("Unexpected overlap result for %v: %v", cr, !expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+maxDecompressedBlockSize limits the size of the data a single sstable block
+may decompress to. Blocks are written once they exceed sstableBlockSize, so
+only blocks holding very large rows come anywhere near it; anything larger
+is rejected rather than read into memory.
+*/
+const maxDecompressedBlockSize = 256 << 20
+
+/*
+blockCompressor compresses the data written to it into the writer it has
+last been reset to, as implemented by flate.Writer and gzip.Writer.
+*/
+type blockCompressor interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+func newFlateWriter(level int) interface{} {
+	var w *flate.Writer
+
+	// Errors are only reported for invalid compression levels.
+	w, _ = flate.NewWriter(nil, level)
+	return w
+}
+
+/*
+compressorPools holds reusable compressors for each supported compression,
+since they are expensive to create.
+*/
+var compressorPools = map[redcloud.Compression]*sync.Pool{
+	redcloud.Compression_DEFLATE_FAST: &sync.Pool{New: func() interface{} {
+		return newFlateWriter(flate.BestSpeed)
+	}},
+	redcloud.Compression_DEFLATE_BEST: &sync.Pool{New: func() interface{} {
+		return newFlateWriter(flate.BestCompression)
+	}},
+	redcloud.Compression_GZIP: &sync.Pool{New: func() interface{} {
+		return gzip.NewWriter(nil)
+	}},
+}
+
+/*
+compressBlock compresses the encoded rows of an sstable block with the
+specified compression. Returns the data to store along with the compression
+actually applied: data which wouldn't get any smaller is stored
+uncompressed.
+*/
+func compressBlock(data []byte, compression redcloud.Compression) (
+	[]byte, redcloud.Compression, error) {
+	var pool *sync.Pool
+	var w blockCompressor
+	var buf bytes.Buffer
+	var ok bool
+	var err error
+
+	if compression == redcloud.Compression_UNCOMPRESSED {
+		return data, compression, nil
+	}
+
+	if pool, ok = compressorPools[compression]; !ok {
+		return nil, compression, fmt.Errorf(
+			"Unsupported compression %s", compression)
+	}
+
+	w = pool.Get().(blockCompressor)
+	defer pool.Put(w)
+
+	w.Reset(&buf)
+	if _, err = w.Write(data); err != nil {
+		return nil, compression, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, compression, err
+	}
+
+	if buf.Len() >= len(data) {
+		return data, redcloud.Compression_UNCOMPRESSED, nil
+	}
+
+	return buf.Bytes(), compression, nil
+}
+
+/*
+readLimited reads all data from r, failing if there's more than limit bytes
+of it. Without a limit, a corrupted or forged block could make us read any
+amount of data into memory.
+*/
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	var data []byte
+	var err error
+
+	if data, err = ioutil.ReadAll(io.LimitReader(r, limit+1)); err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("Data exceeds the limit of %d bytes", limit)
+	}
+
+	return data, nil
+}
+
+/*
+decompressBlock restores the encoded rows of an sstable block compressed by
+compressBlock. The checksum of the block has to be verified before it is
+decompressed. Blocks decompressing to more than maxDecompressedBlockSize
+are rejected.
+*/
+func decompressBlock(data []byte, compression redcloud.Compression) (
+	[]byte, error) {
+	var r io.ReadCloser
+	var err error
+
+	switch compression {
+	case redcloud.Compression_UNCOMPRESSED:
+		return data, nil
+	case redcloud.Compression_DEFLATE_FAST, redcloud.Compression_DEFLATE_BEST:
+		r = flate.NewReader(bytes.NewReader(data))
+	case redcloud.Compression_GZIP:
+		if r, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported compression %s", compression)
+	}
+	defer r.Close()
+
+	return readLimited(r, maxDecompressedBlockSize)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+func TestCompressBlock(t *testing.T) {
+	var compressions = []redcloud.Compression{
+		redcloud.Compression_UNCOMPRESSED,
+		redcloud.Compression_DEFLATE_FAST,
+		redcloud.Compression_DEFLATE_BEST,
+		redcloud.Compression_GZIP,
+	}
+	var data = []byte(strings.Repeat("text ", 100))
+	var compression redcloud.Compression
+
+	for _, compression = range compressions {
+		var compressed, decompressed []byte
+		var applied redcloud.Compression
+		var err error
+
+		if compressed, applied, err = compressBlock(
+			data, compression); err != nil {
+			t.Errorf("Error compressing with %s: %s", compression, err)
+			continue
+		}
+		if applied != compression {
+			t.Errorf("Unexpected compression: got %s, want %s", applied,
+				compression)
+		}
+		if compression != redcloud.Compression_UNCOMPRESSED &&
+			len(compressed) >= len(data) {
+			t.Errorf("Data not smaller with %s: %d bytes, uncompressed %d",
+				compression, len(compressed), len(data))
+		}
+
+		if decompressed, err = decompressBlock(
+			compressed, applied); err != nil {
+			t.Errorf("Error decompressing %s: %s", compression, err)
+			continue
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Errorf("Unexpected data after %s round trip: %q", compression,
+				decompressed)
+		}
+	}
+}
+
+func TestCompressBlockIncompressible(t *testing.T) {
+	var data = []byte("a")
+	var compressed []byte
+	var applied redcloud.Compression
+	var err error
+
+	if compressed, applied, err = compressBlock(
+		data, redcloud.Compression_DEFLATE_BEST); err != nil {
+		t.Errorf("Error compressing: %s", err)
+	}
+	if applied != redcloud.Compression_UNCOMPRESSED ||
+		!bytes.Equal(compressed, data) {
+		t.Errorf("Data not left uncompressed: %s %q", applied, compressed)
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	var cases = []struct {
+		size int
+		ok   bool
+	}{
+		{0, true},
+		{10, true},
+		{11, false},
+		{1000, false},
+	}
+	var i int
+
+	for i = range cases {
+		var data []byte
+		var err error
+
+		data, err = readLimited(
+			bytes.NewReader(make([]byte, cases[i].size)), 10)
+		if (err == nil) != cases[i].ok {
+			t.Errorf("Unexpected result reading %d bytes: %v",
+				cases[i].size, err)
+		}
+		if err == nil && len(data) != cases[i].size {
+			t.Errorf("Read %d of %d bytes", len(data), cases[i].size)
+		}
+	}
+}
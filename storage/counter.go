@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"encoding/binary"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+EncodeCounter encodes the counter value or delta as the content of a
+COUNTER column.
+*/
+func EncodeCounter(value int64) []byte {
+	var rv = make([]byte, 8)
+	binary.BigEndian.PutUint64(rv, uint64(value))
+	return rv
+}
+
+/*
+DecodeCounter decodes the content of a COUNTER column. Content which is not
+a valid counter is treated as 0.
+*/
+func DecodeCounter(content []byte) int64 {
+	if len(content) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(content))
+}
+
+/*
+CollapseCounters combines the COUNTER versions of each column in the column
+family into a single version holding their sum, with the timestamp of the
+newest of them. Versions covered by tombstones in the column family are left
+alone, since they must not contribute to the sum. Versions with a TTL are
+only combined if includeExpiring is set, since the value of the counter
+would otherwise change once they expire; this is only safe if the result is
+not written back.
+*/
+func CollapseCounters(cf *redcloud.ColumnFamily, includeExpiring bool) {
+	var rangeTombstones = findRangeTombstones(cf)
+	var cset *redcloud.ColumnSet
+
+	for _, cset = range cf.ColumnSet {
+		var cols []*redcloud.Column
+		var col *redcloud.Column
+		var sum *redcloud.Column
+		var before int64
+		var deleted bool
+
+		before, deleted = deletedBefore(cset, rangeTombstones)
+
+		for _, col = range cset.Column {
+			if col.Type != redcloud.Column_COUNTER ||
+				(deleted && col.Timestamp <= before) ||
+				(col.Ttl > 0 && !includeExpiring) {
+				cols = append(cols, col)
+				continue
+			}
+
+			/*
+				Versions are ordered newest first, so the sum takes the
+				place of the newest delta.
+			*/
+			if sum == nil {
+				sum = &redcloud.Column{
+					Type:      redcloud.Column_COUNTER,
+					Timestamp: col.Timestamp,
+				}
+				cols = append(cols, sum)
+			}
+			sum.Content = EncodeCounter(
+				DecodeCounter(sum.Content) + DecodeCounter(col.Content))
+		}
+
+		cset.Column = cols
+	}
+}
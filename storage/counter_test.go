@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+func counterColumn(timestamp, ttl, delta int64) *redcloud.Column {
+	return &redcloud.Column{
+		Type:      redcloud.Column_COUNTER,
+		Timestamp: timestamp,
+		Ttl:       ttl,
+		Content:   EncodeCounter(delta),
+	}
+}
+
+func TestEncodeCounter(t *testing.T) {
+	var values = []int64{0, 1, -1, 1 << 40, -(1 << 62)}
+	var value int64
+
+	for _, value = range values {
+		if DecodeCounter(EncodeCounter(value)) != value {
+			t.Errorf("Counter value %d changed to %d when encoding", value,
+				DecodeCounter(EncodeCounter(value)))
+		}
+	}
+
+	if DecodeCounter([]byte("foo")) != 0 {
+		t.Errorf("Invalid counter decoded as %d, expected 0",
+			DecodeCounter([]byte("foo")))
+	}
+}
+
+func TestCollapseCounters(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Deltas are summed up into the newest one.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				counterColumn(30, 0, 5), counterColumn(20, 0, -2),
+				counterColumn(10, 0, 1)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{counterColumn(30, 0, 4)}},
+		}},
+		// Deleted deltas and those with a TTL are left alone.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				counterColumn(40, 0, 5), counterColumn(30, 100, 2),
+				counterColumn(25, 0, 1), tombstoneColumn(20),
+				counterColumn(10, 0, 7)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				counterColumn(40, 0, 6), counterColumn(30, 100, 2),
+				tombstoneColumn(20), counterColumn(10, 0, 7)}},
+		}},
+		// Range tombstones are taken into account as well.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "", Column: []*redcloud.Column{
+				rangeTombstoneColumn(20, "")}},
+			{Name: "a", Column: []*redcloud.Column{
+				counterColumn(30, 0, 1), counterColumn(25, 0, 1),
+				counterColumn(10, 0, 7), counterColumn(5, 0, 7)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "", Column: []*redcloud.Column{
+				rangeTombstoneColumn(20, "")}},
+			{Name: "a", Column: []*redcloud.Column{
+				counterColumn(30, 0, 2), counterColumn(10, 0, 7),
+				counterColumn(5, 0, 7)}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		CollapseCounters(in, false)
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected counter collapse result: %v (expected %v)",
+				in, expected)
+		}
+	}
+
+	in = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+		{Name: "a", Column: []*redcloud.Column{
+			counterColumn(30, 100, 2), counterColumn(20, 0, 3)}},
+	}}
+	expected = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+		{Name: "a", Column: []*redcloud.Column{counterColumn(30, 0, 5)}},
+	}}
+	CollapseCounters(in, true)
+	if !proto.Equal(in, expected) {
+		t.Errorf("Unexpected counter collapse result: %v (expected %v)",
+			in, expected)
+	}
+}
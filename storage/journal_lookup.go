@@ -4,7 +4,6 @@ import (
 	"context"
 	"io"
 	"net/url"
-	"sort"
 
 	"github.com/childoftheuniverse/filesystem"
 	"github.com/childoftheuniverse/recordio"
@@ -45,16 +44,16 @@ func init() {
 
 /*
 LookupInJournal finds all records in the specified key range in the specified
-file matching the selected columns. Deadlines and cancellations from the
+file matching the selected columns and the key conditions of the filter
+(see RowFilter.MatchesKey). Deadlines and cancellations from the
 context "ctx" are taken into account. Any results will be reported through
 the channel "results", errors will be reported through "errors", and "done"
 will be marked as soon as processing the file has completed.
-
-columns is expected to be sorted (see sort.Strings).
 */
-func LookupInJournal(parentCtx context.Context, path string, columns []string,
-	kr *common.KeyRange, results chan *redcloud.ColumnFamily,
-	errors chan error, done chan struct{}) {
+func LookupInJournal(parentCtx context.Context, path string,
+	columns *ColumnSelector, filter *RowFilter, kr *common.KeyRange,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
 	var ctx context.Context
 	var span *trace.Span
 	var reader *recordio.RecordReader
@@ -124,17 +123,26 @@ func LookupInJournal(parentCtx context.Context, path string, columns []string,
 			journalLookupErrors.With(
 				prometheus.Labels{"error_class": "read_error"}).Inc()
 			errors <- err
+			return
+		}
+
+		if err = VerifyChecksum(cf); err != nil {
+			span.AddAttributes(trace.StringAttribute("error", err.Error()))
+			span.Annotate(nil, "Corrupted record in journal")
+			journalLookupErrors.With(
+				prometheus.Labels{"error_class": "checksum_mismatch"}).Inc()
+			errors <- err
+			return
 		}
 
 		// Check if the key of the record is in the range of interest.
-		if !kr.Contains(cf.Key) {
+		if !kr.Contains(cf.Key) || !filter.MatchesKey(cf.Key) {
 			continue
 		}
 
 		for _, cs = range cf.ColumnSet {
-			var off int
-			off = sort.SearchStrings(columns, cs.Name)
-			if off >= 0 && off < len(columns) && columns[off] == cs.Name {
+			var selected *redcloud.ColumnSet
+			if selected = SelectColumns(cs, columns); selected != nil {
 				/*
 					Collect all matching columns in a special return
 					ColumnFamily.
@@ -144,7 +152,7 @@ func LookupInJournal(parentCtx context.Context, path string, columns []string,
 					rcf.Key = cf.Key
 				}
 
-				rcf.ColumnSet = append(rcf.ColumnSet, cs)
+				rcf.ColumnSet = append(rcf.ColumnSet, selected)
 			}
 		}
 
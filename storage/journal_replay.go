@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/recordio"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+ReplayJournal adds all records of the journal at the specified path to the
+memtable and returns the number of records read. If the journal ends in a
+truncated record, e.g. because the data node writing the journal died
+halfway through writing it, reading stops there and the torn tail is
+reported as "torn". All other errors, including IO errors and records which
+don't match their checksum, are reported as "err", since the journal may
+hold more data after them.
+*/
+func ReplayJournal(ctx context.Context, path string, memtable *Memtable) (
+	numRecords int64, torn error, err error) {
+	var u *url.URL
+	var f filesystem.ReadCloser
+
+	if u, err = url.Parse(path); err != nil {
+		return 0, nil, err
+	}
+
+	if f, err = filesystem.OpenReader(ctx, u); err != nil {
+		return 0, nil, err
+	}
+	defer f.Close(ctx)
+
+	return replayRecords(ctx, recordio.NewRecordReader(f), memtable)
+}
+
+/*
+journalReader reads the records of a journal.
+*/
+type journalReader interface {
+	ReadMessage(ctx context.Context, msg proto.Message) error
+}
+
+/*
+replayRecords adds all records read from reader to the memtable, see
+ReplayJournal.
+*/
+func replayRecords(ctx context.Context, reader journalReader,
+	memtable *Memtable) (numRecords int64, torn error, err error) {
+	for {
+		var cf = new(redcloud.ColumnFamily)
+
+		if ctx.Err() != nil {
+			return numRecords, nil, ctx.Err()
+		}
+
+		if err = reader.ReadMessage(ctx, cf); err == io.EOF {
+			return numRecords, nil, nil
+		} else if err == io.ErrUnexpectedEOF {
+			// The file ended in the middle of the record.
+			return numRecords, err, nil
+		} else if err != nil {
+			return numRecords, nil, err
+		}
+
+		if err = VerifyChecksum(cf); err != nil {
+			return numRecords, nil, err
+		}
+
+		if err = memtable.Add(cf); err != nil {
+			return numRecords, nil, err
+		}
+		numRecords++
+	}
+}
+
+/*
+WriteJournal writes all rows of the memtable to a new journal at the
+specified path, in key order. Returns the size of the data written.
+*/
+func WriteJournal(ctx context.Context, path string, memtable *Memtable) (
+	int64, error) {
+	var u *url.URL
+	var f filesystem.WriteCloser
+	var writer *recordio.RecordWriter
+	var reader = memtable.NewReader()
+	var size int64
+	var err error
+
+	if u, err = url.Parse(path); err != nil {
+		return 0, err
+	}
+
+	if f, err = filesystem.OpenWriter(ctx, u); err != nil {
+		return 0, err
+	}
+
+	writer = recordio.NewRecordWriter(f)
+
+	for {
+		var cf redcloud.ColumnFamily
+		var record *redcloud.ColumnFamily
+
+		if err = reader.ReadMessage(ctx, &cf); err == io.EOF {
+			break
+		} else if err != nil {
+			f.Close(ctx)
+			filesystem.Remove(ctx, u)
+			return 0, err
+		}
+
+		if record, err = ChecksumRecord(&cf); err != nil {
+			f.Close(ctx)
+			filesystem.Remove(ctx, u)
+			return 0, err
+		}
+
+		if err = writer.WriteMessage(ctx, record); err != nil {
+			f.Close(ctx)
+			filesystem.Remove(ctx, u)
+			return 0, err
+		}
+		size += int64(proto.Size(&cf))
+	}
+
+	if err = f.Close(ctx); err != nil {
+		filesystem.Remove(ctx, u)
+		return 0, err
+	}
+
+	return size, nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+fakeJournal returns its rows, then ends with the configured error.
+*/
+type fakeJournal struct {
+	rows []*redcloud.ColumnFamily
+	end  error
+}
+
+func (f *fakeJournal) ReadMessage(
+	ctx context.Context, msg proto.Message) error {
+	if len(f.rows) == 0 {
+		return f.end
+	}
+
+	proto.Merge(msg, f.rows[0])
+	f.rows = f.rows[1:]
+	return nil
+}
+
+func checksummedRow(key string) *redcloud.ColumnFamily {
+	var record *redcloud.ColumnFamily
+
+	record, _ = ChecksumRecord(
+		singleColumnRow(key, "col", dataColumn(1, 0, "data")))
+	return record
+}
+
+func TestReplayRecords(t *testing.T) {
+	var ioError = errors.New("I/O error")
+	var corrupted = checksummedRow("c")
+	var cases = []struct {
+		name       string
+		rows       []*redcloud.ColumnFamily
+		end        error
+		numRecords int64
+		torn       error
+		err        error
+	}{
+		{"complete", []*redcloud.ColumnFamily{
+			checksummedRow("a"), checksummedRow("b")}, io.EOF, 2, nil, nil},
+		{"torn tail", []*redcloud.ColumnFamily{
+			checksummedRow("a"), checksummedRow("b")}, io.ErrUnexpectedEOF,
+			2, io.ErrUnexpectedEOF, nil},
+		{"read error", []*redcloud.ColumnFamily{checksummedRow("a")},
+			ioError, 1, nil, ioError},
+		{"corrupted", []*redcloud.ColumnFamily{
+			checksummedRow("a"), corrupted, checksummedRow("d")}, io.EOF,
+			1, nil, common.ErrChecksumMismatch},
+		{"empty", nil, io.EOF, 0, nil, nil},
+	}
+	var i int
+
+	corrupted.ChecksummedData[len(corrupted.ChecksummedData)-1] ^= 1
+
+	for i = range cases {
+		var memtable = NewMemtable()
+		var reader *MemtableReader
+		var numRecords, numRows int64
+		var torn, err error
+
+		numRecords, torn, err = replayRecords(context.Background(),
+			&fakeJournal{rows: cases[i].rows, end: cases[i].end}, memtable)
+
+		if numRecords != cases[i].numRecords {
+			t.Errorf("%s: unexpected number of records: got %d, want %d",
+				cases[i].name, numRecords, cases[i].numRecords)
+		}
+		if torn != cases[i].torn {
+			t.Errorf("%s: unexpected torn tail: got %v, want %v",
+				cases[i].name, torn, cases[i].torn)
+		}
+		if err != cases[i].err {
+			t.Errorf("%s: unexpected error: got %v, want %v",
+				cases[i].name, err, cases[i].err)
+		}
+
+		reader = memtable.NewReader()
+		for {
+			var cf redcloud.ColumnFamily
+
+			if err = reader.ReadMessage(
+				context.Background(), &cf); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s: error reading memtable: %s", cases[i].name, err)
+				break
+			}
+			numRows++
+		}
+
+		if numRows != cases[i].numRecords {
+			t.Errorf("%s: unexpected number of rows in memtable: got %d, "+
+				"want %d", cases[i].name, numRows, cases[i].numRecords)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/recordio"
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+keyIndexInterval determines how many rows are skipped between two keys
+sampled into a KeyIndex. It matches the interval of the sstable indices.
+*/
+const keyIndexInterval = 32
+
+/*
+KeyIndex samples the row keys written to an sstable, along with the amount
+of data stored before each of them. This allows finding the middle of the
+data of an sstable without reading it.
+*/
+type KeyIndex struct {
+	keys    [][]byte
+	offsets []int64
+	total   int64
+	numRows int64
+}
+
+/*
+NewKeyIndex creates a KeyIndex without any keys.
+*/
+func NewKeyIndex() *KeyIndex {
+	return new(KeyIndex)
+}
+
+/*
+Add records a row of the specified size as the next one written to the
+sstable. Only every keyIndexInterval-th key is kept.
+*/
+func (k *KeyIndex) Add(key []byte, size int64) {
+	if k.numRows%keyIndexInterval == 0 {
+		k.keys = append(k.keys, append([]byte{}, key...))
+		k.offsets = append(k.offsets, k.total)
+	}
+
+	k.total += size
+	k.numRows++
+}
+
+/*
+MiddleKey returns the first sampled key with at least half of the data of
+the sstable stored before it, or nil if there is no such key.
+*/
+func (k *KeyIndex) MiddleKey() []byte {
+	var i int
+
+	for i = range k.keys {
+		if k.offsets[i] > 0 && k.offsets[i] >= k.total/2 {
+			return k.keys[i]
+		}
+	}
+
+	return nil
+}
+
+/*
+WriteKeyIndex writes the KeyIndex to the key index file of the sstable at
+the specified path.
+*/
+func WriteKeyIndex(ctx context.Context, path string, index *KeyIndex) error {
+	var u *url.URL
+	var out filesystem.WriteCloser
+	var err error
+
+	if u, err = url.Parse(path + ".keys"); err != nil {
+		return err
+	}
+
+	if out, err = filesystem.OpenWriter(ctx, u); err != nil {
+		return err
+	}
+
+	if err = recordio.NewRecordWriter(out).WriteMessage(
+		ctx, &redcloud.KeyIndexData{
+			Key:       index.keys,
+			Offset:    index.offsets,
+			TotalSize: index.total,
+		}); err != nil {
+		out.Close(ctx)
+		filesystem.Remove(ctx, u)
+		return err
+	}
+
+	return out.Close(ctx)
+}
+
+/*
+ReadKeyIndex reads the KeyIndex from the key index file of the sstable at
+the specified path.
+*/
+func ReadKeyIndex(ctx context.Context, path string) (*KeyIndex, error) {
+	var u *url.URL
+	var in filesystem.ReadCloser
+	var data redcloud.KeyIndexData
+	var err error
+
+	if u, err = url.Parse(path + ".keys"); err != nil {
+		return nil, err
+	}
+
+	if in, err = filesystem.OpenReader(ctx, u); err != nil {
+		return nil, err
+	}
+	defer in.Close(ctx)
+
+	if err = recordio.NewRecordReader(in).ReadMessage(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	if len(data.Key) != len(data.Offset) {
+		return nil, fmt.Errorf("Key index %s has %d keys but %d offsets",
+			u.String(), len(data.Key), len(data.Offset))
+	}
+
+	return &KeyIndex{
+		keys:    data.Key,
+		offsets: data.Offset,
+		total:   data.TotalSize,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKeyIndexMiddleKey(t *testing.T) {
+	var index = NewKeyIndex()
+	var i int
+
+	if index.MiddleKey() != nil {
+		t.Errorf("Unexpected middle key of empty index: %s",
+			index.MiddleKey())
+	}
+
+	// The first quarter of the rows holds more than half of the data.
+	for i = 0; i < 4*keyIndexInterval; i++ {
+		var size int64 = 1
+
+		if i < keyIndexInterval {
+			size = 5
+		}
+		index.Add([]byte(fmt.Sprintf("key%03d", i)), size)
+	}
+
+	if len(index.keys) != 4 {
+		t.Errorf("Unexpected number of sampled keys: got %d, want 4",
+			len(index.keys))
+	}
+	if string(index.MiddleKey()) != fmt.Sprintf("key%03d", keyIndexInterval) {
+		t.Errorf("Unexpected middle key: %s", index.MiddleKey())
+	}
+}
+
+func TestKeyIndexSingleSample(t *testing.T) {
+	var index = NewKeyIndex()
+
+	// A key at the very start of the sstable doesn't split anything.
+	index.Add([]byte("a"), 100)
+	index.Add([]byte("b"), 100)
+
+	if index.MiddleKey() != nil {
+		t.Errorf("Unexpected middle key: %s", index.MiddleKey())
+	}
+}
@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opencensus.io/trace"
+)
+
+var memtableLookups = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_memtable_lookups",
+	Help:      "Number of lookups of data in memtables",
+})
+var memtableLookupNumResults = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_memtable_lookup_results",
+	Help:      "Number of results from lookups of data in memtables",
+})
+
+func init() {
+	prometheus.MustRegister(memtableLookups)
+	prometheus.MustRegister(memtableLookupNumResults)
+}
+
+/*
+Maximum height of the skip list of a memtable. With every level holding
+a quarter of the rows of the level below, this comfortably covers millions
+of rows.
+*/
+const memtableMaxLevel = 12
+
+/*
+memtableNode is a row in the skip list of a memtable.
+*/
+type memtableNode struct {
+	row  *redcloud.ColumnFamily
+	next []*memtableNode
+}
+
+/*
+Memtable holds the rows written to a journal in memory, sorted by key, so
+they can be looked up without reading the journal. All records written for
+the same key are merged into one row. Once a memtable has been frozen, e.g.
+because it is being flushed to an sstable, no more data can be added to it.
+*/
+type Memtable struct {
+	lock   sync.RWMutex
+	head   *memtableNode
+	level  int
+	size   int64
+	oldest int64
+	newest int64
+	frozen bool
+	random *rand.Rand
+
+	/*
+		Number of records reserved by BeginWrite which haven't been added
+		yet. Freeze waits on writesDone for them to reach 0.
+	*/
+	pendingWrites int
+	writesDone    *sync.Cond
+}
+
+/*
+NewMemtable creates a new, empty Memtable.
+*/
+func NewMemtable() *Memtable {
+	var m = &Memtable{
+		head:   &memtableNode{next: make([]*memtableNode, memtableMaxLevel)},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	m.writesDone = sync.NewCond(&m.lock)
+	return m
+}
+
+/*
+randomLevel determines the number of levels of the skip list a new node is
+linked into. Expects the write lock to be held.
+*/
+func (m *Memtable) randomLevel() int {
+	var level = 1
+
+	for level < memtableMaxLevel && m.random.Intn(4) == 0 {
+		level++
+	}
+
+	return level
+}
+
+/*
+findGreaterOrEqual finds the first node whose key is not less than key, or
+nil if there is none. If "update" is set, the last node before the position
+of key on every level is stored in it. Expects a lock to be held.
+*/
+func (m *Memtable) findGreaterOrEqual(key []byte,
+	update []*memtableNode) *memtableNode {
+	var node = m.head
+	var i int
+
+	for i = m.level - 1; i >= 0; i-- {
+		for node.next[i] != nil &&
+			bytes.Compare(node.next[i].row.Key, key) < 0 {
+			node = node.next[i]
+		}
+		if update != nil {
+			update[i] = node
+		}
+	}
+
+	return node.next[0]
+}
+
+/*
+Add merges a copy of the record into the row with the same key. Returns
+common.ErrMemtableFrozen if the memtable no longer accepts data.
+*/
+func (m *Memtable) Add(cf *redcloud.ColumnFamily) error {
+	var err error
+
+	if err = m.BeginWrite(); err != nil {
+		return err
+	}
+
+	m.FinishWrite(cf)
+	return nil
+}
+
+/*
+BeginWrite reserves the addition of a record to the memtable, e.g. while
+the record is being written to its journal. Returns common.ErrMemtableFrozen
+if the memtable no longer accepts data. Otherwise, the record has to be
+added using FinishWrite, which cannot fail, or the reservation has to be
+given up using AbortWrite. Freeze waits for all reserved records.
+*/
+func (m *Memtable) BeginWrite() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.frozen {
+		return common.ErrMemtableFrozen
+	}
+
+	m.pendingWrites++
+	return nil
+}
+
+/*
+AbortWrite gives up a reservation made by BeginWrite without adding any
+data.
+*/
+func (m *Memtable) AbortWrite() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.endWrite()
+}
+
+/*
+endWrite releases a reservation made by BeginWrite. Expects the write lock
+to be held.
+*/
+func (m *Memtable) endWrite() {
+	if m.pendingWrites--; m.pendingWrites == 0 {
+		m.writesDone.Broadcast()
+	}
+}
+
+/*
+FinishWrite merges a copy of the record reserved by BeginWrite into the row
+with the same key. The record is added even if the memtable has been frozen
+since.
+*/
+func (m *Memtable) FinishWrite(cf *redcloud.ColumnFamily) {
+	var update = make([]*memtableNode, memtableMaxLevel)
+	var row = proto.Clone(cf).(*redcloud.ColumnFamily)
+	var node *memtableNode
+	var cs *redcloud.ColumnSet
+	var col *redcloud.Column
+	var level int
+	var i int
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	defer m.endWrite()
+
+	m.size += int64(proto.Size(row))
+	for _, cs = range row.ColumnSet {
+		for _, col = range cs.Column {
+			if m.oldest == 0 || col.Timestamp < m.oldest {
+				m.oldest = col.Timestamp
+			}
+			if col.Timestamp > m.newest {
+				m.newest = col.Timestamp
+			}
+		}
+	}
+
+	node = m.findGreaterOrEqual(row.Key, update)
+	if node != nil && bytes.Equal(node.row.Key, row.Key) {
+		MergeColumnFamilies(node.row, row)
+		return
+	}
+
+	if level = m.randomLevel(); level > m.level {
+		for i = m.level; i < level; i++ {
+			update[i] = m.head
+		}
+		m.level = level
+	}
+
+	node = &memtableNode{row: row, next: make([]*memtableNode, level)}
+	for i = 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+}
+
+/*
+Freeze stops the memtable from accepting any more data. All writes added
+or reserved before Freeze returns are contained in the memtable.
+*/
+func (m *Memtable) Freeze() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.frozen = true
+	for m.pendingWrites > 0 {
+		m.writesDone.Wait()
+	}
+}
+
+/*
+Frozen determines whether the memtable has stopped accepting data.
+*/
+func (m *Memtable) Frozen() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.frozen
+}
+
+/*
+OldestTimestamp returns the oldest timestamp of all columns added to the
+memtable, or 0 if it is empty.
+*/
+func (m *Memtable) OldestTimestamp() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.oldest
+}
+
+/*
+NewestTimestamp returns the most recent timestamp of all columns added to
+the memtable, or 0 if it is empty.
+*/
+func (m *Memtable) NewestTimestamp() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.newest
+}
+
+/*
+Size returns the size of all records added to the memtable, in bytes.
+*/
+func (m *Memtable) Size() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.size
+}
+
+/*
+seek returns a copy of the first row whose key is not less than key, or nil
+if there is none.
+*/
+func (m *Memtable) seek(key []byte) *redcloud.ColumnFamily {
+	var node *memtableNode
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if node = m.findGreaterOrEqual(key, nil); node == nil {
+		return nil
+	}
+
+	return proto.Clone(node.row).(*redcloud.ColumnFamily)
+}
+
+/*
+nextKey returns the smallest key following key.
+*/
+func nextKey(key []byte) []byte {
+	return append(append([]byte{}, key...), 0)
+}
+
+/*
+Lookup finds all rows in the specified key range matching the selected
+columns and the key conditions of the filter, in key order. Results and
+completion are reported through the channels like in LookupInSstable.
+Since the data is held in memory, no errors are ever reported.
+*/
+func (m *Memtable) Lookup(parentCtx context.Context,
+	columns *ColumnSelector, filter *RowFilter, kr *common.KeyRange,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var ctx context.Context
+	var span *trace.Span
+	var key = kr.StartKey
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.Memtable/Lookup")
+	defer span.End()
+	memtableLookups.Inc()
+
+	defer markDone(done)
+
+	/*
+		Rows are copied one by one instead of holding the lock for the
+		entire lookup, so slow readers don't hold up writes.
+	*/
+	for {
+		var row *redcloud.ColumnFamily
+		var rcf *redcloud.ColumnFamily
+
+		// Nobody is interested in the results any more.
+		if ctx.Err() != nil {
+			return
+		}
+
+		if row = m.seek(key); row == nil || !kr.Contains(row.Key) {
+			return
+		}
+
+		if rcf = selectRow(row, columns, filter); rcf != nil {
+			memtableLookupNumResults.Inc()
+			results <- rcf
+		}
+
+		key = nextKey(row.Key)
+	}
+}
+
+/*
+LookupKeys finds the rows for all of the specified keys, reporting only the
+selected columns. Results and completion are reported through the channels
+like in LookupKeysInSstable.
+*/
+func (m *Memtable) LookupKeys(parentCtx context.Context,
+	columns *ColumnSelector, keys [][]byte,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var ctx context.Context
+	var span *trace.Span
+	var key []byte
+
+	ctx, span = trace.StartSpan(
+		parentCtx, "red-cloud.Memtable/LookupKeys")
+	defer span.End()
+	memtableLookups.Inc()
+
+	defer markDone(done)
+
+	for _, key = range sortedKeys(keys) {
+		var row *redcloud.ColumnFamily
+		var rcf *redcloud.ColumnFamily
+
+		// Nobody is interested in the results any more.
+		if ctx.Err() != nil {
+			return
+		}
+
+		if row = m.seek(key); row == nil || !bytes.Equal(row.Key, key) {
+			continue
+		}
+
+		if rcf = selectRow(row, columns, nil); rcf != nil {
+			memtableLookupNumResults.Inc()
+			results <- rcf
+		}
+	}
+}
+
+/*
+MemtableReader reads all rows of a memtable in key order, e.g. in order to
+flush them to an sstable.
+*/
+type MemtableReader struct {
+	memtable *Memtable
+	next     []byte
+}
+
+/*
+NewReader creates a MemtableReader positioned at the first row of the
+memtable.
+*/
+func (m *Memtable) NewReader() *MemtableReader {
+	return &MemtableReader{memtable: m}
+}
+
+/*
+ReadMessage stores the next row of the memtable in msg. Returns io.EOF once
+all rows have been read.
+*/
+func (r *MemtableReader) ReadMessage(ctx context.Context,
+	msg proto.Message) error {
+	var row *redcloud.ColumnFamily
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if row = r.memtable.seek(r.next); row == nil {
+		return io.EOF
+	}
+	r.next = nextKey(row.Key)
+
+	msg.Reset()
+	proto.Merge(msg, row)
+	return nil
+}
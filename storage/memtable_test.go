@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+)
+
+func collectMemtableLookup(m *Memtable, kr *common.KeyRange) []string {
+	var results = make(chan *redcloud.ColumnFamily)
+	var errors = make(chan error)
+	var done = make(chan struct{})
+	var keys []string
+
+	go m.Lookup(context.Background(), NewColumnSelector([]string{"col"}),
+		nil, kr, results, errors, done)
+
+	for {
+		var cf *redcloud.ColumnFamily
+
+		select {
+		case cf = <-results:
+			keys = append(keys, string(cf.Key))
+		case <-done:
+			return keys
+		}
+	}
+}
+
+func TestMemtableLookup(t *testing.T) {
+	var m = NewMemtable()
+	var expected = map[*common.KeyRange]string{
+		common.NewKeyRange([]byte{}, []byte{}):       "[a b c d]",
+		common.NewKeyRange([]byte("b"), []byte("d")): "[b c]",
+		common.NewKeyRange([]byte("c"), []byte("c")): "[c]",
+		common.NewKeyRange([]byte("e"), []byte{}):    "[]",
+	}
+	var kr *common.KeyRange
+	var keys string
+	var key string
+	var err error
+
+	for _, key = range []string{"c", "a", "d", "b"} {
+		if err = m.Add(singleColumnRow(
+			key, "col", dataColumn(1, 0, ""))); err != nil {
+			t.Errorf("Error adding %s: %s", key, err)
+		}
+	}
+
+	for kr, keys = range expected {
+		var got = fmt.Sprint(collectMemtableLookup(m, kr))
+
+		if got != keys {
+			t.Errorf("Unexpected keys in %s: got %s, want %s", kr, got, keys)
+		}
+	}
+}
+
+func TestMemtableMergesRows(t *testing.T) {
+	var m = NewMemtable()
+	var reader *MemtableReader
+	var row redcloud.ColumnFamily
+	var numRows int
+	var err error
+
+	if m.OldestTimestamp() != 0 {
+		t.Errorf("Unexpected oldest timestamp of empty memtable: %d",
+			m.OldestTimestamp())
+	}
+
+	m.Add(singleColumnRow("a", "col", dataColumn(2, 0, "")))
+	m.Add(singleColumnRow("b", "col", dataColumn(1, 0, "")))
+	m.Add(singleColumnRow("a", "col", dataColumn(3, 0, "")))
+
+	if m.OldestTimestamp() != 1 {
+		t.Errorf("Unexpected oldest timestamp: got %d, want 1",
+			m.OldestTimestamp())
+	}
+	if m.NewestTimestamp() != 3 {
+		t.Errorf("Unexpected newest timestamp: got %d, want 3",
+			m.NewestTimestamp())
+	}
+
+	reader = m.NewReader()
+	for {
+		if err = reader.ReadMessage(context.Background(), &row); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Error reading memtable: %s", err)
+		}
+
+		numRows++
+		if string(row.Key) == "a" &&
+			(len(row.ColumnSet) != 1 || len(row.ColumnSet[0].Column) != 2 ||
+				row.ColumnSet[0].Column[0].Timestamp != 3) {
+			t.Errorf("Unexpected merged row: %v", row)
+		}
+	}
+
+	if numRows != 2 {
+		t.Errorf("Unexpected number of rows: got %d, want 2", numRows)
+	}
+}
+
+func TestMemtableFreeze(t *testing.T) {
+	var m = NewMemtable()
+	var got []string
+	var err error
+
+	m.Add(singleColumnRow("a", "col", dataColumn(1, 0, "")))
+	if m.Frozen() {
+		t.Error("New memtable is frozen")
+	}
+	m.Freeze()
+
+	if !m.Frozen() {
+		t.Error("Memtable not frozen")
+	}
+
+	if err = m.Add(singleColumnRow(
+		"b", "col", dataColumn(1, 0, ""))); err != common.ErrMemtableFrozen {
+		t.Errorf("Unexpected error adding to frozen memtable: %v", err)
+	}
+	if got = collectMemtableLookup(m, common.NewKeyRange(
+		[]byte{}, []byte{})); len(got) != 1 {
+		t.Errorf("Unexpected rows in frozen memtable: %v", got)
+	}
+}
+
+func TestMemtableFreezeWaitsForReservedWrites(t *testing.T) {
+	var m = NewMemtable()
+	var frozen = make(chan struct{})
+	var got []string
+	var err error
+
+	if err = m.BeginWrite(); err != nil {
+		t.Errorf("Unexpected error reserving write: %s", err)
+	}
+
+	go func() {
+		m.Freeze()
+		close(frozen)
+	}()
+
+	select {
+	case <-frozen:
+		t.Error("Freeze returned with a reserved write pending")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	m.FinishWrite(singleColumnRow("a", "col", dataColumn(1, 0, "")))
+	<-frozen
+
+	if err = m.BeginWrite(); err != common.ErrMemtableFrozen {
+		t.Errorf("Unexpected error reserving write on frozen memtable: %v",
+			err)
+	}
+	if got = collectMemtableLookup(m, common.NewKeyRange(
+		[]byte{}, []byte{})); len(got) != 1 {
+		t.Errorf("Unexpected rows in frozen memtable: %v", got)
+	}
+}
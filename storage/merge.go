@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"math"
+	"sort"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+/*
+columnsByTimestamp sorts the versions of a column by their timestamp, newest
+first.
+*/
+type columnsByTimestamp []*redcloud.Column
+
+/*
+Len returns the number of versions in the slice.
+*/
+func (c columnsByTimestamp) Len() int {
+	return len(c)
+}
+
+/*
+Less determines whether the i-th version is newer than the j-th.
+*/
+func (c columnsByTimestamp) Less(i, j int) bool {
+	return c[i].Timestamp > c[j].Timestamp
+}
+
+/*
+Swap exchanges the i-th and j-th version.
+*/
+func (c columnsByTimestamp) Swap(i, j int) {
+	var col = c[j]
+	c[j] = c[i]
+	c[i] = col
+}
+
+/*
+MergeColumnFamilies merges the column sets of b into a. Both are expected to
+describe the same row. All versions of a column end up in the same
+ColumnSet, ordered by timestamp with the newest version first, and the
+column sets are ordered by column name.
+*/
+func MergeColumnFamilies(a, b *redcloud.ColumnFamily) {
+	var columnSets = make(map[string]*redcloud.ColumnSet)
+	var names []string
+	var cset *redcloud.ColumnSet
+	var name string
+	var ok bool
+
+	for _, cset = range append(a.ColumnSet, b.ColumnSet...) {
+		var merged *redcloud.ColumnSet
+
+		if merged, ok = columnSets[cset.Name]; !ok {
+			merged = &redcloud.ColumnSet{Name: cset.Name}
+			columnSets[cset.Name] = merged
+			names = append(names, cset.Name)
+		}
+
+		merged.Column = append(merged.Column, cset.Column...)
+	}
+
+	sort.Strings(names)
+	a.ColumnSet = make([]*redcloud.ColumnSet, 0, len(names))
+
+	for _, name = range names {
+		cset = columnSets[name]
+		sort.Stable(columnsByTimestamp(cset.Column))
+		a.ColumnSet = append(a.ColumnSet, cset)
+	}
+}
+
+/*
+IsExpired determines whether the TTL of the column has passed at the time
+now, given in milliseconds since the epoch. Tombstones never expire, since
+they would otherwise resurrect the data they cover.
+*/
+func IsExpired(col *redcloud.Column, now int64) bool {
+	return !IsTombstone(col) && col.Ttl > 0 && col.Timestamp+col.Ttl < now
+}
+
+/*
+rangeTombstoneCovers determines whether the column is a range tombstone
+stored in the column set start which covers the column name.
+*/
+func rangeTombstoneCovers(start string, col *redcloud.Column,
+	name string) bool {
+	return col.Type == redcloud.Column_RANGE_TOMBSTONE && name >= start &&
+		(len(col.EndColumn) == 0 || name < col.EndColumn)
+}
+
+/*
+SelectColumns determines which parts of the column set are relevant to a
+lookup of the columns selected by the ColumnSelector. If the column set is
+one of the selected columns, it is returned as is. Otherwise, a column set
+holding only the range tombstones covering any of the selected columns is
+returned, since they may hide data of the selected columns stored elsewhere.
+If nothing is relevant, nil is returned.
+*/
+func SelectColumns(cs *redcloud.ColumnSet,
+	selector *ColumnSelector) *redcloud.ColumnSet {
+	var rv *redcloud.ColumnSet
+	var col *redcloud.Column
+
+	if selector.Matches(cs.Name) {
+		return cs
+	}
+
+	for _, col = range cs.Column {
+		if col.Type == redcloud.Column_RANGE_TOMBSTONE &&
+			selector.Overlaps(cs.Name, col.EndColumn) {
+			if rv == nil {
+				rv = &redcloud.ColumnSet{Name: cs.Name}
+			}
+			rv.Column = append(rv.Column, col)
+		}
+	}
+
+	return rv
+}
+
+/*
+ExpireColumns removes all versions whose TTL has passed at the time now,
+given in milliseconds since the epoch, from the column family. Column sets
+which end up without any versions are removed as well. Returns whether any
+data is left in the column family.
+*/
+func ExpireColumns(cf *redcloud.ColumnFamily, now int64) bool {
+	var csets []*redcloud.ColumnSet
+	var cset *redcloud.ColumnSet
+
+	for _, cset = range cf.ColumnSet {
+		var cols []*redcloud.Column
+		var col *redcloud.Column
+
+		for _, col = range cset.Column {
+			if !IsExpired(col, now) {
+				cols = append(cols, col)
+			}
+		}
+
+		if len(cols) > 0 {
+			cset.Column = cols
+			csets = append(csets, cset)
+		}
+	}
+
+	cf.ColumnSet = csets
+	return len(csets) > 0
+}
+
+/*
+DropVersionsAfter removes all versions written after readTimestamp, given in
+milliseconds since the epoch, from the column family, so only the data as of
+that time remains. This includes tombstones, so data deleted later on is
+visible again. Column sets which end up without any versions are removed as
+well. Returns whether any data is left in the column family.
+*/
+func DropVersionsAfter(cf *redcloud.ColumnFamily, readTimestamp int64) bool {
+	var csets []*redcloud.ColumnSet
+	var cset *redcloud.ColumnSet
+
+	for _, cset = range cf.ColumnSet {
+		var cols []*redcloud.Column
+		var col *redcloud.Column
+
+		for _, col = range cset.Column {
+			if col.Timestamp <= readTimestamp {
+				cols = append(cols, col)
+			}
+		}
+
+		if len(cols) > 0 {
+			cset.Column = cols
+			csets = append(csets, cset)
+		}
+	}
+
+	cf.ColumnSet = csets
+	return len(csets) > 0
+}
+
+/*
+IsTombstone determines whether the column is a tombstone of any kind.
+*/
+func IsTombstone(col *redcloud.Column) bool {
+	return col.Type == redcloud.Column_TOMBSTONE ||
+		col.Type == redcloud.Column_RANGE_TOMBSTONE
+}
+
+/*
+findRangeTombstones collects all range tombstones of the column family,
+along with the names of the column sets they are stored in.
+*/
+func findRangeTombstones(
+	cf *redcloud.ColumnFamily) map[*redcloud.Column]string {
+	var rangeTombstones = make(map[*redcloud.Column]string)
+	var cset *redcloud.ColumnSet
+	var col *redcloud.Column
+
+	for _, cset = range cf.ColumnSet {
+		for _, col = range cset.Column {
+			if col.Type == redcloud.Column_RANGE_TOMBSTONE {
+				rangeTombstones[col] = cset.Name
+			}
+		}
+	}
+
+	return rangeTombstones
+}
+
+/*
+deletedBefore determines the timestamp of the newest tombstone covering the
+column set, taking both the tombstones in the column set and the range
+tombstones of the row into account. All versions not newer than this
+timestamp are deleted. Returns false if no tombstone covers the column set.
+*/
+func deletedBefore(cset *redcloud.ColumnSet,
+	rangeTombstones map[*redcloud.Column]string) (int64, bool) {
+	var col *redcloud.Column
+	var start string
+	var timestamp int64
+	var deleted bool
+
+	for _, col = range cset.Column {
+		if col.Type == redcloud.Column_TOMBSTONE &&
+			(!deleted || col.Timestamp > timestamp) {
+			timestamp = col.Timestamp
+			deleted = true
+		}
+	}
+
+	for col, start = range rangeTombstones {
+		if rangeTombstoneCovers(start, col, cset.Name) &&
+			(!deleted || col.Timestamp > timestamp) {
+			timestamp = col.Timestamp
+			deleted = true
+		}
+	}
+
+	return timestamp, deleted
+}
+
+/*
+PurgeTombstones removes all tombstones from the column family, along with
+all versions of the columns covered by them which are not newer than the
+tombstone. This must only be applied when all older data of the row is being
+rewritten, or when the row data is about to be returned to a reader, since
+the tombstones will no longer be available to shadow data elsewhere. Returns
+whether any data is left in the column family.
+*/
+func PurgeTombstones(cf *redcloud.ColumnFamily) bool {
+	return PurgeTombstonesBefore(cf, math.MaxInt64)
+}
+
+/*
+PurgeTombstonesBefore works like PurgeTombstones, but only removes the
+tombstones older than cutoff, in milliseconds since the epoch. Newer
+tombstones are kept to shadow data which isn't being rewritten, while the
+data they cover is removed all the same. Returns whether any data or
+tombstones are left in the column family.
+*/
+func PurgeTombstonesBefore(cf *redcloud.ColumnFamily, cutoff int64) bool {
+	var rangeTombstones = findRangeTombstones(cf)
+	var csets []*redcloud.ColumnSet
+	var cset *redcloud.ColumnSet
+
+	for _, cset = range cf.ColumnSet {
+		var cols []*redcloud.Column
+		var col *redcloud.Column
+		var before int64
+		var deleted bool
+
+		before, deleted = deletedBefore(cset, rangeTombstones)
+
+		for _, col = range cset.Column {
+			if IsTombstone(col) {
+				if col.Timestamp >= cutoff {
+					cols = append(cols, col)
+				}
+			} else if !deleted || col.Timestamp > before {
+				cols = append(cols, col)
+			}
+		}
+
+		if len(cols) > 0 {
+			cset.Column = cols
+			csets = append(csets, cset)
+		}
+	}
+
+	cf.ColumnSet = csets
+	return len(csets) > 0
+}
+
+/*
+LimitVersions removes all versions from the column family which exceed the
+version limits of the table: only the newest maxVersions versions of each
+column are kept, and all versions but the newest one are removed once they
+are older than maxVersionAge milliseconds at the time now. A limit of 0
+disables the respective check. The versions of each column are expected to
+be ordered newest first. Tombstones are neither counted nor removed. Returns
+whether any data is left in the column family.
+*/
+func LimitVersions(cf *redcloud.ColumnFamily, maxVersions, maxVersionAge,
+	now int64) bool {
+	var csets []*redcloud.ColumnSet
+	var cset *redcloud.ColumnSet
+
+	if maxVersions <= 0 && maxVersionAge <= 0 {
+		return len(cf.ColumnSet) > 0
+	}
+
+	for _, cset = range cf.ColumnSet {
+		var cols []*redcloud.Column
+		var col *redcloud.Column
+		var numVersions int64
+
+		for _, col = range cset.Column {
+			if col.Type != redcloud.Column_DATA {
+				cols = append(cols, col)
+				continue
+			}
+
+			numVersions++
+			if maxVersions > 0 && numVersions > maxVersions {
+				continue
+			}
+			if maxVersionAge > 0 && numVersions > 1 &&
+				col.Timestamp+maxVersionAge < now {
+				continue
+			}
+			cols = append(cols, col)
+		}
+
+		if len(cols) > 0 {
+			cset.Column = cols
+			csets = append(csets, cset)
+		}
+	}
+
+	cf.ColumnSet = csets
+	return len(csets) > 0
+}
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+func dataColumn(timestamp, ttl int64, content string) *redcloud.Column {
+	return &redcloud.Column{
+		Type:      redcloud.Column_DATA,
+		Timestamp: timestamp,
+		Ttl:       ttl,
+		Content:   []byte(content),
+	}
+}
+
+func tombstoneColumn(timestamp int64) *redcloud.Column {
+	return &redcloud.Column{
+		Type:      redcloud.Column_TOMBSTONE,
+		Timestamp: timestamp,
+	}
+}
+
+func TestMergeColumnFamilies(t *testing.T) {
+	var a = &redcloud.ColumnFamily{
+		Key: []byte("row"),
+		ColumnSet: []*redcloud.ColumnSet{
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "b10")}},
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "a10")}},
+		},
+	}
+	var b = &redcloud.ColumnFamily{
+		Key: []byte("row"),
+		ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(20, 0, "a20")}},
+			{Name: "c", Column: []*redcloud.Column{dataColumn(5, 0, "c5")}},
+		},
+	}
+	var expected = &redcloud.ColumnFamily{
+		Key: []byte("row"),
+		ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(20, 0, "a20"), dataColumn(10, 0, "a10")}},
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "b10")}},
+			{Name: "c", Column: []*redcloud.Column{dataColumn(5, 0, "c5")}},
+		},
+	}
+
+	MergeColumnFamilies(a, b)
+
+	if !proto.Equal(a, expected) {
+		t.Errorf("Unexpected merge result: %v (expected %v)", a, expected)
+	}
+}
+
+func TestExpireColumns(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Data without a TTL never expires.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "x")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "x")}},
+		}},
+		// Only the expired version is removed.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(900, 200, "new"), dataColumn(10, 20, "old")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(900, 200, "new")}},
+		}},
+		// Column sets without versions are removed.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 20, "x")}},
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "y")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "y")}},
+		}},
+		// Tombstones never expire.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{tombstoneColumn(10)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{tombstoneColumn(10)}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		if !ExpireColumns(in, 1000) {
+			t.Errorf("No data left after expiry, expected %v", expected)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected expiry result: %v (expected %v)",
+				in, expected)
+		}
+	}
+
+	in = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+		{Name: "a", Column: []*redcloud.Column{dataColumn(10, 20, "x")}},
+	}}
+	if ExpireColumns(in, 1000) {
+		t.Errorf("Data left after expiring everything: %v", in)
+	}
+}
+
+func TestDropVersionsAfter(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Versions up to and including the read timestamp are kept.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(2000, 0, "new"), dataColumn(1000, 0, "at"),
+				dataColumn(10, 0, "old")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(1000, 0, "at"), dataColumn(10, 0, "old")}},
+		}},
+		// Later deletions are ignored.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				tombstoneColumn(1500), dataColumn(10, 0, "x")}},
+			{Name: "b", Column: []*redcloud.Column{tombstoneColumn(1500)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "x")}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		if !DropVersionsAfter(in, 1000) {
+			t.Errorf("No data left at read timestamp, expected %v", expected)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected data at read timestamp: %v (expected %v)",
+				in, expected)
+		}
+	}
+
+	in = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+		{Name: "a", Column: []*redcloud.Column{dataColumn(1001, 0, "x")}},
+	}}
+	if DropVersionsAfter(in, 1000) {
+		t.Errorf("Data left before it was written: %v", in)
+	}
+}
+
+func TestPurgeTombstones(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Data newer than the tombstone survives.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new"), tombstoneColumn(20),
+				dataColumn(20, 0, "same"), dataColumn(10, 0, "old")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new")}},
+		}},
+		// Tombstones only affect their own column.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				tombstoneColumn(20), dataColumn(10, 0, "old")}},
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "x")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "b", Column: []*redcloud.Column{dataColumn(10, 0, "x")}},
+		}},
+		// The newest tombstone wins.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				tombstoneColumn(5), dataColumn(30, 0, "new"),
+				dataColumn(10, 0, "old"), tombstoneColumn(15)}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new")}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		if !PurgeTombstones(in) {
+			t.Errorf("No data left after purging, expected %v", expected)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected purge result: %v (expected %v)",
+				in, expected)
+		}
+	}
+
+	in = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+		{Name: "a", Column: []*redcloud.Column{
+			tombstoneColumn(20), dataColumn(10, 0, "old")}},
+	}}
+	if PurgeTombstones(in) {
+		t.Errorf("Data left after deleting everything: %v", in)
+	}
+}
+
+func TestPurgeTombstonesBefore(t *testing.T) {
+	var testdata = map[int64]*redcloud.ColumnFamily{
+		// Tombstones older than the cutoff are purged.
+		25: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new")}},
+		}},
+		// Newer ones are kept, but the data they cover is still removed.
+		20: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new"), tombstoneColumn(20)}},
+		}},
+	}
+	var cutoff int64
+	var expected *redcloud.ColumnFamily
+
+	for cutoff, expected = range testdata {
+		var in = &redcloud.ColumnFamily{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new"), tombstoneColumn(20),
+				dataColumn(10, 0, "old")}},
+		}}
+
+		if !PurgeTombstonesBefore(in, cutoff) {
+			t.Errorf("Nothing left after purging before %d", cutoff)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected purge result before %d: %v (expected %v)",
+				cutoff, in, expected)
+		}
+	}
+}
+
+func TestLimitVersions(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Only the newest versions are kept.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(990, 0, "3"), dataColumn(980, 0, "2"),
+				dataColumn(970, 0, "1")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(990, 0, "3"), dataColumn(980, 0, "2")}},
+		}},
+		// Old versions are dropped, but the newest one is always kept.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(500, 0, "new"), dataColumn(400, 0, "old")}},
+			{Name: "b", Column: []*redcloud.Column{
+				dataColumn(950, 0, "new"), dataColumn(920, 0, "recent")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				dataColumn(500, 0, "new")}},
+			{Name: "b", Column: []*redcloud.Column{
+				dataColumn(950, 0, "new"), dataColumn(920, 0, "recent")}},
+		}},
+		// Tombstones are not counted as versions.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				tombstoneColumn(995), dataColumn(990, 0, "2"),
+				dataColumn(980, 0, "1")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{
+				tombstoneColumn(995), dataColumn(990, 0, "2"),
+				dataColumn(980, 0, "1")}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		if !LimitVersions(in, 2, 100, 1000) {
+			t.Errorf("No data left after limiting versions, expected %v",
+				expected)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected version limiting result: %v (expected %v)",
+				in, expected)
+		}
+	}
+}
+
+func rangeTombstoneColumn(timestamp int64, end string) *redcloud.Column {
+	return &redcloud.Column{
+		Type:      redcloud.Column_RANGE_TOMBSTONE,
+		Timestamp: timestamp,
+		EndColumn: end,
+	}
+}
+
+func TestPurgeRangeTombstones(t *testing.T) {
+	var testdata = map[*redcloud.ColumnFamily]*redcloud.ColumnFamily{
+		// Range tombstones cover the columns in their range only.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "a")}},
+			{Name: "b", Column: []*redcloud.Column{
+				rangeTombstoneColumn(20, "d"), dataColumn(10, 0, "b")}},
+			{Name: "c", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new"), dataColumn(10, 0, "old")}},
+			{Name: "d", Column: []*redcloud.Column{dataColumn(10, 0, "d")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "a")}},
+			{Name: "c", Column: []*redcloud.Column{dataColumn(30, 0, "new")}},
+			{Name: "d", Column: []*redcloud.Column{dataColumn(10, 0, "d")}},
+		}},
+		// An open ended range tombstone at the empty name covers the row.
+		{ColumnSet: []*redcloud.ColumnSet{
+			{Name: "", Column: []*redcloud.Column{
+				rangeTombstoneColumn(20, "")}},
+			{Name: "a", Column: []*redcloud.Column{dataColumn(10, 0, "a")}},
+			{Name: "z", Column: []*redcloud.Column{
+				dataColumn(30, 0, "new"), dataColumn(20, 0, "old")}},
+		}}: {ColumnSet: []*redcloud.ColumnSet{
+			{Name: "z", Column: []*redcloud.Column{dataColumn(30, 0, "new")}},
+		}},
+	}
+	var in, expected *redcloud.ColumnFamily
+
+	for in, expected = range testdata {
+		if !PurgeTombstones(in) {
+			t.Errorf("No data left after purging, expected %v", expected)
+		}
+		if !proto.Equal(in, expected) {
+			t.Errorf("Unexpected purge result: %v (expected %v)",
+				in, expected)
+		}
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	var columns = NewColumnSelector([]string{"d", "b"})
+	var named = &redcloud.ColumnSet{Name: "d", Column: []*redcloud.Column{
+		dataColumn(10, 0, "d")}}
+	var covering = &redcloud.ColumnSet{Name: "c", Column: []*redcloud.Column{
+		dataColumn(10, 0, "c"), rangeTombstoneColumn(20, "e")}}
+	var notCovering = &redcloud.ColumnSet{Name: "c", Column: []*redcloud.Column{
+		dataColumn(10, 0, "c"), rangeTombstoneColumn(20, "d")}}
+	var selected *redcloud.ColumnSet
+
+	if selected = SelectColumns(named, columns); selected != named {
+		t.Errorf("Requested column not selected: %v", selected)
+	}
+
+	selected = SelectColumns(covering, columns)
+	if !proto.Equal(selected, &redcloud.ColumnSet{
+		Name:   "c",
+		Column: []*redcloud.Column{rangeTombstoneColumn(20, "e")},
+	}) {
+		t.Errorf("Unexpected selection of covering tombstone: %v", selected)
+	}
+
+	if selected = SelectColumns(notCovering, columns); selected != nil {
+		t.Errorf("Unexpected selection of unrelated column: %v", selected)
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"go.opencensus.io/trace"
+)
+
+/*
+sortedKeys returns a sorted copy of the keys with all duplicates removed.
+*/
+func sortedKeys(keys [][]byte) [][]byte {
+	var sorted = append([][]byte{}, keys...)
+	var rv [][]byte
+	var key []byte
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	for _, key = range sorted {
+		if len(rv) == 0 || !bytes.Equal(rv[len(rv)-1], key) {
+			rv = append(rv, key)
+		}
+	}
+
+	return rv
+}
+
+/*
+LookupKeysInSstable finds the records for all of the specified keys in the
+specified sstable, reporting only the selected columns. The file is opened
+only once, and the keys are looked up in key order so the file is read
+strictly forward. Results, errors and completion are reported through the
+channels like in LookupInSstable.
+*/
+func LookupKeysInSstable(parentCtx context.Context, path string,
+	columns *ColumnSelector, keys [][]byte,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var ctx context.Context
+	var span *trace.Span
+	var r *cachedReader
+	var missing [][]byte
+	var key []byte
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx,
+		"red-cloud.storage/LookupKeysInSstable")
+	defer span.End()
+	sstableLookups.Inc()
+
+	span.AddAttributes(
+		trace.StringAttribute("path", path),
+		trace.Int64Attribute("num-keys", int64(len(keys))))
+
+	defer markDone(done)
+
+	// Keys found in the block cache don't need to be looked up in the file.
+	for _, key = range sortedKeys(keys) {
+		var cf *redcloud.ColumnFamily
+		var rcf *redcloud.ColumnFamily
+		var cached bool
+
+		if cf, cached = sstableCache.getBlock(path, key); !cached {
+			missing = append(missing, key)
+		} else if rcf = selectRow(cf, columns, nil); rcf != nil {
+			sstableLookupNumResults.Inc()
+			results <- rcf
+		}
+	}
+
+	if len(missing) == 0 {
+		return
+	}
+
+	if r, err = sstableCache.acquire(ctx, span, path); err != nil {
+		errors <- err
+		return
+	}
+	// Readers which failed may be in an inconsistent state.
+	defer func() {
+		sstableCache.release(r, err == nil || err == io.EOF)
+	}()
+
+	for _, key = range missing {
+		var cf = new(redcloud.ColumnFamily)
+		var rcf *redcloud.ColumnFamily
+		var found string
+
+		// Nobody is interested in the results any more.
+		if ctx.Err() != nil {
+			return
+		}
+
+		if found, err = r.reader.ReadSubsequentProto(
+			ctx, string(key), cf); err == io.EOF {
+			// There are no more records following this key.
+			return
+		} else if err != nil {
+			reportSstableReadError(span, err)
+			errors <- err
+			return
+		}
+
+		if found != string(key) {
+			continue
+		}
+
+		sstableCache.putBlock(path, key, cf)
+
+		if rcf = selectRow(cf, columns, nil); rcf != nil {
+			sstableLookupNumResults.Inc()
+			results <- rcf
+		}
+	}
+}
+
+/*
+LookupKeysInJournal finds the records for all of the specified keys in the
+specified journal, reporting only the selected columns. The journal is read
+only once for all keys. Results, errors and completion are reported through
+the channels like in LookupInJournal.
+*/
+func LookupKeysInJournal(ctx context.Context, path string,
+	columns *ColumnSelector, keys [][]byte,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var unfiltered = make(chan *redcloud.ColumnFamily)
+	var journalDone = make(chan struct{})
+	var wanted = make(map[string]bool)
+	var sorted [][]byte
+	var key []byte
+	var kr *common.KeyRange
+	var complete bool
+
+	defer markDone(done)
+
+	if len(keys) == 0 {
+		return
+	}
+
+	sorted = sortedKeys(keys)
+	for _, key = range sorted {
+		wanted[string(key)] = true
+	}
+
+	// Only consider records between the first and the last key.
+	kr = common.NewKeyRange(sorted[0],
+		append(append([]byte{}, sorted[len(sorted)-1]...), 0))
+
+	go LookupInJournal(ctx, path, columns, nil, kr, unfiltered, errors,
+		journalDone)
+
+	for !complete {
+		var cf *redcloud.ColumnFamily
+
+		select {
+		case cf = <-unfiltered:
+			if wanted[string(cf.Key)] {
+				results <- cf
+			}
+		case <-journalDone:
+			complete = true
+		}
+	}
+}
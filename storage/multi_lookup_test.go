@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSortedKeys(t *testing.T) {
+	var testdata = map[string][]string{
+		"[]":      {},
+		"[a]":     {"a"},
+		"[a b c]": {"c", "a", "b"},
+		"[a b]":   {"b", "a", "b", "a"},
+		"[ a]":    {"a", ""},
+	}
+	var expected string
+	var keys []string
+
+	for expected, keys = range testdata {
+		var input [][]byte
+		var sorted []string
+		var key []byte
+		var i int
+
+		for i = range keys {
+			input = append(input, []byte(keys[i]))
+		}
+
+		for _, key = range sortedKeys(input) {
+			sorted = append(sorted, string(key))
+		}
+
+		if fmt.Sprint(sorted) != expected {
+			t.Errorf("Unexpected sorted keys for %v: %v (expected %s)",
+				keys, sorted, expected)
+		}
+	}
+}
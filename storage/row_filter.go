@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+RowFilter evaluates a number of redcloud.RowFilter conditions on rows. A row
+matches only if all conditions are met. A nil RowFilter matches all rows.
+*/
+type RowFilter struct {
+	filters []*redcloud.RowFilter
+
+	// Compiled regular expressions of the filters, or nil if unused.
+	regexes []*regexp.Regexp
+}
+
+/*
+NewRowFilter creates a RowFilter for the specified conditions. Conditions
+without a column family refer to "defaultFamily". Returns an error if any
+of the conditions is invalid. If there are no conditions, nil is returned.
+*/
+func NewRowFilter(filters []*redcloud.RowFilter,
+	defaultFamily string) (*RowFilter, error) {
+	var rv *RowFilter
+	var filter *redcloud.RowFilter
+	var err error
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	rv = new(RowFilter)
+	for _, filter = range filters {
+		var re *regexp.Regexp
+
+		switch filter.Type {
+		case redcloud.RowFilter_VALUE_REGEX, redcloud.RowFilter_KEY_REGEX:
+			if re, err = regexp.Compile(filter.Regex); err != nil {
+				return nil, err
+			}
+		case redcloud.RowFilter_VALUE_EQUALS, redcloud.RowFilter_VALUE_PREFIX,
+			redcloud.RowFilter_VALUE_LENGTH, redcloud.RowFilter_HAS_COLUMN:
+		default:
+			return nil, fmt.Errorf("Unknown filter type %s", filter.Type)
+		}
+
+		if filter.Type != redcloud.RowFilter_KEY_REGEX &&
+			len(filter.Column) == 0 {
+			return nil, fmt.Errorf("Filter of type %s requires a column",
+				filter.Type)
+		}
+
+		if len(filter.ColumnFamily) == 0 {
+			filter = proto.Clone(filter).(*redcloud.RowFilter)
+			filter.ColumnFamily = defaultFamily
+		}
+
+		rv.filters = append(rv.filters, filter)
+		rv.regexes = append(rv.regexes, re)
+	}
+
+	return rv, nil
+}
+
+/*
+Columns returns the names of all columns of the column family which need to
+be read in order to evaluate the filter.
+*/
+func (f *RowFilter) Columns(columnFamily string) []string {
+	var rv []string
+	var filter *redcloud.RowFilter
+
+	if f == nil {
+		return rv
+	}
+
+	for _, filter = range f.filters {
+		if filter.Type != redcloud.RowFilter_KEY_REGEX &&
+			filter.ColumnFamily == columnFamily {
+			rv = append(rv, filter.Column)
+		}
+	}
+
+	return rv
+}
+
+/*
+MatchesKey determines whether the row key satisfies all conditions on keys.
+Conditions on column values are not taken into account, since they can only
+be evaluated once all data of the row is known. This allows lookups to skip
+rows early.
+*/
+func (f *RowFilter) MatchesKey(key []byte) bool {
+	var filter *redcloud.RowFilter
+	var i int
+
+	if f == nil {
+		return true
+	}
+
+	for i, filter = range f.filters {
+		if filter.Type == redcloud.RowFilter_KEY_REGEX &&
+			!f.regexes[i].Match(key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Matches determines whether the row satisfies all conditions. "rows" holds
+the data of the row for each column family read, with all deleted, expired
+and excess versions already removed.
+*/
+func (f *RowFilter) Matches(
+	key []byte, rows map[string]*redcloud.ColumnFamily) bool {
+	var filter *redcloud.RowFilter
+	var i int
+
+	if f == nil {
+		return true
+	}
+
+	for i, filter = range f.filters {
+		var latest *redcloud.Column
+		var length int64
+
+		if filter.Type == redcloud.RowFilter_KEY_REGEX {
+			if !f.regexes[i].Match(key) {
+				return false
+			}
+			continue
+		}
+
+		if latest = latestVersion(
+			rows[filter.ColumnFamily], filter.Column); latest == nil {
+			// None of the conditions on values can be met without a value.
+			return false
+		}
+
+		switch filter.Type {
+		case redcloud.RowFilter_VALUE_EQUALS:
+			if !bytes.Equal(latest.Content, filter.Value) {
+				return false
+			}
+		case redcloud.RowFilter_VALUE_PREFIX:
+			if !bytes.HasPrefix(latest.Content, filter.Value) {
+				return false
+			}
+		case redcloud.RowFilter_VALUE_REGEX:
+			if !f.regexes[i].Match(latest.Content) {
+				return false
+			}
+		case redcloud.RowFilter_VALUE_LENGTH:
+			length = int64(len(latest.Content))
+			if length < filter.MinLength ||
+				(filter.MaxLength > 0 && length > filter.MaxLength) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/*
+latestVersion finds the latest version of the named column in the row, or
+returns nil if the column holds no data.
+*/
+func latestVersion(row *redcloud.ColumnFamily, name string) *redcloud.Column {
+	var cset *redcloud.ColumnSet
+
+	if row == nil {
+		return nil
+	}
+
+	for _, cset = range row.ColumnSet {
+		if cset.Name == name && len(cset.Column) > 0 {
+			// Versions are ordered newest first.
+			return cset.Column[0]
+		}
+	}
+
+	return nil
+}
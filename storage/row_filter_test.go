@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+)
+
+func TestRowFilterMatches(t *testing.T) {
+	var rows = map[string]*redcloud.ColumnFamily{
+		"cf": {
+			Key: []byte("user-17"),
+			ColumnSet: []*redcloud.ColumnSet{
+				{Name: "status", Column: []*redcloud.Column{
+					dataColumn(20, 0, "active"), dataColumn(10, 0, "new")}},
+			},
+		},
+	}
+	var testdata = map[*redcloud.RowFilter]bool{
+		{Type: redcloud.RowFilter_VALUE_EQUALS, Column: "status",
+			Value: []byte("active")}: true,
+		// Only the latest version is taken into account.
+		{Type: redcloud.RowFilter_VALUE_EQUALS, Column: "status",
+			Value: []byte("new")}: false,
+		{Type: redcloud.RowFilter_VALUE_PREFIX, Column: "status",
+			Value: []byte("act")}: true,
+		{Type: redcloud.RowFilter_VALUE_REGEX, Column: "status",
+			Regex: "^a.*e$"}: true,
+		{Type: redcloud.RowFilter_KEY_REGEX, Regex: "^user-[0-9]+$"}: true,
+		{Type: redcloud.RowFilter_KEY_REGEX, Regex: "^group-"}:       false,
+		{Type: redcloud.RowFilter_VALUE_LENGTH, Column: "status",
+			MinLength: 2, MaxLength: 6}: true,
+		{Type: redcloud.RowFilter_VALUE_LENGTH, Column: "status",
+			MaxLength: 5}: false,
+		{Type: redcloud.RowFilter_HAS_COLUMN, Column: "status"}: true,
+		{Type: redcloud.RowFilter_HAS_COLUMN, Column: "email"}:  false,
+		{Type: redcloud.RowFilter_HAS_COLUMN, ColumnFamily: "other",
+			Column: "status"}: false,
+	}
+	var in *redcloud.RowFilter
+	var expected bool
+
+	for in, expected = range testdata {
+		var filter *RowFilter
+		var err error
+
+		if filter, err = NewRowFilter(
+			[]*redcloud.RowFilter{in}, "cf"); err != nil {
+			t.Errorf("Error creating filter %v: %s", in, err)
+			continue
+		}
+
+		if filter.Matches([]byte("user-17"), rows) != expected {
+			t.Errorf("Unexpected result of filter %v: %v", in, !expected)
+		}
+	}
+}
+
+func TestRowFilterMatchesKey(t *testing.T) {
+	var filter *RowFilter
+	var err error
+
+	if filter, err = NewRowFilter([]*redcloud.RowFilter{
+		{Type: redcloud.RowFilter_KEY_REGEX, Regex: "^a"},
+		{Type: redcloud.RowFilter_HAS_COLUMN, Column: "x"},
+	}, "cf"); err != nil {
+		t.Fatalf("Error creating filter: %s", err)
+	}
+
+	if !filter.MatchesKey([]byte("abc")) {
+		t.Error("Key matching the filter is rejected")
+	}
+	if filter.MatchesKey([]byte("bcd")) {
+		t.Error("Key not matching the filter is accepted")
+	}
+
+	filter = nil
+	if !filter.MatchesKey([]byte("bcd")) {
+		t.Error("Empty filter rejects a key")
+	}
+}
+
+func TestNewRowFilterInvalid(t *testing.T) {
+	var testdata = []*redcloud.RowFilter{
+		{Type: redcloud.RowFilter_KEY_REGEX, Regex: "("},
+		{Type: redcloud.RowFilter_VALUE_EQUALS},
+		{Type: redcloud.RowFilter_FilterType(42), Column: "x"},
+	}
+	var in *redcloud.RowFilter
+	var err error
+
+	for _, in = range testdata {
+		if _, err = NewRowFilter(
+			[]*redcloud.RowFilter{in}, "cf"); err == nil {
+			t.Errorf("Invalid filter %v accepted", in)
+		}
+	}
+}
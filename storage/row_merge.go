@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+)
+
+/*
+RowSource collects the results of a single lookup (see LookupInSstable and
+LookupInJournal). The channels are meant to be passed to the lookup function
+as its results, errors and done channels.
+*/
+type RowSource struct {
+	Results chan *redcloud.ColumnFamily
+	Errors  chan error
+	Done    chan struct{}
+
+	head     *redcloud.ColumnFamily
+	finished bool
+}
+
+/*
+NewRowSource creates a new RowSource with fresh channels for a lookup to
+report to.
+*/
+func NewRowSource() *RowSource {
+	return &RowSource{
+		Results: make(chan *redcloud.ColumnFamily),
+		Errors:  make(chan error),
+		Done:    make(chan struct{}),
+	}
+}
+
+/*
+next waits for the next row reported by the lookup and stores it as the head
+of the source. If the lookup has completed instead, the source is marked as
+finished. Any errors reported in the meantime are returned.
+*/
+func (s *RowSource) next() []string {
+	var allErrors []string
+	var err error
+
+	s.head = nil
+	for !s.finished && s.head == nil {
+		select {
+		case s.head = <-s.Results:
+		case err = <-s.Errors:
+			allErrors = append(allErrors, err.Error())
+		case <-s.Done:
+			s.finished = true
+		}
+	}
+
+	return allErrors
+}
+
+/*
+Drain discards everything reported by the lookup until it has completed, so
+lookups which are no longer of interest don't block forever.
+*/
+func (s *RowSource) Drain() {
+	for !s.finished {
+		s.next()
+	}
+}
+
+/*
+MergeRows performs a k-way merge by row key over the rows reported by all
+sources. Every source must report its rows in key order, or in reverse key
+order if "reverse" is set.
+
+Each row is passed to "emit" exactly once, in the same order, with the versions
+from all sources merged. If "emit" returns false, merging stops and the
+remaining results are discarded; the lookups should be cancelled through
+their context in that case. Returns all errors reported by the lookups.
+*/
+func MergeRows(sources []*RowSource, reverse bool,
+	emit func(*redcloud.ColumnFamily) bool) []string {
+	return MergeRowsBySource(sources, reverse,
+		func(key []byte, rows []*redcloud.ColumnFamily) bool {
+			var merged = &redcloud.ColumnFamily{Key: key}
+			var row *redcloud.ColumnFamily
+
+			for _, row = range rows {
+				if row != nil {
+					MergeColumnFamilies(merged, row)
+				}
+			}
+
+			return emit(merged)
+		})
+}
+
+/*
+MergeRowsBySource performs a k-way merge by row key over the rows reported by
+all sources, like MergeRows, but keeps the data from the different sources
+apart. For every row key, "emit" is passed the row as reported by each of
+the sources, in the order of the sources, or nil for sources which have no
+data for that row.
+*/
+func MergeRowsBySource(sources []*RowSource, reverse bool,
+	emit func([]byte, []*redcloud.ColumnFamily) bool) []string {
+	var allErrors []string
+	var source *RowSource
+
+	for _, source = range sources {
+		allErrors = append(allErrors, source.next()...)
+	}
+
+	for {
+		var rows = make([]*redcloud.ColumnFamily, len(sources))
+		var key []byte
+		var i int
+
+		/*
+			Find the smallest key among the heads of all sources, or the
+			largest one when merging in reverse.
+		*/
+		for _, source = range sources {
+			if source.head != nil && (key == nil ||
+				(bytes.Compare(source.head.Key, key) < 0) != reverse) {
+				key = source.head.Key
+			}
+		}
+
+		if key == nil {
+			// All sources have been exhausted.
+			return allErrors
+		}
+
+		// Collect the data of all sources for this row and move them on.
+		for i, source = range sources {
+			for source.head != nil && bytes.Equal(source.head.Key, key) {
+				if rows[i] == nil {
+					rows[i] = &redcloud.ColumnFamily{Key: key}
+				}
+				MergeColumnFamilies(rows[i], source.head)
+				allErrors = append(allErrors, source.next()...)
+			}
+		}
+
+		if !emit(key, rows) {
+			for _, source = range sources {
+				go source.Drain()
+			}
+			return allErrors
+		}
+	}
+}
+
+/*
+ReverseWindow limits the number of rows ReverseRows keeps from each lookup.
+Lookups reporting more rows than that only have their last rows reported,
+and the floor of the window is raised to the lowest key reported by any of
+them. Rows below the floor are incomplete, so they have to be read in
+another pass over the range before the floor.
+*/
+type ReverseWindow struct {
+	size      int
+	floor     []byte
+	truncated bool
+	lock      sync.Mutex
+}
+
+/*
+NewReverseWindow creates a ReverseWindow keeping the last "size" rows of each
+lookup. A size of 0 keeps all rows.
+*/
+func NewReverseWindow(size int) *ReverseWindow {
+	return &ReverseWindow{size: size}
+}
+
+/*
+Floor returns the lowest key for which the data of all lookups is complete,
+and whether any lookup was truncated at all. Since every lookup has to be
+read completely before its first row is reported, the floor is final by the
+time the first row of all lookups has been reported.
+*/
+func (w *ReverseWindow) Floor() ([]byte, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.floor, w.truncated
+}
+
+/*
+raise moves the floor of the window up to "key" if it is lower.
+*/
+func (w *ReverseWindow) raise(key []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.truncated || bytes.Compare(key, w.floor) > 0 {
+		w.floor = key
+	}
+	w.truncated = true
+}
+
+/*
+ReverseRows collects all rows reported by the lookup feeding "in" and,
+once the lookup has completed, reports them through the channels of "out"
+in reverse order. Errors are passed on as soon as they are reported. Since
+sstables can only be read front to back, this allows lookups to report
+their rows in reverse key order.
+
+If "window" is set, only the last rows of the lookup are kept, up to the
+size of the window, and the window is told where they start.
+*/
+func ReverseRows(ctx context.Context, in, out *RowSource,
+	window *ReverseWindow) {
+	var rows []*redcloud.ColumnFamily
+	var row *redcloud.ColumnFamily
+	var err error
+	var complete bool
+	var dropped bool
+	var start int
+	var i int
+
+	defer markDone(out.Done)
+
+	for !complete {
+		select {
+		case row = <-in.Results:
+			// Keep the window in a ring buffer once it is full.
+			if window != nil && window.size > 0 &&
+				len(rows) == window.size {
+				rows[start] = row
+				start = (start + 1) % len(rows)
+				dropped = true
+			} else {
+				rows = append(rows, row)
+			}
+		case err = <-in.Errors:
+			out.Errors <- err
+		case <-in.Done:
+			complete = true
+		}
+	}
+
+	if dropped {
+		window.raise(rows[start].Key)
+	}
+
+	for i = len(rows) - 1; i >= 0; i-- {
+		// No need to report anything if nobody is interested any more.
+		if ctx.Err() != nil {
+			return
+		}
+
+		out.Results <- rows[(start+i)%len(rows)]
+	}
+}
+
+/*
+LookupInJournalSorted works like LookupInJournal, but reports the matching
+records in key order, with all records for the same key merged into one.
+Since journals are written in the order the mutations arrived in, all
+matching records have to be read before the first one can be reported.
+*/
+func LookupInJournalSorted(ctx context.Context, path string,
+	columns *ColumnSelector, filter *RowFilter, kr *common.KeyRange,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var unsorted = make(chan *redcloud.ColumnFamily)
+	var journalDone = make(chan struct{})
+	var rows = make(map[string]*redcloud.ColumnFamily)
+	var keys []string
+	var key string
+	var complete bool
+
+	defer markDone(done)
+
+	go LookupInJournal(ctx, path, columns, filter, kr, unsorted, errors,
+		journalDone)
+
+	for !complete {
+		var cf *redcloud.ColumnFamily
+		var row *redcloud.ColumnFamily
+		var ok bool
+
+		select {
+		case cf = <-unsorted:
+			if row, ok = rows[string(cf.Key)]; !ok {
+				row = &redcloud.ColumnFamily{Key: cf.Key}
+				rows[string(cf.Key)] = row
+				keys = append(keys, string(cf.Key))
+			}
+			MergeColumnFamilies(row, cf)
+		case <-journalDone:
+			complete = true
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key = range keys {
+		// No need to report anything if nobody is interested any more.
+		if ctx.Err() != nil {
+			return
+		}
+
+		results <- rows[key]
+	}
+}
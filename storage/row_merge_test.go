@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+func fakeRowSource(rows ...*redcloud.ColumnFamily) *RowSource {
+	var source = NewRowSource()
+
+	go func() {
+		var row *redcloud.ColumnFamily
+
+		defer markDone(source.Done)
+		for _, row = range rows {
+			source.Results <- row
+		}
+	}()
+
+	return source
+}
+
+func singleColumnRow(key, column string, col *redcloud.Column) *redcloud.ColumnFamily {
+	return &redcloud.ColumnFamily{
+		Key: []byte(key),
+		ColumnSet: []*redcloud.ColumnSet{
+			{Name: column, Column: []*redcloud.Column{col}},
+		},
+	}
+}
+
+func TestMergeRows(t *testing.T) {
+	var sources = []*RowSource{
+		fakeRowSource(
+			singleColumnRow("a", "x", dataColumn(10, 0, "a10")),
+			singleColumnRow("c", "x", dataColumn(10, 0, "c10"))),
+		fakeRowSource(
+			singleColumnRow("b", "x", dataColumn(20, 0, "b20")),
+			singleColumnRow("c", "x", dataColumn(20, 0, "c20"))),
+		fakeRowSource(),
+	}
+	var expected = []*redcloud.ColumnFamily{
+		singleColumnRow("a", "x", dataColumn(10, 0, "a10")),
+		singleColumnRow("b", "x", dataColumn(20, 0, "b20")),
+		{Key: []byte("c"), ColumnSet: []*redcloud.ColumnSet{
+			{Name: "x", Column: []*redcloud.Column{
+				dataColumn(20, 0, "c20"), dataColumn(10, 0, "c10")}},
+		}},
+	}
+	var rows []*redcloud.ColumnFamily
+	var allErrors []string
+	var i int
+
+	allErrors = MergeRows(sources, false, func(row *redcloud.ColumnFamily) bool {
+		rows = append(rows, row)
+		return true
+	})
+
+	if len(allErrors) > 0 {
+		t.Errorf("Unexpected errors merging rows: %v", allErrors)
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("Unexpected number of rows: %d (expected %d)",
+			len(rows), len(expected))
+	}
+	for i = range expected {
+		if !proto.Equal(rows[i], expected[i]) {
+			t.Errorf("Unexpected row %d: %v (expected %v)",
+				i, rows[i], expected[i])
+		}
+	}
+}
+
+func TestMergeRowsStop(t *testing.T) {
+	var sources = []*RowSource{
+		fakeRowSource(
+			singleColumnRow("a", "x", dataColumn(10, 0, "a10")),
+			singleColumnRow("b", "x", dataColumn(10, 0, "b10")),
+			singleColumnRow("c", "x", dataColumn(10, 0, "c10"))),
+	}
+	var numRows int
+
+	MergeRows(sources, false, func(row *redcloud.ColumnFamily) bool {
+		numRows++
+		return false
+	})
+
+	if numRows != 1 {
+		t.Errorf("Unexpected number of rows after stopping: %d", numRows)
+	}
+}
+
+func TestMergeRowsBySource(t *testing.T) {
+	var sources = []*RowSource{
+		fakeRowSource(
+			singleColumnRow("a", "x", dataColumn(10, 0, "a-first"))),
+		fakeRowSource(
+			singleColumnRow("a", "x", dataColumn(20, 0, "a-second")),
+			singleColumnRow("b", "x", dataColumn(20, 0, "b-second"))),
+	}
+	var keys []string
+	var allRows [][]*redcloud.ColumnFamily
+
+	MergeRowsBySource(sources, false,
+		func(key []byte, rows []*redcloud.ColumnFamily) bool {
+			keys = append(keys, string(key))
+			allRows = append(allRows, rows)
+			return true
+		})
+
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+	if !proto.Equal(allRows[0][0], singleColumnRow(
+		"a", "x", dataColumn(10, 0, "a-first"))) {
+		t.Errorf("Unexpected data from first source: %v", allRows[0][0])
+	}
+	if !proto.Equal(allRows[0][1], singleColumnRow(
+		"a", "x", dataColumn(20, 0, "a-second"))) {
+		t.Errorf("Unexpected data from second source: %v", allRows[0][1])
+	}
+	if allRows[1][0] != nil {
+		t.Errorf("Unexpected data from first source: %v", allRows[1][0])
+	}
+}
+
+func TestMergeRowsReverse(t *testing.T) {
+	var forward = fakeRowSource(
+		singleColumnRow("a", "x", dataColumn(10, 0, "a10")),
+		singleColumnRow("c", "x", dataColumn(10, 0, "c10")))
+	var reversed = NewRowSource()
+	var sources = []*RowSource{
+		reversed,
+		fakeRowSource(
+			singleColumnRow("b", "x", dataColumn(20, 0, "b20")),
+			singleColumnRow("a", "x", dataColumn(20, 0, "a20"))),
+	}
+	var keys []string
+
+	go ReverseRows(context.Background(), forward, reversed, nil)
+
+	MergeRows(sources, true, func(row *redcloud.ColumnFamily) bool {
+		keys = append(keys, string(row.Key))
+		return true
+	})
+
+	if len(keys) != 3 || keys[0] != "c" || keys[1] != "b" || keys[2] != "a" {
+		t.Errorf("Unexpected order of keys in reverse merge: %v", keys)
+	}
+}
+
+func TestReverseRowsWindow(t *testing.T) {
+	var windows = map[int]string{
+		0: "dcba",
+		2: "dc",
+		4: "dcba",
+		5: "dcba",
+	}
+	var size int
+	var want string
+
+	for size, want = range windows {
+		var forward = fakeRowSource(
+			singleColumnRow("a", "x", dataColumn(10, 0, "a")),
+			singleColumnRow("b", "x", dataColumn(10, 0, "b")),
+			singleColumnRow("c", "x", dataColumn(10, 0, "c")),
+			singleColumnRow("d", "x", dataColumn(10, 0, "d")))
+		var reversed = NewRowSource()
+		var window = NewReverseWindow(size)
+		var keys string
+		var floor []byte
+		var truncated bool
+
+		go ReverseRows(context.Background(), forward, reversed, window)
+
+		MergeRows([]*RowSource{reversed}, true,
+			func(row *redcloud.ColumnFamily) bool {
+				keys += string(row.Key)
+				return true
+			})
+
+		if keys != want {
+			t.Errorf("Unexpected keys with window of %d: got %s, want %s",
+				size, keys, want)
+		}
+
+		floor, truncated = window.Floor()
+		if truncated != (len(want) < 4) {
+			t.Errorf("Unexpected truncation with window of %d: %v",
+				size, truncated)
+		}
+		if truncated && string(floor) != want[len(want)-1:] {
+			t.Errorf("Unexpected floor with window of %d: %s", size, floor)
+		}
+	}
+}
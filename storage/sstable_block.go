@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"hash/crc32"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/childoftheuniverse/sstable"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+sstableBlockSize is the encoded size of the rows at which a block is
+written to the sstable. Every block is read as a whole, so this is the
+minimum amount of data read for every lookup.
+*/
+const sstableBlockSize = 32 << 10
+
+/*
+sstableBlockSource reads the records of an sstable, as implemented by
+sstable.Reader.
+*/
+type sstableBlockSource interface {
+	ReadNextProto(ctx context.Context, msg proto.Message) (string, error)
+	ReadSubsequentProto(ctx context.Context, key string, msg proto.Message) (
+		string, error)
+}
+
+/*
+sstableBlockSink writes the records of an sstable, as implemented by
+sstable.Writer.
+*/
+type sstableBlockSink interface {
+	WriteProto(ctx context.Context, key string, msg proto.Message) error
+}
+
+/*
+BlockWriter writes rows to an sstable, collecting consecutive rows in
+blocks which are compressed and protected by a checksum. Rows have to be
+written in key order, and Flush has to be called once all rows have been
+written.
+*/
+type BlockWriter struct {
+	out         sstableBlockSink
+	compression redcloud.Compression
+	rows        []*redcloud.ColumnFamily
+	size        int
+}
+
+/*
+NewBlockWriter creates a BlockWriter writing the sstable to sst, and an
+index of all of its blocks to idx. Blocks are compressed with the specified
+compression.
+*/
+func NewBlockWriter(ctx context.Context, sst, idx filesystem.WriteCloser,
+	compression redcloud.Compression) *BlockWriter {
+	return newBlockWriter(sstable.NewIndexedWriter(
+		ctx, sst, idx, sstable.IndexType_EVERY_N, 1), compression)
+}
+
+/*
+newBlockWriter creates a BlockWriter writing its blocks to out.
+*/
+func newBlockWriter(out sstableBlockSink,
+	compression redcloud.Compression) *BlockWriter {
+	return &BlockWriter{out: out, compression: compression}
+}
+
+/*
+WriteProto adds a copy of the row, which has to be a ColumnFamily, to the
+current block, and writes the block once it is full. The key is taken from
+the row itself.
+*/
+func (w *BlockWriter) WriteProto(ctx context.Context, key string,
+	msg proto.Message) error {
+	var row = proto.Clone(msg).(*redcloud.ColumnFamily)
+
+	w.rows = append(w.rows, row)
+	if w.size += proto.Size(row); w.size >= sstableBlockSize {
+		return w.Flush(ctx)
+	}
+
+	return nil
+}
+
+/*
+Flush writes the rows collected so far as a block, if there are any. The
+checksum covers the block data as stored, i.e. after compression.
+*/
+func (w *BlockWriter) Flush(ctx context.Context) error {
+	var block redcloud.SSTableBlock
+	var last *redcloud.ColumnFamily
+	var data []byte
+	var err error
+
+	if len(w.rows) == 0 {
+		return nil
+	}
+
+	if data, err = proto.Marshal(
+		&redcloud.SSTableBlockData{Row: w.rows}); err != nil {
+		return err
+	}
+	if block.Data, block.Compression, err = compressBlock(
+		data, w.compression); err != nil {
+		return err
+	}
+	block.HasChecksum = true
+	block.Checksum = crc32.Checksum(block.Data, castagnoliTable)
+
+	last = w.rows[len(w.rows)-1]
+	w.rows = nil
+	w.size = 0
+
+	return w.out.WriteProto(ctx, string(last.Key), &block)
+}
+
+/*
+BlockReader reads the rows of an sstable written by a BlockWriter, verifying
+the checksum of every block read. Sstables written before blocks were
+introduced, which hold a single row in every record, are read as well.
+*/
+type BlockReader struct {
+	in   sstableBlockSource
+	rows []*redcloud.ColumnFamily
+}
+
+/*
+NewBlockReader creates a BlockReader reading the rows of the sstable read
+by in.
+*/
+func NewBlockReader(in *sstable.Reader) *BlockReader {
+	return newBlockReader(in)
+}
+
+/*
+newBlockReader creates a BlockReader reading its blocks from in.
+*/
+func newBlockReader(in sstableBlockSource) *BlockReader {
+	return &BlockReader{in: in}
+}
+
+/*
+decodeBlock verifies the checksum of a block read from an sstable, then
+decompresses and decodes its rows. Returns common.ErrChecksumMismatch if the
+block has been corrupted.
+*/
+func decodeBlock(block *redcloud.SSTableBlock) (
+	[]*redcloud.ColumnFamily, error) {
+	var data redcloud.SSTableBlockData
+	var decompressed []byte
+	var err error
+
+	if !block.HasChecksum {
+		// The flag may have been lost, but the data can't be trusted then.
+		if len(block.Data) > 0 {
+			return nil, common.ErrChecksumMismatch
+		}
+
+		return []*redcloud.ColumnFamily{{
+			Key:       block.Key,
+			ColumnSet: block.ColumnSet,
+		}}, nil
+	}
+
+	if crc32.Checksum(block.Data, castagnoliTable) != block.Checksum {
+		return nil, common.ErrChecksumMismatch
+	}
+
+	if decompressed, err = decompressBlock(
+		block.Data, block.Compression); err != nil {
+		return nil, err
+	}
+
+	if err = proto.Unmarshal(decompressed, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Row, nil
+}
+
+/*
+ReadNextProto stores the next row of the sstable in msg and returns its
+key. Returns io.EOF once all rows have been read, or
+common.ErrChecksumMismatch if a corrupted block has been read.
+*/
+func (r *BlockReader) ReadNextProto(ctx context.Context,
+	msg proto.Message) (string, error) {
+	var block redcloud.SSTableBlock
+	var row *redcloud.ColumnFamily
+	var err error
+
+	for len(r.rows) == 0 {
+		block.Reset()
+		if _, err = r.in.ReadNextProto(ctx, &block); err != nil {
+			return "", err
+		}
+		if r.rows, err = decodeBlock(&block); err != nil {
+			return "", err
+		}
+	}
+
+	row, r.rows = r.rows[0], r.rows[1:]
+	msg.Reset()
+	proto.Merge(msg, row)
+	return string(row.Key), nil
+}
+
+/*
+ReadSubsequentProto stores the first row of the sstable whose key is not
+less than key in msg and returns its key. Subsequent rows can be read using
+ReadNextProto. Returns io.EOF if there is no such row, or
+common.ErrChecksumMismatch if a corrupted block has been read.
+*/
+func (r *BlockReader) ReadSubsequentProto(ctx context.Context, key string,
+	msg proto.Message) (string, error) {
+	var block redcloud.SSTableBlock
+	var err error
+
+	r.rows = nil
+	if _, err = r.in.ReadSubsequentProto(ctx, key, &block); err != nil {
+		return "", err
+	}
+	if r.rows, err = decodeBlock(&block); err != nil {
+		return "", err
+	}
+
+	for len(r.rows) > 0 && bytes.Compare(r.rows[0].Key, []byte(key)) < 0 {
+		r.rows = r.rows[1:]
+	}
+
+	return r.ReadNextProto(ctx, msg)
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/childoftheuniverse/red-cloud/common"
+	"github.com/golang/protobuf/proto"
+)
+
+/*
+fakeSstable stores encoded records in memory, in the order they were
+written, and reads them back like an indexed sstable.
+*/
+type fakeSstable struct {
+	keys    []string
+	records [][]byte
+	pos     int
+}
+
+func (f *fakeSstable) WriteProto(ctx context.Context, key string,
+	msg proto.Message) error {
+	var data []byte
+	var err error
+
+	if data, err = proto.Marshal(msg); err != nil {
+		return err
+	}
+
+	f.keys = append(f.keys, key)
+	f.records = append(f.records, data)
+	return nil
+}
+
+func (f *fakeSstable) ReadNextProto(ctx context.Context,
+	msg proto.Message) (string, error) {
+	if f.pos >= len(f.records) {
+		return "", io.EOF
+	}
+
+	f.pos++
+	return f.keys[f.pos-1], proto.Unmarshal(f.records[f.pos-1], msg)
+}
+
+func (f *fakeSstable) ReadSubsequentProto(ctx context.Context, key string,
+	msg proto.Message) (string, error) {
+	for f.pos = 0; f.pos < len(f.keys) && f.keys[f.pos] < key; f.pos++ {
+	}
+
+	return f.ReadNextProto(ctx, msg)
+}
+
+/*
+writeBlocks writes n rows with content of the specified size to sst, using
+the specified compression.
+*/
+func writeBlocks(sst *fakeSstable, n, size int,
+	compression redcloud.Compression) error {
+	var w = newBlockWriter(sst, compression)
+	var i int
+	var err error
+
+	for i = 0; i < n; i++ {
+		var key = fmt.Sprintf("row%02d", i)
+
+		if err = w.WriteProto(context.Background(), key, singleColumnRow(
+			key, "col", dataColumn(1, 0, strings.Repeat("x", size)))); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush(context.Background())
+}
+
+/*
+readKeys reads all remaining rows from r and appends their keys to keys.
+*/
+func readKeys(r *BlockReader, keys []string) ([]string, error) {
+	var err error
+
+	for {
+		var cf redcloud.ColumnFamily
+		var key string
+
+		if key, err = r.ReadNextProto(context.Background(), &cf); err == io.EOF {
+			return keys, nil
+		} else if err != nil {
+			return keys, err
+		}
+		if key != string(cf.Key) {
+			return keys, fmt.Errorf("Key %s returned for row %s", key, cf.Key)
+		}
+
+		keys = append(keys, key)
+	}
+}
+
+func TestBlockWriterRoundTrip(t *testing.T) {
+	var cases = []struct {
+		name        string
+		rows        int
+		size        int
+		compression redcloud.Compression
+		blocks      int
+	}{
+		{"empty", 0, 10, redcloud.Compression_UNCOMPRESSED, 0},
+		{"single block", 5, 10, redcloud.Compression_UNCOMPRESSED, 1},
+		{"multiple blocks", 10, sstableBlockSize / 3,
+			redcloud.Compression_UNCOMPRESSED, 4},
+		{"compressed", 10, sstableBlockSize / 3,
+			redcloud.Compression_GZIP, 4},
+	}
+	var i int
+
+	for i = range cases {
+		var sst fakeSstable
+		var keys []string
+		var err error
+
+		if err = writeBlocks(&sst, cases[i].rows, cases[i].size,
+			cases[i].compression); err != nil {
+			t.Errorf("%s: error writing rows: %s", cases[i].name, err)
+			continue
+		}
+		if len(sst.records) != cases[i].blocks {
+			t.Errorf("%s: unexpected number of blocks: got %d, want %d",
+				cases[i].name, len(sst.records), cases[i].blocks)
+		}
+
+		if keys, err = readKeys(newBlockReader(&sst), nil); err != nil {
+			t.Errorf("%s: error reading rows: %s", cases[i].name, err)
+		}
+		if len(keys) != cases[i].rows {
+			t.Errorf("%s: unexpected rows read: %v", cases[i].name, keys)
+		}
+	}
+}
+
+func TestBlockReaderSeek(t *testing.T) {
+	var cases = []struct {
+		key      string
+		expected string
+	}{
+		{"", "[row00 row01 row02 row03 row04 row05]"},
+		{"row03", "[row03 row04 row05]"},
+		{"row025", "[row03 row04 row05]"},
+		{"row05", "[row05]"},
+		{"row06", "[]"},
+	}
+	var sst fakeSstable
+	var i int
+	var err error
+
+	if err = writeBlocks(&sst, 6, sstableBlockSize/3,
+		redcloud.Compression_DEFLATE_FAST); err != nil {
+		t.Fatalf("Error writing rows: %s", err)
+	}
+
+	for i = range cases {
+		var r = newBlockReader(&sst)
+		var cf redcloud.ColumnFamily
+		var keys []string
+		var key string
+
+		if key, err = r.ReadSubsequentProto(
+			context.Background(), cases[i].key, &cf); err == nil {
+			keys, err = readKeys(r, []string{key})
+		}
+		if err != nil && err != io.EOF {
+			t.Errorf("Error reading from %s: %s", cases[i].key, err)
+			continue
+		}
+
+		if fmt.Sprint(keys) != cases[i].expected {
+			t.Errorf("Unexpected rows from %s: got %v, want %s",
+				cases[i].key, keys, cases[i].expected)
+		}
+	}
+}
+
+func TestBlockReaderCorrupted(t *testing.T) {
+	var expected = map[string]error{
+		"intact":    nil,
+		"corrupted": common.ErrChecksumMismatch,
+		"flag lost": common.ErrChecksumMismatch,
+	}
+	var name string
+	var want error
+
+	for name, want = range expected {
+		var sst fakeSstable
+		var block redcloud.SSTableBlock
+		var cf redcloud.ColumnFamily
+		var err error
+
+		if err = writeBlocks(
+			&sst, 3, 100, redcloud.Compression_DEFLATE_BEST); err != nil {
+			t.Errorf("Error writing %s block: %s", name, err)
+			continue
+		}
+
+		if err = proto.Unmarshal(sst.records[0], &block); err != nil {
+			t.Errorf("Error decoding %s block: %s", name, err)
+			continue
+		}
+		switch name {
+		case "corrupted":
+			block.Data[len(block.Data)-1] ^= 1
+		case "flag lost":
+			block.HasChecksum = false
+		}
+		if sst.records[0], err = proto.Marshal(&block); err != nil {
+			t.Errorf("Error encoding %s block: %s", name, err)
+			continue
+		}
+
+		if _, err = newBlockReader(&sst).ReadNextProto(
+			context.Background(), &cf); err != want {
+			t.Errorf("Unexpected result reading %s block: got %v, want %v",
+				name, err, want)
+		}
+	}
+}
+
+func TestBlockReaderLegacyRows(t *testing.T) {
+	var sst fakeSstable
+	var keys []string
+	var key string
+	var err error
+
+	for _, key = range []string{"a", "b", "c"} {
+		if err = sst.WriteProto(context.Background(), key, singleColumnRow(
+			key, "col", dataColumn(1, 0, "data"))); err != nil {
+			t.Fatalf("Error writing row %s: %s", key, err)
+		}
+	}
+
+	if keys, err = readKeys(newBlockReader(&sst), nil); err != nil {
+		t.Errorf("Error reading legacy rows: %s", err)
+	}
+	if fmt.Sprint(keys) != "[a b c]" {
+		t.Errorf("Unexpected legacy rows: %v", keys)
+	}
+}
@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/childoftheuniverse/filesystem"
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opencensus.io/trace"
+)
+
+var readerCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_reader_cache_hits",
+	Help:      "Number of sstable lookups served by an already open reader",
+})
+var readerCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_reader_cache_misses",
+	Help:      "Number of sstable lookups which had to open the sstable",
+})
+var readerCacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_reader_cache_evictions",
+	Help:      "Number of open sstable readers closed to make room for others",
+})
+var blockCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_block_cache_hits",
+	Help:      "Number of sstable point lookups served from the block cache",
+})
+var blockCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "num_block_cache_misses",
+	Help:      "Number of sstable point lookups not found in the block cache",
+})
+var blockCacheBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+	Namespace: "red_cloud",
+	Subsystem: "storage",
+	Name:      "block_cache_bytes",
+	Help:      "Size of the decoded records held in the block cache",
+})
+
+func init() {
+	prometheus.MustRegister(readerCacheHits)
+	prometheus.MustRegister(readerCacheMisses)
+	prometheus.MustRegister(readerCacheEvictions)
+	prometheus.MustRegister(blockCacheHits)
+	prometheus.MustRegister(blockCacheMisses)
+	prometheus.MustRegister(blockCacheBytes)
+}
+
+/*
+sstableCache is the cache used by all sstable lookups, or nil if sstables
+are opened for every lookup.
+*/
+var sstableCache *SstableCache
+
+/*
+SetSstableCache sets the cache to be used by all sstable lookups. It is
+expected to be called before any lookups are made.
+*/
+func SetSstableCache(c *SstableCache) {
+	sstableCache = c
+}
+
+/*
+InvalidateSstable removes everything cached about the sstable at the
+specified path from the cache used by sstable lookups. This has to be done
+whenever an sstable is replaced or no longer served.
+*/
+func InvalidateSstable(path string) {
+	sstableCache.Invalidate(path)
+}
+
+/*
+cachedReader is an open sstable reader along with the files it reads from.
+*/
+type cachedReader struct {
+	path     string
+	reader   *BlockReader
+	sst, idx filesystem.ReadCloser
+
+	// Position in the list of idle readers, while the reader is idle.
+	elem *list.Element
+}
+
+/*
+close closes the files underlying the reader.
+*/
+func (r *cachedReader) close() {
+	var ctx = context.Background()
+
+	r.sst.Close(ctx)
+	r.idx.Close(ctx)
+}
+
+/*
+cachedBlock is a decoded sstable record held in the block cache.
+*/
+type cachedBlock struct {
+	path string
+	key  string
+	row  *redcloud.ColumnFamily
+	size int64
+}
+
+/*
+SstableCache keeps sstable readers open between lookups, so the sstable and
+its index don't have to be opened and parsed again for every lookup, and
+holds decoded records for repeated point lookups of the same keys. Both are
+bounded in size and evict the least recently used entries first. A nil
+SstableCache caches nothing.
+
+Readers keep track of a position in the sstable, so each of them is only
+used by one lookup at a time. They are repositioned through the index for
+every lookup.
+*/
+type SstableCache struct {
+	lock sync.Mutex
+
+	// Maximum number of idle readers and size of the cached records.
+	maxReaders    int
+	maxBlockBytes int64
+
+	// Idle readers by path, and all idle readers, least recently used first.
+	idle    map[string][]*cachedReader
+	idleLRU *list.List
+
+	// Number of readers of each path currently used by lookups.
+	inUse map[string]int
+
+	/*
+		Paths which have been invalidated while readers were in use. These
+		readers will be closed once the lookups are done with them.
+	*/
+	retired map[string]bool
+
+	/*
+		Cached records by path and key, and all of them, least recently used
+		first.
+	*/
+	blocks     map[string]map[string]*list.Element
+	blockLRU   *list.List
+	blockBytes int64
+}
+
+/*
+NewSstableCache creates a new SstableCache keeping up to maxReaders readers
+open while they're not in use, and up to maxBlockBytes bytes of decoded
+records.
+*/
+func NewSstableCache(maxReaders int, maxBlockBytes int64) *SstableCache {
+	return &SstableCache{
+		maxReaders:    maxReaders,
+		maxBlockBytes: maxBlockBytes,
+		idle:          make(map[string][]*cachedReader),
+		idleLRU:       list.New(),
+		inUse:         make(map[string]int),
+		retired:       make(map[string]bool),
+		blocks:        make(map[string]map[string]*list.Element),
+		blockLRU:      list.New(),
+	}
+}
+
+/*
+acquire obtains a reader for the sstable at the specified path, opening the
+sstable if there's no idle reader for it. The reader has to be handed back
+through release once the lookup is done.
+*/
+func (c *SstableCache) acquire(ctx context.Context, span *trace.Span,
+	path string) (*cachedReader, error) {
+	var r = &cachedReader{path: path}
+	var readers []*cachedReader
+	var err error
+
+	if c != nil {
+		c.lock.Lock()
+		if readers = c.idle[path]; len(readers) > 0 {
+			r = readers[len(readers)-1]
+			c.setIdle(path, readers[:len(readers)-1])
+			c.idleLRU.Remove(r.elem)
+			r.elem = nil
+			c.inUse[path]++
+			c.lock.Unlock()
+
+			readerCacheHits.Inc()
+			return r, nil
+		}
+		c.inUse[path]++
+		c.lock.Unlock()
+
+		readerCacheMisses.Inc()
+	}
+
+	if r.reader, r.sst, r.idx, err = openSstable(ctx, span, path); err != nil {
+		c.release(r, false)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+/*
+release hands back a reader obtained through acquire. If "reusable" is not
+set, e.g. because reading failed, the reader is closed rather than kept for
+other lookups.
+*/
+func (c *SstableCache) release(r *cachedReader, reusable bool) {
+	var evicted []*cachedReader
+	var oldest *cachedReader
+
+	if c == nil {
+		if r.reader != nil {
+			r.close()
+		}
+		return
+	}
+
+	c.lock.Lock()
+	c.inUse[r.path]--
+	if c.inUse[r.path] == 0 {
+		delete(c.inUse, r.path)
+	}
+
+	if r.reader == nil || !reusable || c.retired[r.path] {
+		if c.inUse[r.path] == 0 {
+			delete(c.retired, r.path)
+		}
+		c.lock.Unlock()
+
+		if r.reader != nil {
+			r.close()
+		}
+		return
+	}
+
+	r.elem = c.idleLRU.PushBack(r)
+	c.idle[r.path] = append(c.idle[r.path], r)
+
+	for c.idleLRU.Len() > c.maxReaders {
+		oldest = c.idleLRU.Remove(c.idleLRU.Front()).(*cachedReader)
+		c.removeIdle(oldest)
+		evicted = append(evicted, oldest)
+	}
+	c.lock.Unlock()
+
+	// Close files without holding up other lookups.
+	for _, oldest = range evicted {
+		readerCacheEvictions.Inc()
+		oldest.close()
+	}
+}
+
+/*
+setIdle updates the list of idle readers of the path. Expects the cache lock
+to be held.
+*/
+func (c *SstableCache) setIdle(path string, readers []*cachedReader) {
+	if len(readers) == 0 {
+		delete(c.idle, path)
+	} else {
+		c.idle[path] = readers
+	}
+}
+
+/*
+removeIdle removes the reader from the idle readers of its path. Expects the
+cache lock to be held.
+*/
+func (c *SstableCache) removeIdle(r *cachedReader) {
+	var readers = c.idle[r.path]
+	var i int
+
+	for i = range readers {
+		if readers[i] == r {
+			c.setIdle(r.path, append(readers[:i], readers[i+1:]...))
+			return
+		}
+	}
+}
+
+/*
+getBlock looks up the decoded record of the key in the sstable at the
+specified path. The caller may modify the returned record.
+*/
+func (c *SstableCache) getBlock(path string, key []byte) (
+	*redcloud.ColumnFamily, bool) {
+	var elem *list.Element
+	var ok bool
+
+	if c == nil || c.maxBlockBytes <= 0 {
+		return nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok = c.blocks[path][string(key)]; !ok {
+		blockCacheMisses.Inc()
+		return nil, false
+	}
+
+	blockCacheHits.Inc()
+	c.blockLRU.MoveToBack(elem)
+	return proto.Clone(elem.Value.(*cachedBlock).row).(*redcloud.ColumnFamily),
+		true
+}
+
+/*
+putBlock adds the decoded record of the key in the sstable at the specified
+path to the block cache, evicting the least recently used records if the
+cache is full.
+*/
+func (c *SstableCache) putBlock(path string, key []byte,
+	row *redcloud.ColumnFamily) {
+	var block *cachedBlock
+	var ok bool
+
+	if c == nil || c.maxBlockBytes <= 0 {
+		return
+	}
+
+	block = &cachedBlock{
+		path: path,
+		key:  string(key),
+		row:  proto.Clone(row).(*redcloud.ColumnFamily),
+		size: int64(proto.Size(row)),
+	}
+
+	if block.size > c.maxBlockBytes {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok = c.blocks[path][block.key]; ok {
+		return
+	}
+	if c.blocks[path] == nil {
+		c.blocks[path] = make(map[string]*list.Element)
+	}
+
+	c.blocks[path][block.key] = c.blockLRU.PushBack(block)
+	c.blockBytes += block.size
+
+	for c.blockBytes > c.maxBlockBytes {
+		c.removeBlock(c.blockLRU.Front())
+	}
+
+	blockCacheBytes.Set(float64(c.blockBytes))
+}
+
+/*
+removeBlock removes the cached record from the block cache. Expects the
+cache lock to be held.
+*/
+func (c *SstableCache) removeBlock(elem *list.Element) {
+	var block = c.blockLRU.Remove(elem).(*cachedBlock)
+
+	delete(c.blocks[block.path], block.key)
+	if len(c.blocks[block.path]) == 0 {
+		delete(c.blocks, block.path)
+	}
+	c.blockBytes -= block.size
+}
+
+/*
+Invalidate removes all readers and records of the sstable at the specified
+path from the cache. Readers currently in use are closed as soon as they are
+released.
+*/
+func (c *SstableCache) Invalidate(path string) {
+	var readers []*cachedReader
+	var r *cachedReader
+	var elem *list.Element
+
+	if c == nil || len(path) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	readers = c.idle[path]
+	delete(c.idle, path)
+	for _, r = range readers {
+		c.idleLRU.Remove(r.elem)
+	}
+
+	if c.inUse[path] > 0 {
+		c.retired[path] = true
+	}
+
+	for _, elem = range c.blocks[path] {
+		c.removeBlock(elem)
+	}
+	blockCacheBytes.Set(float64(c.blockBytes))
+	c.lock.Unlock()
+
+	for _, r = range readers {
+		r.close()
+	}
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/childoftheuniverse/red-cloud"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestBlockCacheEviction(t *testing.T) {
+	var rowSize = int64(proto.Size(
+		singleColumnRow("key1", "col", dataColumn(1, 0, "data"))))
+	var cache = NewSstableCache(1, 2*rowSize)
+	var expected = map[string]bool{
+		"key1": false,
+		"key2": true,
+		"key3": true,
+	}
+	var key string
+	var want, ok bool
+
+	cache.putBlock("sst", []byte("key1"),
+		singleColumnRow("key1", "col", dataColumn(1, 0, "data")))
+	cache.putBlock("sst", []byte("key2"),
+		singleColumnRow("key2", "col", dataColumn(1, 0, "data")))
+	cache.putBlock("sst", []byte("key3"),
+		singleColumnRow("key3", "col", dataColumn(1, 0, "data")))
+
+	for key, want = range expected {
+		if _, ok = cache.getBlock("sst", []byte(key)); ok != want {
+			t.Errorf("Unexpected cache state for %s: got %v, want %v",
+				key, ok, want)
+		}
+	}
+
+	if cache.blockBytes != 2*rowSize {
+		t.Errorf("Unexpected cache size: got %d, want %d",
+			cache.blockBytes, 2*rowSize)
+	}
+}
+
+func TestBlockCacheInvalidate(t *testing.T) {
+	var cache = NewSstableCache(1, 1048576)
+	var row *redcloud.ColumnFamily
+	var ok bool
+
+	cache.putBlock("sst1", []byte("key"),
+		singleColumnRow("key", "col", dataColumn(1, 0, "data")))
+	cache.putBlock("sst2", []byte("key"),
+		singleColumnRow("key", "col", dataColumn(1, 0, "data")))
+
+	// Modifying returned records must not affect the cache.
+	if row, ok = cache.getBlock("sst1", []byte("key")); !ok {
+		t.Fatal("Record missing from cache")
+	}
+	row.ColumnSet = nil
+	if row, ok = cache.getBlock("sst1", []byte("key")); !ok ||
+		len(row.ColumnSet) != 1 {
+		t.Errorf("Cached record was modified: %v", row)
+	}
+
+	cache.Invalidate("sst1")
+
+	if _, ok = cache.getBlock("sst1", []byte("key")); ok {
+		t.Error("Record of invalidated sstable still cached")
+	}
+	if _, ok = cache.getBlock("sst2", []byte("key")); !ok {
+		t.Error("Record of other sstable was invalidated")
+	}
+}
+
+func TestNilSstableCache(t *testing.T) {
+	var cache *SstableCache
+	var ok bool
+
+	cache.putBlock("sst", []byte("key"),
+		singleColumnRow("key", "col", dataColumn(1, 0, "data")))
+	if _, ok = cache.getBlock("sst", []byte("key")); ok {
+		t.Error("Nil cache returned a record")
+	}
+	cache.Invalidate("sst")
+}
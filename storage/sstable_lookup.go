@@ -1,10 +1,10 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/url"
-	"sort"
 
 	"github.com/childoftheuniverse/filesystem"
 	"github.com/childoftheuniverse/red-cloud"
@@ -40,93 +40,180 @@ func init() {
 }
 
 /*
-LookupInSstable finds all records in the specified key range in the specified
-file matching the selected columns. Deadlines and cancellations from the
-context "ctx" are taken into account. Any results will be reported through
-the channel "results", errors will be reported through "errors", and "done"
-will be marked as soon as processing the file has completed.
-
-columns is expected to be sorted (see sort.Strings).
+openSstable opens the sstable and index files with the specified path prefix
+and creates a reader for the rows stored in them. The files have to be
+closed by the caller once the reader is no longer used.
 */
-func LookupInSstable(parentCtx context.Context, path string, columns []string,
-	kr *common.KeyRange, results chan *redcloud.ColumnFamily,
-	errors chan error, done chan struct{}) {
-	var ctx context.Context
-	var span *trace.Span
-	var cf *redcloud.ColumnFamily
+func openSstable(ctx context.Context, span *trace.Span, path string) (
+	*BlockReader, filesystem.ReadCloser, filesystem.ReadCloser, error) {
 	var reader *sstable.Reader
 	var sst, idx filesystem.ReadCloser
 	var sstu, idxu *url.URL
-	var key string
 	var err error
 
-	ctx, span = trace.StartSpan(parentCtx, "red-cloud.storage/LookupInSstable")
-	defer span.End()
-	sstableLookups.Inc()
-
-	span.AddAttributes(trace.StringAttribute("path", path))
-
-	/*
-		As soon as this function exits, successul or not, there's no point in
-		waiting for the result any longer.
-	*/
-	defer markDone(done)
-
 	if sstu, err = url.Parse(path + ".sst"); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Invalid sstable path URL")
 		sstableLookupErrors.With(
 			prometheus.Labels{"error_class": "parse_file_name"}).Inc()
-		errors <- err
-		return
+		return nil, nil, nil, err
 	}
 	if sst, err = filesystem.OpenReader(ctx, sstu); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Unable to open sstable for reading")
 		sstableLookupErrors.With(
 			prometheus.Labels{"error_class": "open_sstable_readonly"}).Inc()
-		errors <- err
-		return
+		return nil, nil, nil, err
 	}
-	defer sst.Close(ctx)
 
 	if idxu, err = url.Parse(path + ".idx"); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Invalid sstable index path URL")
 		sstableLookupErrors.With(
 			prometheus.Labels{"error_class": "parse_file_name"}).Inc()
-		errors <- err
-		return
+		sst.Close(ctx)
+		return nil, nil, nil, err
 	}
 	if idx, err = filesystem.OpenReader(ctx, idxu); err != nil {
 		span.AddAttributes(trace.StringAttribute("error", err.Error()))
 		span.Annotate(nil, "Unable to open sstable index for reading")
 		sstableLookupErrors.With(
 			prometheus.Labels{"error_class": "open_sstable_readonly"}).Inc()
-		errors <- err
-		return
+		sst.Close(ctx)
+		return nil, nil, nil, err
 	}
-	defer idx.Close(ctx)
 
 	if reader, err = sstable.NewReaderWithIdx(
 		ctx, sst, idx, false); err != nil {
+		sst.Close(ctx)
+		idx.Close(ctx)
+		return nil, nil, nil, err
+	}
+
+	return NewBlockReader(reader), sst, idx, nil
+}
+
+/*
+reportSstableReadError records an error reading rows from an sstable in the
+span and the error metrics.
+*/
+func reportSstableReadError(span *trace.Span, err error) {
+	var class = "read_error"
+
+	span.AddAttributes(trace.StringAttribute("error", err.Error()))
+	if err == common.ErrChecksumMismatch {
+		span.Annotate(nil, "Corrupted block in sstable")
+		class = "checksum_mismatch"
+	} else {
+		span.Annotate(nil, "Error reading from sstable")
+	}
+
+	sstableLookupErrors.With(prometheus.Labels{"error_class": class}).Inc()
+}
+
+/*
+selectRow extracts the columns matching the selector from the row. Returns
+nil if no columns match, or if the key of the row doesn't match the key
+conditions of the filter.
+*/
+func selectRow(cf *redcloud.ColumnFamily, columns *ColumnSelector,
+	filter *RowFilter) *redcloud.ColumnFamily {
+	var rcf *redcloud.ColumnFamily
+	var cs *redcloud.ColumnSet
+
+	// Rows with keys not matching the filter are skipped entirely.
+	if !filter.MatchesKey(cf.Key) {
+		return nil
+	}
+
+	for _, cs = range cf.ColumnSet {
+		var selected *redcloud.ColumnSet
+		if selected = SelectColumns(cs, columns); selected != nil {
+			/*
+				Collect all matching columns in a special return
+				ColumnFamily.
+			*/
+			if rcf == nil {
+				rcf = new(redcloud.ColumnFamily)
+				rcf.Key = cf.Key
+			}
+
+			rcf.ColumnSet = append(rcf.ColumnSet, selected)
+		}
+	}
+
+	return rcf
+}
+
+/*
+LookupInSstable finds all records in the specified key range in the specified
+file matching the selected columns and the key conditions of the filter
+(see RowFilter.MatchesKey). Deadlines and cancellations from the
+context "ctx" are taken into account. Any results will be reported through
+the channel "results", errors will be reported through "errors", and "done"
+will be marked as soon as processing the file has completed.
+
+Lookups of a single key are served from the block cache if possible.
+*/
+func LookupInSstable(parentCtx context.Context, path string,
+	columns *ColumnSelector, filter *RowFilter, kr *common.KeyRange,
+	results chan *redcloud.ColumnFamily, errors chan error,
+	done chan struct{}) {
+	var ctx context.Context
+	var span *trace.Span
+	var cf *redcloud.ColumnFamily
+	var rcf *redcloud.ColumnFamily
+	var r *cachedReader
+	// Empty end keys denote the end of the table.
+	var pointLookup = len(kr.EndKey) > 0 &&
+		bytes.Equal(kr.StartKey, kr.EndKey)
+	var cached bool
+	var key string
+	var err error
+
+	ctx, span = trace.StartSpan(parentCtx, "red-cloud.storage/LookupInSstable")
+	defer span.End()
+	sstableLookups.Inc()
+
+	span.AddAttributes(trace.StringAttribute("path", path))
+
+	/*
+		As soon as this function exits, successul or not, there's no point in
+		waiting for the result any longer.
+	*/
+	defer markDone(done)
+
+	if pointLookup {
+		if cf, cached = sstableCache.getBlock(path, kr.StartKey); cached {
+			span.Annotate(nil, "Served from block cache")
+			if rcf = selectRow(cf, columns, filter); rcf != nil {
+				sstableLookupNumResults.Inc()
+				results <- rcf
+			}
+			return
+		}
+	}
+
+	if r, err = sstableCache.acquire(ctx, span, path); err != nil {
 		errors <- err
 		return
 	}
+	// Readers which failed may be in an inconsistent state.
+	defer func() {
+		sstableCache.release(r, err == nil || err == io.EOF)
+	}()
 
 	cf = new(redcloud.ColumnFamily)
 
-	if key, err = reader.ReadSubsequentProto(
+	if key, err = r.reader.ReadSubsequentProto(
 		ctx, string(kr.StartKey), cf); err == io.EOF {
 		return
 	} else if err != nil {
+		reportSstableReadError(span, err)
 		errors <- err
 		return
 	}
 	for {
-		var rcf *redcloud.ColumnFamily
-		var cs *redcloud.ColumnSet
-
 		if !kr.Contains([]byte(key)) {
 			// The key is no longer in our range, so we don't care about it.
 			return
@@ -141,31 +228,24 @@ func LookupInSstable(parentCtx context.Context, path string, columns []string,
 			return
 		}
 
-		for _, cs = range cf.ColumnSet {
-			var off int
-			off = sort.SearchStrings(columns, cs.Name)
-			if off >= 0 && off < len(columns) && columns[off] == cs.Name {
-				/*
-					Collect all matching columns in a special return
-					ColumnFamily.
-				*/
-				if rcf == nil {
-					rcf = new(redcloud.ColumnFamily)
-					rcf.Key = cf.Key
-				}
-
-				rcf.ColumnSet = append(rcf.ColumnSet, cs)
-			}
+		if pointLookup {
+			sstableCache.putBlock(path, cf.Key, cf)
 		}
 
-		if rcf != nil {
+		if rcf = selectRow(cf, columns, filter); rcf != nil {
 			sstableLookupNumResults.Inc()
 			results <- rcf
 		}
 
-		if key, err = reader.ReadNextProto(ctx, cf); err == io.EOF {
+		// There can't be any further records for the key.
+		if pointLookup {
+			return
+		}
+
+		if key, err = r.reader.ReadNextProto(ctx, cf); err == io.EOF {
 			return
 		} else if err != nil {
+			reportSstableReadError(span, err)
 			errors <- err
 			return
 		}
@@ -12,20 +12,66 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+//
+// Compression codec applied to the blocks of rows stored in sstables. Every
+// block is compressed on its own; blocks which wouldn't get any smaller are
+// stored uncompressed. All codecs are implemented with the Go standard
+// library.
+type Compression int32
+
+const (
+	// Data is stored as is.
+	Compression_UNCOMPRESSED Compression = 0
+	//
+	// DEFLATE at its fastest level, trading compression ratio for speed like
+	// snappy does. For tables which are written to a lot.
+	Compression_DEFLATE_FAST Compression = 1
+	//
+	// DEFLATE at its best compression level, favouring compression ratio like
+	// zstd does. For tables which are mostly read.
+	Compression_DEFLATE_BEST Compression = 2
+	// gzip at its default level.
+	Compression_GZIP Compression = 3
+)
+
+var Compression_name = map[int32]string{
+	0: "UNCOMPRESSED",
+	1: "DEFLATE_FAST",
+	2: "DEFLATE_BEST",
+	3: "GZIP",
+}
+var Compression_value = map[string]int32{
+	"UNCOMPRESSED": 0,
+	"DEFLATE_FAST": 1,
+	"DEFLATE_BEST": 2,
+	"GZIP":         3,
+}
+
+func (x Compression) String() string {
+	return proto.EnumName(Compression_name, int32(x))
+}
+func (Compression) EnumDescriptor() ([]byte, []int) { return fileDescriptor4, []int{0} }
+
 type Column_ColumnContentType int32
 
 const (
-	Column_DATA      Column_ColumnContentType = 0
-	Column_TOMBSTONE Column_ColumnContentType = 1
+	Column_DATA            Column_ColumnContentType = 0
+	Column_TOMBSTONE       Column_ColumnContentType = 1
+	Column_RANGE_TOMBSTONE Column_ColumnContentType = 2
+	Column_COUNTER         Column_ColumnContentType = 3
 )
 
 var Column_ColumnContentType_name = map[int32]string{
 	0: "DATA",
 	1: "TOMBSTONE",
+	2: "RANGE_TOMBSTONE",
+	3: "COUNTER",
 }
 var Column_ColumnContentType_value = map[string]int32{
-	"DATA":      0,
-	"TOMBSTONE": 1,
+	"DATA":            0,
+	"TOMBSTONE":       1,
+	"RANGE_TOMBSTONE": 2,
+	"COUNTER":         3,
 }
 
 func (x Column_ColumnContentType) String() string {
@@ -51,7 +97,13 @@ type Column struct {
 	//
 	// Type of the data contained in the column. In journals and minor sstables,
 	// this might be set to TOMBSTONE to represent that data in the column has
-	// been deleted.
+	// been deleted, or to RANGE_TOMBSTONE to represent that data in all columns
+	// from the name of the ColumnSet up to end_column has been deleted.
+	// Tombstones cover all data with a timestamp not newer than their own.
+	//
+	// COUNTER columns hold a signed 64-bit integer in big endian byte order as
+	// their content. Rather than replacing older versions, they are deltas:
+	// the value of the counter is the sum of all its versions.
 	Type Column_ColumnContentType `protobuf:"varint,1,opt,name=type,enum=redcloud.Column_ColumnContentType" json:"type,omitempty"`
 	//
 	// timestamp describes the exact point in time, encoded as milliseconds,
@@ -68,6 +120,11 @@ type Column struct {
 	Ttl int64 `protobuf:"varint,3,opt,name=ttl" json:"ttl,omitempty"`
 	// content contains the data actually written to the column.
 	Content []byte `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	//
+	// For RANGE_TOMBSTONE columns, end_column contains the name of the first
+	// column no longer covered by the tombstone, or an empty string to cover
+	// all columns following the name of the ColumnSet.
+	EndColumn string `protobuf:"bytes,5,opt,name=end_column,json=endColumn" json:"end_column,omitempty"`
 }
 
 func (m *Column) Reset()                    { *m = Column{} }
@@ -103,6 +160,13 @@ func (m *Column) GetContent() []byte {
 	return nil
 }
 
+func (m *Column) GetEndColumn() string {
+	if m != nil {
+		return m.EndColumn
+	}
+	return ""
+}
+
 //
 // ColumnSet is a list of data records for a single column within a single row.
 type ColumnSet struct {
@@ -141,6 +205,18 @@ type ColumnFamily struct {
 	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	// Many ColumnSets make a ColumnFamily.
 	ColumnSet []*ColumnSet `protobuf:"bytes,2,rep,name=column_set,json=columnSet" json:"column_set,omitempty"`
+	//
+	// CRC-32C checksum of checksummed_data as stored in journals, if
+	// has_checksum is set. Never sent to clients.
+	Checksum uint32 `protobuf:"varint,3,opt,name=checksum" json:"checksum,omitempty"`
+	//
+	// Key and column sets of a record stored in a journal, encoded as a
+	// ColumnFamily, which is protected by checksum. Records written before
+	// checksums were introduced hold their data in key and column_set
+	// instead, and are not verified.
+	ChecksummedData []byte `protobuf:"bytes,6,opt,name=checksummed_data,json=checksummedData,proto3" json:"checksummed_data,omitempty"`
+	// Set if the record is stored in checksummed_data.
+	HasChecksum bool `protobuf:"varint,7,opt,name=has_checksum,json=hasChecksum" json:"has_checksum,omitempty"`
 }
 
 func (m *ColumnFamily) Reset()                    { *m = ColumnFamily{} }
@@ -162,33 +238,158 @@ func (m *ColumnFamily) GetColumnSet() []*ColumnSet {
 	return nil
 }
 
+func (m *ColumnFamily) GetChecksum() uint32 {
+	if m != nil {
+		return m.Checksum
+	}
+	return 0
+}
+
+func (m *ColumnFamily) GetChecksummedData() []byte {
+	if m != nil {
+		return m.ChecksummedData
+	}
+	return nil
+}
+
+func (m *ColumnFamily) GetHasChecksum() bool {
+	if m != nil {
+		return m.HasChecksum
+	}
+	return false
+}
+
+//
+// SSTableBlock is a record of an sstable holding a block of consecutive rows.
+// It is stored under the key of the last row it holds, so seeking to a key
+// finds the only block which may hold the key.
+type SSTableBlock struct {
+	//
+	// Sstables written before blocks were introduced hold a single row in
+	// every record, stored as a ColumnFamily. These are read as blocks with
+	// only key and column_set set.
+	Key       []byte       `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ColumnSet []*ColumnSet `protobuf:"bytes,2,rep,name=column_set,json=columnSet" json:"column_set,omitempty"`
+	// Set on all blocks.
+	HasChecksum bool `protobuf:"varint,16,opt,name=has_checksum,json=hasChecksum" json:"has_checksum,omitempty"`
+	// CRC-32C checksum of data, exactly as stored.
+	Checksum uint32 `protobuf:"varint,17,opt,name=checksum" json:"checksum,omitempty"`
+	// The rows of the block, encoded as SSTableBlockData and compressed.
+	Data []byte `protobuf:"bytes,18,opt,name=data,proto3" json:"data,omitempty"`
+	// Compression applied to data.
+	Compression Compression `protobuf:"varint,19,opt,name=compression,enum=redcloud.Compression" json:"compression,omitempty"`
+}
+
+func (m *SSTableBlock) Reset()                    { *m = SSTableBlock{} }
+func (m *SSTableBlock) String() string            { return proto.CompactTextString(m) }
+func (*SSTableBlock) ProtoMessage()               {}
+func (*SSTableBlock) Descriptor() ([]byte, []int) { return fileDescriptor4, []int{4} }
+
+func (m *SSTableBlock) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *SSTableBlock) GetColumnSet() []*ColumnSet {
+	if m != nil {
+		return m.ColumnSet
+	}
+	return nil
+}
+
+func (m *SSTableBlock) GetHasChecksum() bool {
+	if m != nil {
+		return m.HasChecksum
+	}
+	return false
+}
+
+func (m *SSTableBlock) GetChecksum() uint32 {
+	if m != nil {
+		return m.Checksum
+	}
+	return 0
+}
+
+func (m *SSTableBlock) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *SSTableBlock) GetCompression() Compression {
+	if m != nil {
+		return m.Compression
+	}
+	return Compression_UNCOMPRESSED
+}
+
+//
+// SSTableBlockData holds the rows of an SSTableBlock, in key order.
+type SSTableBlockData struct {
+	Row []*ColumnFamily `protobuf:"bytes,1,rep,name=row" json:"row,omitempty"`
+}
+
+func (m *SSTableBlockData) Reset()                    { *m = SSTableBlockData{} }
+func (m *SSTableBlockData) String() string            { return proto.CompactTextString(m) }
+func (*SSTableBlockData) ProtoMessage()               {}
+func (*SSTableBlockData) Descriptor() ([]byte, []int) { return fileDescriptor4, []int{5} }
+
+func (m *SSTableBlockData) GetRow() []*ColumnFamily {
+	if m != nil {
+		return m.Row
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Empty)(nil), "redcloud.Empty")
 	proto.RegisterType((*Column)(nil), "redcloud.Column")
 	proto.RegisterType((*ColumnSet)(nil), "redcloud.ColumnSet")
 	proto.RegisterType((*ColumnFamily)(nil), "redcloud.ColumnFamily")
+	proto.RegisterType((*SSTableBlock)(nil), "redcloud.SSTableBlock")
+	proto.RegisterType((*SSTableBlockData)(nil), "redcloud.SSTableBlockData")
+	proto.RegisterEnum("redcloud.Compression", Compression_name, Compression_value)
 	proto.RegisterEnum("redcloud.Column_ColumnContentType", Column_ColumnContentType_name, Column_ColumnContentType_value)
 }
 
 func init() { proto.RegisterFile("types.proto", fileDescriptor4) }
 
 var fileDescriptor4 = []byte{
-	// 268 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x90, 0x31, 0x6f, 0xbb, 0x30,
-	0x10, 0xc5, 0xff, 0x0e, 0xfc, 0x49, 0xb8, 0xd0, 0x8a, 0x5e, 0x17, 0x0f, 0x1d, 0x90, 0x27, 0x86,
-	0x8a, 0x81, 0x4a, 0xdd, 0xd3, 0x34, 0x95, 0x3a, 0xb4, 0x91, 0x0c, 0x7b, 0x45, 0x89, 0x87, 0xa8,
-	0x18, 0x50, 0xb8, 0x0c, 0xfe, 0x66, 0xfd, 0x78, 0x15, 0x36, 0xa8, 0x52, 0x3a, 0xf9, 0xf9, 0xdd,
-	0x4f, 0xcf, 0xcf, 0x07, 0x6b, 0x32, 0xbd, 0x1a, 0xb2, 0xfe, 0xd4, 0x51, 0x87, 0xab, 0x93, 0x3a,
-	0xd4, 0x4d, 0x77, 0x3e, 0x88, 0x25, 0xfc, 0xdf, 0xe9, 0x9e, 0x8c, 0xf8, 0x66, 0x10, 0x6c, 0xbb,
-	0xe6, 0xac, 0x5b, 0x7c, 0x04, 0x7f, 0x84, 0x39, 0x4b, 0x58, 0x7a, 0x9d, 0x8b, 0x6c, 0x86, 0x33,
-	0x37, 0x9f, 0x8e, 0x6d, 0xd7, 0x92, 0x6a, 0xa9, 0x34, 0xbd, 0x92, 0x96, 0xc7, 0x3b, 0x08, 0xe9,
-	0xa8, 0xd5, 0x40, 0x95, 0xee, 0xf9, 0x22, 0x61, 0xa9, 0x27, 0x7f, 0x0d, 0x8c, 0xc1, 0x23, 0x6a,
-	0xb8, 0x67, 0xfd, 0x51, 0x22, 0x87, 0x65, 0xed, 0x42, 0xb8, 0x9f, 0xb0, 0x34, 0x92, 0xf3, 0x55,
-	0xdc, 0xc3, 0xcd, 0x9f, 0x47, 0x70, 0x05, 0xfe, 0xf3, 0xa6, 0xdc, 0xc4, 0xff, 0xf0, 0x0a, 0xc2,
-	0x72, 0xff, 0xf6, 0x54, 0x94, 0xfb, 0xf7, 0x5d, 0xcc, 0xc4, 0x2b, 0x84, 0x8e, 0x2e, 0x14, 0x21,
-	0x82, 0xdf, 0x56, 0xda, 0x95, 0x0f, 0xa5, 0xd5, 0x98, 0x42, 0x50, 0x5b, 0x80, 0x2f, 0x12, 0x2f,
-	0x5d, 0xe7, 0xf1, 0xe5, 0x97, 0xe4, 0x34, 0x17, 0x25, 0x44, 0xce, 0x79, 0xa9, 0xf4, 0xb1, 0x31,
-	0x63, 0xe9, 0x2f, 0x65, 0x6c, 0x58, 0x24, 0x47, 0x89, 0x39, 0x80, 0x63, 0x3f, 0x06, 0x45, 0x53,
-	0xde, 0xed, 0x65, 0x5e, 0xa1, 0x48, 0x86, 0xf5, 0x2c, 0x3f, 0x03, 0xbb, 0xf5, 0x87, 0x9f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x5d, 0x29, 0x13, 0x0f, 0x84, 0x01, 0x00, 0x00,
+	// 499 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0xed, 0xc6, 0x69, 0x12, 0x8f, 0x5d, 0xba, 0x9d, 0x0a, 0x64, 0x21, 0x90, 0x8c, 0x4f, 0x86,
+	0x43, 0x0e, 0x41, 0x82, 0x0b, 0x97, 0x7c, 0x38, 0x55, 0x25, 0x92, 0x54, 0x6b, 0xf7, 0xc2, 0xc5,
+	0xda, 0xda, 0x2b, 0x25, 0x8a, 0xbf, 0x14, 0x6f, 0x84, 0xf2, 0xe7, 0xf8, 0x2d, 0xfc, 0x0d, 0x6e,
+	0xc8, 0xeb, 0x24, 0x35, 0xee, 0x95, 0x93, 0x67, 0xdf, 0x3c, 0xbd, 0x79, 0x6f, 0xbc, 0x0b, 0x86,
+	0x3c, 0x14, 0xa2, 0x1c, 0x16, 0xbb, 0x5c, 0xe6, 0x38, 0xd8, 0x89, 0x38, 0x4a, 0xf2, 0x7d, 0xec,
+	0xf4, 0xe1, 0xd2, 0x4b, 0x0b, 0x79, 0x70, 0xfe, 0x10, 0xe8, 0x4d, 0xf3, 0x64, 0x9f, 0x66, 0xf8,
+	0x05, 0xba, 0x15, 0xd9, 0x22, 0x36, 0x71, 0x5f, 0x8d, 0x9c, 0xe1, 0x89, 0x3c, 0xac, 0xfb, 0xc7,
+	0xcf, 0x34, 0xcf, 0xa4, 0xc8, 0x64, 0x70, 0x28, 0x04, 0x53, 0x7c, 0x7c, 0x07, 0xba, 0xdc, 0xa4,
+	0xa2, 0x94, 0x3c, 0x2d, 0xac, 0x8e, 0x4d, 0x5c, 0x8d, 0x3d, 0x03, 0x48, 0x41, 0x93, 0x32, 0xb1,
+	0x34, 0x85, 0x57, 0x25, 0x5a, 0xd0, 0x8f, 0x6a, 0x11, 0xab, 0x6b, 0x13, 0xd7, 0x64, 0xa7, 0x23,
+	0xbe, 0x07, 0x10, 0x59, 0x1c, 0x46, 0x6a, 0x90, 0x75, 0x69, 0x13, 0x57, 0x67, 0xba, 0xc8, 0xe2,
+	0x7a, 0xb2, 0xb3, 0x84, 0x9b, 0x17, 0x1e, 0x70, 0x00, 0xdd, 0xd9, 0x38, 0x18, 0xd3, 0x0b, 0xbc,
+	0x02, 0x3d, 0x58, 0x2d, 0x26, 0x7e, 0xb0, 0x5a, 0x7a, 0x94, 0xe0, 0x2d, 0x5c, 0xb3, 0xf1, 0xf2,
+	0xce, 0x0b, 0x9f, 0xc1, 0x0e, 0x1a, 0xd0, 0x9f, 0xae, 0x1e, 0x97, 0x81, 0xc7, 0xa8, 0xe6, 0xdc,
+	0x83, 0x5e, 0xeb, 0xf9, 0x42, 0x22, 0x42, 0x37, 0xe3, 0x69, 0x9d, 0x5e, 0x67, 0xaa, 0x46, 0x17,
+	0x7a, 0x47, 0x2f, 0x1d, 0x5b, 0x73, 0x8d, 0x11, 0x6d, 0xef, 0x84, 0x1d, 0xfb, 0xce, 0x2f, 0x02,
+	0x66, 0x0d, 0xcd, 0x79, 0xba, 0x49, 0x0e, 0x55, 0xec, 0xad, 0x38, 0x28, 0x35, 0x93, 0x55, 0x25,
+	0x8e, 0x00, 0x6a, 0x72, 0x58, 0x0a, 0x79, 0x14, 0xbc, 0x6d, 0x0b, 0xfa, 0x42, 0x32, 0x3d, 0x3a,
+	0x9b, 0x7a, 0x0b, 0x83, 0x68, 0x2d, 0xa2, 0x6d, 0xb9, 0x4f, 0xd5, 0x06, 0xaf, 0xd8, 0xf9, 0x8c,
+	0x1f, 0x81, 0x9e, 0xea, 0x54, 0xc4, 0x61, 0xcc, 0x25, 0xb7, 0x7a, 0x6a, 0xdc, 0x75, 0x03, 0x9f,
+	0x71, 0xc9, 0xf1, 0x03, 0x98, 0x6b, 0x5e, 0x86, 0x67, 0xa9, 0xbe, 0x4d, 0xdc, 0x01, 0x33, 0xd6,
+	0xbc, 0x9c, 0x1e, 0x21, 0xe7, 0x37, 0x01, 0xd3, 0xf7, 0x03, 0xfe, 0x94, 0x88, 0x49, 0x92, 0x47,
+	0xdb, 0xff, 0x14, 0xa0, 0x3d, 0x99, 0xbe, 0x98, 0xfc, 0x4f, 0xc6, 0x9b, 0x56, 0x46, 0x84, 0xae,
+	0xca, 0x85, 0xca, 0x85, 0xaa, 0xf1, 0x2b, 0x18, 0x51, 0x9e, 0x16, 0x3b, 0x51, 0x96, 0x9b, 0x3c,
+	0xb3, 0x6e, 0xd5, 0x6d, 0x7d, 0xdd, 0xf4, 0x71, 0x6e, 0xb2, 0x26, 0xd3, 0xf9, 0x06, 0xb4, 0x99,
+	0x50, 0x6d, 0xc6, 0x05, 0x6d, 0x97, 0xff, 0xb4, 0x88, 0x0a, 0xf3, 0xa6, 0x1d, 0xa6, 0xfe, 0x97,
+	0xac, 0xa2, 0x7c, 0x5a, 0x80, 0xd1, 0x50, 0x46, 0x0a, 0xe6, 0xe3, 0x72, 0xba, 0x5a, 0x3c, 0x30,
+	0xcf, 0xf7, 0xbd, 0x19, 0xbd, 0xa8, 0x90, 0x99, 0x37, 0xff, 0x3e, 0x0e, 0xbc, 0x70, 0x3e, 0xf6,
+	0x03, 0x4a, 0x9a, 0xc8, 0xc4, 0xf3, 0x03, 0xda, 0xa9, 0x2e, 0xeb, 0xdd, 0x8f, 0xfb, 0x07, 0xaa,
+	0x3d, 0xf5, 0xd4, 0x8b, 0xfc, 0xfc, 0x37, 0x00, 0x00, 0xff, 0xff, 0xe4, 0xd6, 0x23, 0x04, 0xa0,
+	0x03, 0x00, 0x00,
 }
@@ -3,8 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/childoftheuniverse/red-cloud"
@@ -46,15 +46,19 @@ func (c *RedCloudCLI) Get(
 }
 
 /*
-printResponses formats responses of type ColumnSet and outputs them to
-the standard output channel.
+printResponses formats responses of type ColumnFamily and outputs them to
+the standard output channel, grouped by row.
 */
-func printResponses(colsets chan *redcloud.ColumnSet, cancel chan bool) {
+func printResponses(rows chan *redcloud.ColumnFamily, cancel chan bool) {
+	var row *redcloud.ColumnFamily
 	var colset *redcloud.ColumnSet
 	for {
 		select {
-		case colset = <-colsets:
-			fmt.Print(proto.MarshalTextString(colset))
+		case row = <-rows:
+			fmt.Printf("row %q:\n", row.Key)
+			for _, colset = range row.ColumnSet {
+				fmt.Print(proto.MarshalTextString(colset))
+			}
 		case <-cancel:
 			return
 		}
@@ -63,16 +67,18 @@ func printResponses(colsets chan *redcloud.ColumnSet, cancel chan bool) {
 
 /*
 GetRange fetches all specified columns from the specified
-table / column family / key and outputs the text protocol buffer to stdout.
+table / column family / key range and outputs the text protocol buffers to
+stdout, grouped by row.
 */
 func (c *RedCloudCLI) GetRange(
 	ctx context.Context, tableSpec, columnFamily string, columns []string,
 	startkey, endkey string) {
-	var resp = make(chan *redcloud.ColumnSet)
+	var resp = make(chan *redcloud.ColumnFamily)
 	var cancel = make(chan bool)
 	var dac *client.DataAccessClient
 	var req *redcloud.GetRangeRequest
 	var instance, table string
+	var column string
 	var err error
 
 	if instance, table, err = client.SplitTablePath(tableSpec); err != nil {
@@ -84,14 +90,23 @@ func (c *RedCloudCLI) GetRange(
 		EndKey:       []byte(endkey),
 		Table:        table,
 		ColumnFamily: columnFamily,
-		Column:       columns,
+	}
+
+	for _, column = range columns {
+		if column == "*" {
+			req.AllColumns = true
+		} else if strings.HasSuffix(column, "*") {
+			req.ColumnPrefix = strings.TrimSuffix(column, "*")
+		} else {
+			req.Column = append(req.Column, column)
+		}
 	}
 
 	dac = client.NewDataAccessClient(instance, c.etcdClient, c.tlsConfig)
 
 	go printResponses(resp, cancel)
 
-	if err = dac.GetRange(ctx, req, resp); err != nil && err != io.EOF {
+	if err = dac.GetRangeRows(ctx, req, resp); err != nil {
 		log.Fatalf("Error requesting column %s:%v: %s", columnFamily, columns,
 			err)
 	}
@@ -128,8 +143,41 @@ func (c *RedCloudCLI) Insert(
 
 	dac = client.NewDataAccessClient(instance, c.etcdClient, c.tlsConfig)
 
-	if err = dac.Insert(ctx, req); err != nil {
+	if _, err = dac.Insert(ctx, req); err != nil {
 		log.Fatalf("Error requesting column %s:%s: %s", columnFamily, column,
 			err)
 	}
 }
+
+/*
+Delete removes the specified column, range of columns or, if neither is
+given, the entire row from the specified table / column family / key.
+*/
+func (c *RedCloudCLI) Delete(
+	ctx context.Context, tableSpec, columnFamily, key, column string,
+	columnRange *redcloud.ColumnRange) {
+	var dac *client.DataAccessClient
+	var req *redcloud.DeleteRequest
+	var instance, table string
+	var err error
+
+	if instance, table, err = client.SplitTablePath(tableSpec); err != nil {
+		log.Fatalf("Invalid table specification: %s: %s", tableSpec, err)
+	}
+
+	req = &redcloud.DeleteRequest{
+		Key:          []byte(key),
+		Table:        table,
+		ColumnFamily: columnFamily,
+		Column:       column,
+		ColumnRange:  columnRange,
+		Timestamp:    time.Now().UnixNano() / 1000000,
+	}
+
+	dac = client.NewDataAccessClient(instance, c.etcdClient, c.tlsConfig)
+
+	if err = dac.Delete(ctx, req); err != nil {
+		log.Fatalf("Error deleting from row %s in %s: %s", key, columnFamily,
+			err)
+	}
+}
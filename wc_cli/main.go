@@ -57,7 +57,16 @@ func usage() {
 	fmt.Println("    getrange <table-path> <column-family> \\")
 	fmt.Println("             <comma-separated-cols> <startkey> <endkey>")
 	fmt.Println("        Get all data in the given column family between start")
-	fmt.Println("        and end key in the specified columns")
+	fmt.Println("        and end key in the specified columns. A column ending")
+	fmt.Println("        in * selects all columns with that prefix, * alone")
+	fmt.Println("        selects all columns")
+	fmt.Println("    delete <table-path> <column-family> <key> [<column>]")
+	fmt.Println("        Delete the specified column, or all columns if none is")
+	fmt.Println("        given, from the given table/cf/key")
+	fmt.Println("    deletecolumns <table-path> <column-family> <key> \\")
+	fmt.Println("                  <startcol> <endcol>")
+	fmt.Println("        Delete all columns between start and end column from")
+	fmt.Println("        the given table/cf/key")
 	os.Exit(0)
 }
 
@@ -181,6 +190,23 @@ func main() {
 			usage()
 		}
 		cli.Insert(ctx, flags[0], flags[1], flags[2], flags[3], flags[4])
+	case "delete":
+		if len(flags) == 3 {
+			cli.Delete(ctx, flags[0], flags[1], flags[2], "", nil)
+		} else if len(flags) == 4 {
+			cli.Delete(ctx, flags[0], flags[1], flags[2], flags[3], nil)
+		} else {
+			usage()
+		}
+	case "deletecolumns":
+		if len(flags) != 5 {
+			usage()
+		}
+		cli.Delete(ctx, flags[0], flags[1], flags[2], "",
+			&redcloud.ColumnRange{
+				StartColumn: flags[3],
+				EndColumn:   flags[4],
+			})
 	case "help":
 		usage()
 	default: